@@ -2,13 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"io"
 	"log"
+	"main/pkg/attachment"
+	"main/pkg/blob"
 	"main/pkg/bot"
 	"main/pkg/config"
+	"main/pkg/exchange"
+	"main/pkg/export"
+	"main/pkg/report"
 	"main/pkg/session"
 	"main/pkg/storage"
 	"net/http"
@@ -33,12 +39,41 @@ func main() {
 		log.Fatalf("Error unmarshalling YAML: %v", err)
 	}
 
+	var store storage.Store
 	if cfg.FeaturesConfig.SaveToDB {
-		err = storage.InitDB(cfg.Database)
+		store, err = storage.NewStore(cfg.Database)
 		if err != nil {
 			log.Panic(err)
 		}
-		defer storage.CloseDB()
+	} else {
+		store, err = storage.NewFileStore(cfg.FeaturesConfig.ResponsesFile)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.Printf("Error closing store: %v", err)
+		}
+	}()
+
+	staticRates, err := exchange.NewStaticRates(cfg.Reporting.BaseCurrency, cfg.Reporting.ExchangeRates)
+	if err != nil {
+		log.Panic(err)
+	}
+	// Imported rate history takes precedence over the configured static rates.
+	rates := exchange.NewRates(store, staticRates)
+	reports := report.NewBuilder(store, rates)
+
+	blobs, err := blob.NewFileStore(cfg.Attachments.WithDefaults().Dir)
+	if err != nil {
+		log.Panic(err)
+	}
+	attachments := attachment.NewService(blobs, store, cfg.Attachments)
+
+	exporter, err := export.New(cfg.Export)
+	if err != nil {
+		log.Panic(err)
 	}
 
 	shouldUseML := false // todo: remove boolean variable and switch to configs
@@ -53,11 +88,16 @@ func main() {
 		fmt.Printf("Predicted label: %s (%.2f%% confidence)\n", label, score*100)
 	}
 
-	myBot, err := bot.NewBot(cfg.TelegramConfig.Token, cfg.FeaturesConfig, cfg.FrequentExpenses, cfg.ExpenseCategories, cfg.SupportedCurrencies)
+	myBot, err := bot.NewBot(cfg.TelegramConfig.Token, store, reports, rates, attachments, cfg.FeaturesConfig, cfg.FrequentExpenses, cfg.Suggestions, cfg.Duplicates, exporter, cfg.ExpenseCategories, cfg.IncomeCategories, cfg.SupportedCurrencies)
 	if err != nil {
 		log.Panic(err)
 	}
 
+	// Recurring rules are only kept with a database; without one /recurring is refused, so there is nothing to run.
+	if cfg.FeaturesConfig.SaveToDB {
+		go myBot.RunRecurringRules(context.Background())
+	}
+
 	userSessions := make(map[int64]*session.UserSession)
 	myBot.StartListening(userSessions)
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/config"
+	"main/pkg/storage"
+	"os"
+	"strconv"
+)
+
+const importJSONLUsage = "usage: server import-jsonl [file] [user_id]"
+
+// runImportJSONL implements the `import-jsonl` subcommand. It saves the transactions of the responses file
+// kept without a database (features.responses_file, or the given file) into the database in one database
+// transaction, then renames the file to <file>.imported so that they are never imported twice.
+// Transactions recorded before they were scoped per user are given to user_id.
+func runImportJSONL(dbConfig config.DatabaseConfig, responsesFile string, args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("too many arguments: %s", importJSONLUsage)
+	}
+	path := responsesFile
+	if path == "" {
+		path = storage.SaveFilePath
+	}
+	if len(args) > 0 {
+		path = args[0]
+	}
+	var owner int64
+	if len(args) > 1 {
+		var err error
+		owner, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil || owner < 1 {
+			return fmt.Errorf("invalid user id %q: %s", args[1], importJSONLUsage)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	ts, err := storage.ReadJSONL(file)
+	if closeErr := file.Close(); closeErr != nil {
+		log.Printf("Error closing file: %v", closeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(ts) == 0 {
+		log.Printf("No transactions to import in %s.", path)
+		return nil
+	}
+
+	for i := range ts {
+		// The database numbers the transactions itself, and the rules of the file are not in it.
+		ts[i].ID, ts[i].RecurringRuleID = 0, 0
+		if ts[i].UserID == 0 {
+			if owner == 0 {
+				return fmt.Errorf("transaction %d of %s has no owner, pass the user id: %s", i+1, path, importJSONLUsage)
+			}
+			ts[i].UserID = owner
+		}
+		if err = ts[i].Validate(); err != nil {
+			return fmt.Errorf("invalid transaction %d of %s: %w", i+1, path, err)
+		}
+	}
+
+	store, err := storage.NewStore(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.Printf("Error closing store: %v", err)
+		}
+	}()
+
+	if _, err = store.InsertTransactions(ts); err != nil {
+		var batchErr *storage.BatchError
+		if errors.As(err, &batchErr) {
+			return fmt.Errorf("failed to import transaction %d of %s: %w", batchErr.Index+1, path, batchErr.Err)
+		}
+		return err
+	}
+	imported := path + ".imported"
+	if err = os.Rename(path, imported); err != nil {
+		return fmt.Errorf("imported %d transactions but failed to rename %s, remove it before importing again: %w", len(ts), path, err)
+	}
+	log.Printf("Imported %d transactions from %s and renamed it to %s.", len(ts), path, imported)
+	return nil
+}
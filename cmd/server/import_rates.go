@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"main/pkg/config"
+	"main/pkg/exchange"
+	"main/pkg/storage"
+	"os"
+)
+
+const importRatesUsage = "usage: server import-rates <file.xml | file.csv>..."
+
+// runImportRates implements the `import-rates` subcommand. It loads ECB reference rate XML files
+// (daily or historical) and CSV files of date,base,quote,rate rows into the exchange_rates table.
+func runImportRates(dbConfig config.DatabaseConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing file: %s", importRatesUsage)
+	}
+
+	store, err := storage.NewStore(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.Printf("Error closing store: %v", err)
+		}
+	}()
+
+	for _, path := range args {
+		rates, err := importRatesFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if err = store.UpsertExchangeRates(rates); err != nil {
+			return err
+		}
+		log.Printf("Imported %d exchange rates from %s.", len(rates), path)
+	}
+	return nil
+}
+
+// importRatesFile parses one rates file.
+func importRatesFile(path string) ([]exchange.Rate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing file: %v", err)
+		}
+	}(file)
+	return exchange.ParseFile(file)
+}
@@ -6,8 +6,14 @@ import (
 	"github.com/rs/cors"
 	"gopkg.in/yaml.v3"
 	"log"
+	"main/pkg/attachment"
+	"main/pkg/blob"
+	"main/pkg/category"
 	"main/pkg/config"
+	"main/pkg/exchange"
+	"main/pkg/export"
 	"main/pkg/handler"
+	"main/pkg/report"
 	"main/pkg/storage" // Assuming your storage functions are here
 	"net/http"         // The core HTTP package
 	"os"               // To potentially read port from environment
@@ -28,23 +34,95 @@ func main() {
 		log.Fatalf("Error unmarshalling YAML: %v", err)
 	}
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err = runMigrate(cfg.Database, os.Args[2:]); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+			return
+		case "import-jsonl":
+			if err = runImportJSONL(cfg.Database, cfg.FeaturesConfig.ResponsesFile, os.Args[2:]); err != nil {
+				log.Fatalf("Importing transactions failed: %v", err)
+			}
+			return
+		case "import-rates":
+			if err = runImportRates(cfg.Database, os.Args[2:]); err != nil {
+				log.Fatalf("Importing exchange rates failed: %v", err)
+			}
+			return
+		default:
+			log.Fatalf("Unknown command %q", os.Args[1])
+		}
+	}
+
+	var store storage.Store
 	if cfg.FeaturesConfig.SaveToDB {
-		err = storage.InitDB(cfg.Database)
+		store, err = storage.NewStore(cfg.Database)
 		if err != nil {
 			log.Fatalf("Failed to initialize database: %v", err)
 		}
-		defer storage.CloseDB()
 		log.Println("Database initialized successfully.")
 	} else {
-		log.Println("Database not configured. Transactions API might not function as expected if DB is required.")
+		store, err = storage.NewFileStore(cfg.FeaturesConfig.ResponsesFile)
+		if err != nil {
+			log.Fatalf("Failed to initialize the responses file: %v", err)
+		}
+		log.Println("Database not configured. Using the responses file; only transactions are persisted, the other features are refused.")
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.Printf("Error closing store: %v", err)
+		}
+	}()
+
+	staticRates, err := exchange.NewStaticRates(cfg.Reporting.BaseCurrency, cfg.Reporting.ExchangeRates)
+	if err != nil {
+		log.Fatalf("Invalid reporting config: %v", err)
+	}
+	// Imported rate history takes precedence over the configured static rates.
+	rates := exchange.NewRates(store, staticRates)
+	reports := report.NewBuilder(store, rates)
+
+	blobs, err := blob.NewFileStore(cfg.Attachments.WithDefaults().Dir)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+	attachments := attachment.NewService(blobs, store, cfg.Attachments)
+
+	exporter, err := export.New(cfg.Export)
+	if err != nil {
+		log.Fatalf("Invalid export config: %v", err)
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", handler.HealthCheckHandler)
-	mux.HandleFunc("/api/v1/transactions", handler.TransactionsHandler)
+	mux.Handle("/api/v1/transactions", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewTransactionsHandler(store, store, rates, cfg.Duplicates)))
+	mux.Handle("/api/v1/transactions:batch", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewTransactionsBatchHandler(store, store, rates, cfg.Duplicates)))
+	mux.Handle("/api/v1/transactions/export.csv", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewExportHandler(store, exporter)))
+	mux.Handle("/api/v1/summary", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewSummaryHandler(reports)))
+	mux.Handle("/api/v1/transactions/{id}", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewTransactionItemHandler(store, rates, attachments)))
+	// Without a database only transactions are persisted, so the features keeping anything else are refused.
+	saveToDB := cfg.FeaturesConfig.SaveToDB
+	mux.Handle("/api/v1/transactions/{id}/attachments", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Attachments", handler.NewAttachmentsHandler(attachments))))
+	mux.Handle("/api/v1/transactions/{id}/attachments/{attachmentId}", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Attachments", handler.NewAttachmentHandler(attachments))))
+	mux.Handle("/api/v1/transactions/{id}/claim", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewClaimHandler(store)))
+	mux.Handle("/api/v1/claims", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewClaimsHandler(reports)))
+	mux.Handle("/api/v1/recurring-rules", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Recurring rules", handler.NewRecurringRulesHandler(store))))
+	mux.Handle("/api/v1/recurring-rules/{id}", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Recurring rules", handler.NewRecurringRuleHandler(store))))
+	mux.Handle("/api/v1/budgets", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Budgets", handler.NewBudgetsHandler(store))))
+	mux.Handle("/api/v1/budgets/{id}", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Budgets", handler.NewBudgetHandler(store))))
+	mux.Handle("/api/v1/accounts", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Accounts", handler.NewAccountsHandler(store, reports))))
+	mux.Handle("/api/v1/accounts/{id}", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Accounts", handler.NewAccountHandler(store, reports))))
+	mux.Handle("/api/v1/accounts/{id}/balances", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabase(saveToDB, "Accounts", handler.NewAccountBalancesHandler(reports))))
+	categorySeeds := category.ParseSeeds(cfg.ExpenseCategories, cfg.IncomeCategories)
+	mux.Handle("/api/v1/categories", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabaseForChanges(saveToDB, "Categories", handler.NewCategoriesHandler(store, categorySeeds))))
+	mux.Handle("/api/v1/categories/{id}", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabaseForChanges(saveToDB, "Categories", handler.NewCategoryHandler(store))))
+	mux.Handle("/api/v1/exchange-rates", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewExchangeRateHandler(rates)))
 
-	prefilledHandler := handler.GetPrefilledExpensesHandler(cfg.FrequentExpenses)
-	mux.HandleFunc("/api/v1/prefilled-expenses", prefilledHandler)
+	mux.Handle("/api/v1/prefilled-expenses", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabaseForChanges(saveToDB, "Frequent expenses", handler.NewFrequentExpensesHandler(store, cfg.FrequentExpenses))))
+	mux.Handle("/api/v1/prefilled-expenses/{id}", handler.Authenticate(cfg.APIConfig.Tokens, handler.RequireDatabaseForChanges(saveToDB, "Frequent expenses", handler.NewFrequentExpenseHandler(store))))
+	mux.Handle("/api/v1/prefilled-expenses/suggestions", handler.Authenticate(cfg.APIConfig.Tokens, handler.NewFrequentExpenseSuggestionsHandler(reports, cfg.Suggestions)))
 
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins: []string{"http://localhost:3000", "http://192.168.1.11:3000"},
@@ -52,8 +130,9 @@ func main() {
 			match, _ := regexp.MatchString(`^https?://(localhost|127\.0\.0\.1|192\.168\.\d{1,3}\.\d{1,3}|10\.\d{1,3}\.\d{1,3}\.\d{1,3}|172\.(1[6-9]|2[0-9]|3[0-1])\.\d{1,3}\.\d{1,3}):\d+$`, origin)
 			return match
 		},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", handler.IdempotencyKeyHeader},
+		ExposedHeaders: []string{handler.IdempotencyReplayedHeader},
 	})
 
 	corsMiddlewareHandler := corsMiddleware.Handler(mux)
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"main/pkg/config"
+	"main/pkg/storage"
+	"strconv"
+)
+
+const migrateUsage = "usage: server migrate [up | down [steps] | status | assign-owner <user_id>]"
+
+// runMigrate implements the `migrate` subcommand.
+func runMigrate(dbConfig config.DatabaseConfig, args []string) error {
+	store, err := storage.Open(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.Printf("Error closing store: %v", err)
+		}
+	}()
+
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "up":
+		if err = store.MigrateUp(); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q: %s", args[1], migrateUsage)
+			}
+		}
+		if err = store.MigrateDown(steps); err != nil {
+			return err
+		}
+	case "status":
+	case "assign-owner":
+		// Gives the transactions recorded before they were scoped per user to a single Telegram user.
+		if len(args) != 2 {
+			return fmt.Errorf("missing user id: %s", migrateUsage)
+		}
+		userID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid user id %q: %s", args[1], migrateUsage)
+		}
+		if err = store.MigrateUp(); err != nil {
+			return err
+		}
+		assigned, err := store.AssignOwner(userID)
+		if err != nil {
+			return err
+		}
+		log.Printf("Assigned %d transactions to user %d.", assigned, userID)
+	default:
+		return fmt.Errorf("unknown migrate action %q: %s", action, migrateUsage)
+	}
+
+	current, latest, err := store.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	log.Printf("Schema version %d (latest known %d).", current, latest)
+	return nil
+}
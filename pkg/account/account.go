@@ -0,0 +1,130 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/pkg/money"
+	"strings"
+	"time"
+)
+
+// Type is the kind of payment method an account stands for.
+type Type string
+
+const (
+	Cash    Type = "cash"
+	Card    Type = "card"
+	Bank    Type = "bank"
+	EWallet Type = "ewallet"
+)
+
+// Types lists the account types.
+var Types = []Type{Cash, Card, Bank, EWallet}
+
+// maxNameLength matches the accounts.name column.
+const maxNameLength = 50
+
+// ParseType parses an account type, ignoring case.
+func ParseType(s string) (Type, error) {
+	t := Type(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Types {
+		if t == known {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown account type %q, use cash, card, bank or ewallet", s)
+}
+
+// Account is a card, cash wallet, bank account or e-wallet that transactions are paid from or into.
+// Its balance is the opening balance plus its income and incoming transfers, minus its expenses
+// and outgoing transfers, in the account's currency.
+type Account struct {
+	ID             int64     `json:"id"`
+	UserID         int64     `json:"-"` // Telegram chat ID of the owner
+	Name           string    `json:"name"`
+	Type           Type      `json:"type"`
+	Currency       string    `json:"currency"`
+	OpeningBalance int64     `json:"-"` // In minor units of Currency, see accountJSON
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Validate checks that the account can be stored and normalises its name, type and currency.
+func (a *Account) Validate() error {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(a.Name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	t, err := ParseType(string(a.Type))
+	if err != nil {
+		return err
+	}
+	a.Type = t
+	a.Currency = strings.ToUpper(strings.TrimSpace(a.Currency))
+	if len(a.Currency) != 3 {
+		return errors.New("currency must be a 3-letter ISO 4217 code")
+	}
+	return nil
+}
+
+// FormattedOpeningBalance renders the opening balance as a decimal in the account's currency, e.g. "100.00".
+func (a Account) FormattedOpeningBalance() string {
+	return money.Format(a.OpeningBalance, a.Currency)
+}
+
+// ParseOpeningBalance parses a decimal opening balance in the currency, which may be negative.
+func ParseOpeningBalance(s, currency string) (int64, error) {
+	balance, err := money.Parse(s, currency)
+	if err != nil {
+		return 0, fmt.Errorf("invalid opening balance: %w", err)
+	}
+	return balance, nil
+}
+
+// accountAlias has the fields of Account without its JSON methods.
+type accountAlias Account
+
+// accountJSON is the wire format of an Account, with the opening balance written like a transaction's amount.
+// The opening balance may be negative, e.g. for a credit card that is already in use.
+type accountJSON struct {
+	*accountAlias
+	OpeningBalance      json.Number `json:"openingBalance"`
+	OpeningBalanceMinor *int64      `json:"openingBalanceMinor,omitempty"`
+}
+
+// MarshalJSON writes the opening balance losslessly as both a decimal and minor units.
+func (a Account) MarshalJSON() ([]byte, error) {
+	alias := accountAlias(a)
+	minor := a.OpeningBalance
+	return json.Marshal(accountJSON{
+		accountAlias:        &alias,
+		OpeningBalance:      json.Number(money.Format(a.OpeningBalance, a.Currency)),
+		OpeningBalanceMinor: &minor,
+	})
+}
+
+// UnmarshalJSON accepts the opening balance either as "openingBalanceMinor" or as a decimal "openingBalance"
+// in the account's currency. Without either, the account opens at zero.
+func (a *Account) UnmarshalJSON(data []byte) error {
+	aux := accountJSON{accountAlias: (*accountAlias)(a)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	switch {
+	case aux.OpeningBalanceMinor != nil:
+		a.OpeningBalance = *aux.OpeningBalanceMinor
+	case aux.OpeningBalance != "":
+		balance, err := ParseOpeningBalance(aux.OpeningBalance.String(), a.Currency)
+		if err != nil {
+			return err
+		}
+		a.OpeningBalance = balance
+	default:
+		a.OpeningBalance = 0
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package attachment
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"main/pkg/blob"
+	"main/pkg/config"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrTooLarge is returned for files above the configured size limit.
+var ErrTooLarge = errors.New("file is too large")
+
+// ErrUnsupportedType is returned for files whose content is not one of the allowed MIME types.
+var ErrUnsupportedType = errors.New("file type is not allowed")
+
+// Service stores attachments in a blob store and links them to transactions,
+// enforcing the configured size and MIME type limits.
+type Service struct {
+	blobs        blob.Store
+	store        storage.AttachmentStore
+	maxSize      int64
+	allowedTypes []string
+}
+
+// NewService creates an attachment service. Unset limits fall back to the config defaults.
+func NewService(blobs blob.Store, store storage.AttachmentStore, cfg config.AttachmentsConfig) *Service {
+	cfg = cfg.WithDefaults()
+	return &Service{blobs: blobs, store: store, maxSize: cfg.MaxSizeBytes, allowedTypes: cfg.AllowedTypes}
+}
+
+// MaxSize returns the largest accepted file in bytes.
+func (s *Service) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Save stores the file and links it to the user's transaction. The MIME type is detected from the
+// content rather than trusted from the client. It returns storage.ErrNotFound when the user has no
+// such transaction, ErrTooLarge or ErrUnsupportedType when the file breaks the limits.
+func (s *Service) Save(userID, transactionID int64, fileName string, r io.Reader) (transaction.Attachment, error) {
+	// 512 bytes is all http.DetectContentType looks at.
+	content := bufio.NewReaderSize(r, 512)
+	head, err := content.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return transaction.Attachment{}, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !s.allowed(contentType) {
+		return transaction.Attachment{}, fmt.Errorf("%w: %s, accepted are %s", ErrUnsupportedType, contentType, strings.Join(s.allowedTypes, ", "))
+	}
+
+	key, err := newKey(userID, transactionID, contentType)
+	if err != nil {
+		return transaction.Attachment{}, err
+	}
+	counter := &countingReader{r: io.LimitReader(content, s.maxSize+1)}
+	if err = s.blobs.Put(key, counter); err != nil {
+		return transaction.Attachment{}, err
+	}
+	if counter.n > s.maxSize {
+		s.deleteBlob(key)
+		return transaction.Attachment{}, fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, s.maxSize)
+	}
+
+	a := transaction.Attachment{
+		TransactionID: transactionID,
+		UserID:        userID,
+		FileName:      cleanFileName(fileName, key),
+		ContentType:   contentType,
+		Size:          counter.n,
+		BlobKey:       key,
+	}
+	if a.ID, err = s.store.InsertAttachment(a); err != nil {
+		s.deleteBlob(key)
+		return transaction.Attachment{}, err
+	}
+	// The stores default it to the insert time as well, to the second.
+	a.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	return a, nil
+}
+
+// Open returns an attachment of the user's transaction and its content, which the caller must close.
+func (s *Service) Open(userID, transactionID, id int64) (transaction.Attachment, io.ReadCloser, error) {
+	a, err := s.store.GetAttachment(userID, transactionID, id)
+	if err != nil {
+		return a, nil, err
+	}
+	content, err := s.blobs.Get(a.BlobKey)
+	if err != nil {
+		return a, nil, err
+	}
+	return a, content, nil
+}
+
+// List returns the attachments of the user's transaction.
+func (s *Service) List(userID, transactionID int64) ([]transaction.Attachment, error) {
+	return s.store.GetAttachments(userID, transactionID)
+}
+
+// DeleteFiles removes the content of attachments whose transaction was deleted;
+// the attachment rows themselves are removed with the transaction.
+func (s *Service) DeleteFiles(attachments []transaction.Attachment) {
+	for _, a := range attachments {
+		s.deleteBlob(a.BlobKey)
+	}
+}
+
+// allowed reports whether the MIME type is accepted.
+func (s *Service) allowed(contentType string) bool {
+	for _, allowed := range s.allowedTypes {
+		if strings.EqualFold(allowed, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteBlob removes a blob, logging failures since there is nothing the caller could do about them.
+func (s *Service) deleteBlob(key string) {
+	if err := s.blobs.Delete(key); err != nil {
+		log.Printf("Error deleting attachment blob %s: %v", key, err)
+	}
+}
+
+// extensions are the usual file extensions of common attachment types,
+// mime.ExtensionsByType lists several in alphabetical order (".jfif" for JPEG).
+var extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// newKey returns a unique blob key such as "42/17/3f9a0c1e5b7d2a4f.jpg".
+func newKey(userID, transactionID int64, contentType string) (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate attachment key: %w", err)
+	}
+	ext, ok := extensions[contentType]
+	if !ok {
+		if known, _ := mime.ExtensionsByType(contentType); len(known) > 0 {
+			ext = known[0]
+		}
+	}
+	return fmt.Sprintf("%d/%d/%s%s", userID, transactionID, hex.EncodeToString(random), ext), nil
+}
+
+// cleanFileName keeps only the base name of a client-supplied file name, defaulting to the key's.
+func cleanFileName(fileName, key string) string {
+	fileName = filepath.Base(strings.ReplaceAll(strings.TrimSpace(fileName), "\\", "/"))
+	if fileName == "." || fileName == "/" || fileName == "" {
+		return filepath.Base(key)
+	}
+	return fileName
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/account"
+	"main/pkg/storage"
+	"strings"
+)
+
+const accountsUsage = "Usage:\n" +
+	"/accounts - your accounts and their balances\n" +
+	"/accounts add card SGD 0 Visa Platinum - add an account with its type (cash, card, bank or ewallet), currency and opening balance\n" +
+	"/accounts delete Visa Platinum - remove an account, its transactions are kept"
+
+// handleAccounts answers the /accounts command and its add and delete subcommands.
+func (b *Bot) handleAccounts(chatID int64, args string) error {
+	if !b.botFeatures.SaveToDB {
+		return b.sendText(chatID, "⚠️ Accounts are only available when transactions are saved to the database.")
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return b.sendText(chatID, b.accountsText(chatID))
+	}
+	switch strings.ToLower(fields[0]) {
+	case "add":
+		return b.addAccount(chatID, fields[1:])
+	case "delete", "remove":
+		return b.deleteAccount(chatID, strings.Join(fields[1:], " "))
+	default:
+		return b.sendText(chatID, accountsUsage)
+	}
+}
+
+// accountsText lists the user's accounts with their current balances.
+func (b *Bot) accountsText(chatID int64) string {
+	balances, err := b.reports.AccountBalances(chatID)
+	if err != nil {
+		log.Printf("Chat %d: Error getting account balances: %v", chatID, err)
+		return "Sorry, I couldn't retrieve your accounts at this time. Please try again later."
+	}
+	if len(balances) == 0 {
+		return "You have no accounts.\n\n" + accountsUsage
+	}
+	var builder strings.Builder
+	builder.WriteString("Accounts:")
+	for _, balance := range balances {
+		builder.WriteString(fmt.Sprintf("\n- %s (%s): %s", balance.Account.Name, balance.Account.Type, balance.Balance))
+		if len(balance.MissingRates) > 0 {
+			builder.WriteString(fmt.Sprintf(" (without %s, no exchange rate)", strings.Join(balance.MissingRates, ", ")))
+		}
+	}
+	return builder.String()
+}
+
+// addAccount handles "/accounts add <type> <currency> <opening balance> <name>".
+func (b *Bot) addAccount(chatID int64, args []string) error {
+	if len(args) < 4 {
+		return b.sendText(chatID, accountsUsage)
+	}
+	a := account.Account{UserID: chatID, Type: account.Type(args[0]), Currency: args[1], Name: strings.Join(args[3:], " ")}
+	if err := a.Validate(); err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Invalid account: %s\n\n%s", err, accountsUsage))
+	}
+	opening, err := account.ParseOpeningBalance(args[2], a.Currency)
+	if err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ %s\n\n%s", err, accountsUsage))
+	}
+	a.OpeningBalance = opening
+
+	if _, err = b.store.InsertAccount(a); err != nil {
+		if errors.Is(err, storage.ErrAccountExists) {
+			return b.sendText(chatID, fmt.Sprintf("⚠️ You already have an account called %s.", a.Name))
+		}
+		log.Printf("Chat %d: Error saving account: %v", chatID, err)
+		return b.sendText(chatID, "Sorry, there was an error saving your account. Please try again later.")
+	}
+	return b.sendText(chatID, fmt.Sprintf("💳 Added %s (%s) with an opening balance of %s %s. Pick it when you /add a transaction.",
+		a.Name, a.Type, a.FormattedOpeningBalance(), a.Currency))
+}
+
+// deleteAccount handles "/accounts delete <name>".
+func (b *Bot) deleteAccount(chatID int64, name string) error {
+	if name == "" {
+		return b.sendText(chatID, accountsUsage)
+	}
+	accounts, err := b.store.GetAccounts(chatID)
+	if err != nil {
+		log.Printf("Chat %d: Error getting accounts: %v", chatID, err)
+		return b.sendText(chatID, "Sorry, the account could not be removed. Please try again later.")
+	}
+	for _, a := range accounts {
+		if !strings.EqualFold(a.Name, name) {
+			continue
+		}
+		if err = b.store.DeleteAccount(chatID, a.ID); err != nil {
+			log.Printf("Chat %d: Error deleting account %d: %v", chatID, a.ID, err)
+			return b.sendText(chatID, "Sorry, the account could not be removed. Please try again later.")
+		}
+		return b.sendText(chatID, fmt.Sprintf("Removed your account %s. Its transactions are kept.", a.Name))
+	}
+	return b.sendText(chatID, fmt.Sprintf("⚠️ You have no account called %s.", name))
+}
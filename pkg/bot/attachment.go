@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"main/pkg/attachment"
+	"main/pkg/session"
+	"main/pkg/storage"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// fileFromMessage returns the photo or document sent with the message, or false if there is none.
+// Telegram sends every photo in several sizes, the largest is the last one.
+func fileFromMessage(message *tgbotapi.Message) (session.PendingAttachment, bool) {
+	if message.Document != nil {
+		return session.PendingAttachment{
+			FileID:   message.Document.FileID,
+			FileName: message.Document.FileName,
+			FileSize: int64(message.Document.FileSize),
+		}, true
+	}
+	if len(message.Photo) > 0 {
+		photo := message.Photo[len(message.Photo)-1]
+		return session.PendingAttachment{
+			FileID:   photo.FileID,
+			FileName: fmt.Sprintf("photo-%d.jpg", message.MessageID),
+			FileSize: int64(photo.FileSize),
+		}, true
+	}
+	return session.PendingAttachment{}, false
+}
+
+// handleAttachment handles a photo or document, e.g. a receipt. During /add it is kept until the
+// transaction is saved. Otherwise it is attached to the transaction given in the caption as
+// "/attach <id>", or else to the transaction most recently saved in the chat.
+func (b *Bot) handleAttachment(message *tgbotapi.Message, file session.PendingAttachment, userSessions map[int64]*session.UserSession) error {
+	chatID := message.Chat.ID
+	if !b.botFeatures.SaveToDB {
+		return b.sendText(chatID, "⚠️ Attachments are only kept when transactions are saved to the database.")
+	}
+	if file.FileSize > b.attachments.MaxSize() {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ The file is too large, the limit is %s.", formatFileSize(b.attachments.MaxSize())))
+	}
+
+	if userSession, exists := userSessions[chatID]; exists {
+		userSession.PendingAttachments = append(userSession.PendingAttachments, file)
+		return b.sendText(chatID, fmt.Sprintf("📎 Got it, %s will be attached when the transaction is saved.", file.FileName))
+	}
+
+	var transactionID int64
+	command, args, _ := strings.Cut(strings.TrimSpace(message.Caption), " ")
+	if command == attachOption {
+		id, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+		if err != nil || id < 1 {
+			return b.sendText(chatID, fmt.Sprintf("⚠️ Usage: send the file with the caption %v <transaction id>.", attachOption))
+		}
+		transactionID = id
+	} else if id, ok := b.lastTransactions[chatID]; ok {
+		transactionID = id
+	} else {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Which transaction is this for? Send the file during %v or with the caption %v <transaction id>.", addOption, attachOption))
+	}
+
+	err := b.saveAttachment(chatID, transactionID, file)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Transaction %d not found.", transactionID))
+	case err != nil:
+		return b.sendAttachmentError(chatID, file, err)
+	default:
+		return b.sendText(chatID, fmt.Sprintf("📎 Attached %s to transaction %d.", file.FileName, transactionID))
+	}
+}
+
+// saveAttachments saves the files sent during a session once its transaction has been inserted.
+// Failures are reported to the user without undoing the transaction.
+func (b *Bot) saveAttachments(chatID, transactionID int64, files []session.PendingAttachment) {
+	for _, file := range files {
+		if err := b.saveAttachment(chatID, transactionID, file); err != nil {
+			if sendErr := b.sendAttachmentError(chatID, file, err); sendErr != nil {
+				log.Printf("Chat %d: Error sending attachment error message: %v", chatID, sendErr)
+			}
+		}
+	}
+}
+
+// saveAttachment downloads the file from Telegram and stores it as an attachment of the transaction.
+func (b *Bot) saveAttachment(chatID, transactionID int64, file session.PendingAttachment) error {
+	url, err := b.api.GetFileDirectURL(file.FileID)
+	if err != nil {
+		return fmt.Errorf("failed to get file URL: %w", err)
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Chat %d: Error closing file download: %v", chatID, err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download file: %s", resp.Status)
+	}
+
+	a, err := b.attachments.Save(chatID, transactionID, file.FileName, resp.Body)
+	if err != nil {
+		return err
+	}
+	log.Printf("Chat %d: Saved attachment %d for transaction %d", chatID, a.ID, transactionID)
+	return nil
+}
+
+// sendAttachmentError tells the user why a file could not be attached.
+func (b *Bot) sendAttachmentError(chatID int64, file session.PendingAttachment, err error) error {
+	log.Printf("Chat %d: Error saving attachment %s: %v", chatID, file.FileName, err)
+	switch {
+	case errors.Is(err, attachment.ErrTooLarge):
+		return b.sendText(chatID, fmt.Sprintf("⚠️ %s is too large, the limit is %s.", file.FileName, formatFileSize(b.attachments.MaxSize())))
+	case errors.Is(err, attachment.ErrUnsupportedType):
+		return b.sendText(chatID, fmt.Sprintf("⚠️ %s was not attached: %v.", file.FileName, err))
+	default:
+		return b.sendText(chatID, fmt.Sprintf("Sorry, %s could not be attached. Please try again later.", file.FileName))
+	}
+}
+
+// formatFileSize renders a size in bytes as e.g. "10 MB".
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%d MB", size>>20)
+	case size >= 1<<10:
+		return fmt.Sprintf("%d KB", size>>10)
+	default:
+		return fmt.Sprintf("%d bytes", size)
+	}
+}
@@ -4,15 +4,34 @@ import (
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"log"
+	"main/pkg/attachment"
+	"main/pkg/category"
 	"main/pkg/config"
+	"main/pkg/exchange"
+	"main/pkg/export"
+	"main/pkg/frequent"
+	"main/pkg/recurring"
+	"main/pkg/report"
 	"main/pkg/session"
 	"main/pkg/storage"
+	"main/pkg/transaction"
+	"strconv"
 	"strings"
 )
 
 const (
 	addOption                 = "/add"
 	transactionsSummaryOption = "/summary"
+	exchangeRateOption        = "/rate"
+	attachOption              = "/attach"
+	recurringOption           = "/recurring"
+	budgetOption              = "/budget"
+	claimsOption              = "/claims"
+	incomeOption              = "/income"
+	accountsOption            = "/accounts"
+	categoriesOption          = "/categories"
+	favouriteOption           = "/favourite"
+	exportOption              = "/export"
 )
 
 // Map to track ongoing sessions (active users)
@@ -21,21 +40,33 @@ var userSessions = make(map[int64]*session.UserSession)
 // Bot represents the Telegram bot.
 type Bot struct {
 	api                       *tgbotapi.BotAPI
+	store                     storage.Store
+	reports                   *report.Builder
+	rates                     *exchange.Rates
+	attachments               *attachment.Service
+	scheduler                 *recurring.Scheduler
+	lastTransactions          map[int64]int64 // ID of the transaction last saved in each chat, for attachments
 	botFeatures               config.FeaturesConfig
-	preFilledFrequentExpenses []config.FrequentExpense
-	categories                []string
+	preFilledFrequentExpenses []config.FrequentExpense // Frequent expenses of new users, see frequentExpenses
+	suggestions               config.SuggestionsConfig
+	duplicates                config.DuplicatesConfig
+	categorySeeds             []category.Seed // Categories of new users, see userCategories
 	currencies                []string
+	exporter                  *export.Exporter
 }
 
 // NewBot creates a new bot instance.
-func NewBot(token string, botFeatures config.FeaturesConfig, preFilledExpenses []config.FrequentExpense, expenseCategories, supportedCurrencies []string) (*Bot, error) {
+// Recurring rules are only recorded once RunRecurringRules is started.
+func NewBot(token string, store storage.Store, reports *report.Builder, rates *exchange.Rates, attachments *attachment.Service, botFeatures config.FeaturesConfig, preFilledExpenses []config.FrequentExpense, suggestions config.SuggestionsConfig, duplicates config.DuplicatesConfig, exporter *export.Exporter, expenseCategories, incomeCategories, supportedCurrencies []string) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot API: %w", err)
 	}
 	api.Debug = true
 	log.Printf("Authorized on account %s", api.Self.UserName)
-	return &Bot{api: api, botFeatures: botFeatures, preFilledFrequentExpenses: preFilledExpenses, categories: expenseCategories, currencies: supportedCurrencies}, nil
+	b := &Bot{api: api, store: store, reports: reports, rates: rates, attachments: attachments, lastTransactions: make(map[int64]int64), botFeatures: botFeatures, preFilledFrequentExpenses: preFilledExpenses, suggestions: suggestions, duplicates: duplicates, exporter: exporter, categorySeeds: category.ParseSeeds(expenseCategories, incomeCategories), currencies: supportedCurrencies}
+	b.scheduler = recurring.NewScheduler(store, rates, b.notifyRecurring, recurring.DefaultCheckInterval)
+	return b, nil
 }
 
 // StartListening starts listening for updates.
@@ -62,79 +93,68 @@ func (b *Bot) StartListening(userSessions map[int64]*session.UserSession) {
 // handleTextMessage handles incoming text messages.
 func (b *Bot) handleTextMessage(message *tgbotapi.Message, userSessions map[int64]*session.UserSession) error {
 	chatID := message.Chat.ID
+	// Commands may take arguments, e.g. "/rate USD 2025-01-31".
+	command, args, _ := strings.Cut(strings.TrimSpace(message.Text), " ")
 
-	switch message.Text {
+	// Photos and documents, e.g. receipts, carry their command in the caption.
+	if file, ok := fileFromMessage(message); ok {
+		log.Printf("Chat %v: Received a file", chatID)
+		return b.handleAttachment(message, file, userSessions)
+	}
+
+	switch command {
 	case addOption:
 		log.Printf("Chat %v: Received %v command", chatID, addOption)
-		return b.startSession(chatID, userSessions)
+		return b.startSession(chatID, transaction.TypeExpense, userSessions)
+
+	case incomeOption:
+		log.Printf("Chat %v: Received %v command", chatID, incomeOption)
+		return b.startSession(chatID, transaction.TypeIncome, userSessions)
 
 	case transactionsSummaryOption: // It's good practice to have a cancel command
 		log.Printf("Chat %v: Received %v command", chatID, transactionsSummaryOption)
 
-		categoryCounts, err := storage.GetTransactionCountByCategory()
-		if err != nil {
-			log.Printf("Chat %d: Error getting transaction summary: %v", chatID, err)
-			// Send a generic error message to the user
+		return b.sendSummary(chatID, args)
 
-			errMsg := tgbotapi.NewMessage(chatID, "Sorry, I couldn't retrieve the transaction summary at this time. Please try again later.")
-			_, sendErr := b.api.Send(errMsg)
-			if sendErr != nil {
-				log.Printf("Chat %d: Error sending summary error message: %v", chatID, sendErr)
-			}
+	case exportOption:
+		log.Printf("Chat %v: Received %v command", chatID, exportOption)
 
-			err = b.sendDefaultMessage(chatID)
-			if err != nil {
-				log.Printf("Chat %d: Error sending summary error message: %v", chatID, sendErr)
-			}
+		return b.sendExport(chatID, args)
 
-			return err // Return the original error
-		}
-		var summaryMessageBuilder strings.Builder
-		summaryMessageBuilder.WriteString("Transaction Summary by Category:")
+	case exchangeRateOption:
+		log.Printf("Chat %v: Received %v command", chatID, exchangeRateOption)
 
-		if len(categoryCounts) == 0 {
-			summaryMessageBuilder.WriteString("\nNo transactions found.")
-		} else {
-			totalExpense := float32(0)
-			for category, count := range categoryCounts {
-				summaryMessageBuilder.WriteString(fmt.Sprintf("\n- %v: %v", category, count))
-				totalExpense += count
-			}
-			summaryMessageBuilder.WriteString(fmt.Sprintf("\n- Total Expenses: %v", totalExpense))
-		}
+		return b.sendExchangeRate(chatID, strings.Fields(args))
 
-		summaryMessageBuilder.WriteString("\n\nTotal claimable:")
-		amountByIsClaimable, errGetIsClaimable := storage.GetTotalAmountByIsClaimable()
-		if errGetIsClaimable != nil {
-			log.Printf("Chat %v: Error getting 'is_claimable' totals: %v", chatID, err)
-		}
-		if len(categoryCounts) == 0 || errGetIsClaimable != nil {
-			summaryMessageBuilder.WriteString("\nNo transactions found.")
-		} else {
-			for category, count := range amountByIsClaimable {
-				summaryMessageBuilder.WriteString(fmt.Sprintf("\n- %v: %v", category, count))
-			}
-		}
+	case recurringOption:
+		log.Printf("Chat %v: Received %v command", chatID, recurringOption)
 
-		summaryMessageBuilder.WriteString("\n\nTotal paid for family:")
-		amountByPaidForFamily, errPaidByFamily := storage.GetTotalAmountByPaidForFamily()
-		if errPaidByFamily != nil {
-			log.Printf("Chat %v: Error getting 'paid_for_family' totals: %v", chatID, err)
-		}
-		if len(amountByPaidForFamily) == 0 || errPaidByFamily != nil {
-			summaryMessageBuilder.WriteString("\nNo transactions found.")
-		} else {
-			for category, count := range amountByPaidForFamily {
-				summaryMessageBuilder.WriteString(fmt.Sprintf("\n- %v: %v", category, count))
-			}
-		}
+		return b.handleRecurring(chatID, args)
 
-		msg := tgbotapi.NewMessage(chatID, summaryMessageBuilder.String())
-		_, sendErr := b.api.Send(msg)
-		if sendErr != nil {
-			log.Printf("Chat %d: Error sending summary message: %v", chatID, sendErr)
-		}
-		return sendErr
+	case budgetOption:
+		log.Printf("Chat %v: Received %v command", chatID, budgetOption)
+
+		return b.handleBudget(chatID, args)
+
+	case claimsOption:
+		log.Printf("Chat %v: Received %v command", chatID, claimsOption)
+
+		return b.handleClaims(chatID, args)
+
+	case accountsOption:
+		log.Printf("Chat %v: Received %v command", chatID, accountsOption)
+
+		return b.handleAccounts(chatID, args)
+
+	case categoriesOption:
+		log.Printf("Chat %v: Received %v command", chatID, categoriesOption)
+
+		return b.handleCategories(chatID, args)
+
+	case favouriteOption:
+		log.Printf("Chat %v: Received %v command", chatID, favouriteOption)
+
+		return b.handleFavourite(chatID, args)
 
 	default:
 		if _, exists := userSessions[chatID]; exists {
@@ -145,9 +165,24 @@ func (b *Bot) handleTextMessage(message *tgbotapi.Message, userSessions map[int6
 	}
 }
 
-// startSession starts a new session for the user.
-func (b *Bot) startSession(chatID int64, userSessions map[int64]*session.UserSession) error {
-	userSessions[chatID] = session.NewUserSession()
+// startSession starts a new session for the user, recording a transaction of the type.
+// Expenses start with the user's frequent expenses and suggestions, and the user's accounts are offered
+// once the category is known.
+func (b *Bot) startSession(chatID int64, txType transaction.Type, userSessions map[int64]*session.UserSession) error {
+	userSession := session.NewTypedSession(txType)
+	if txType == transaction.TypeExpense {
+		userSession.FrequentExpenses = b.frequentExpenses(chatID)
+		userSession.Suggestions = b.frequentSuggestions(chatID)
+	}
+	if b.botFeatures.SaveToDB {
+		accounts, err := b.store.GetAccounts(chatID)
+		if err != nil {
+			// Accounts are optional, the transaction can still be recorded without one.
+			log.Printf("Chat %d: Could not load accounts: %v", chatID, err)
+		}
+		userSession.Accounts = accounts
+	}
+	userSessions[chatID] = userSession
 	return b.askCurrentQuestion(chatID, userSessions)
 }
 
@@ -168,7 +203,7 @@ func (b *Bot) askCurrentQuestion(chatID int64, userSessions map[int64]*session.U
 			summaryParts = append(summaryParts, fmt.Sprintf("*Name:* %s", tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, answers.Name)))
 		}
 		if answers.Amount > 0 {
-			summaryParts = append(summaryParts, fmt.Sprintf("*Amount:* `%.2f`", answers.Amount))
+			summaryParts = append(summaryParts, fmt.Sprintf("*Amount:* `%s`", answers.FormattedAmount()))
 		}
 		if answers.Currency != "" {
 			summaryParts = append(summaryParts, fmt.Sprintf("*Currency:* %s", tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, answers.Currency)))
@@ -178,15 +213,23 @@ func (b *Bot) askCurrentQuestion(chatID int64, userSessions map[int64]*session.U
 			summaryParts = append(summaryParts, fmt.Sprintf("*Date:* %s", tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, answers.Date)))
 		}
 		// For booleans, we check if the question has been passed in the session flow.
-		if userSession.CurrentQuestion > session.QuestionIsClaimable {
+		// Only expenses are asked about them.
+		isExpense := answers.TransactionType() == transaction.TypeExpense
+		if isExpense && userSession.CurrentQuestion > session.QuestionIsClaimable {
 			summaryParts = append(summaryParts, fmt.Sprintf("*Claimable:* %t", answers.IsClaimable))
 		}
-		if userSession.CurrentQuestion > session.QuestionPaidForFamily {
+		if isExpense && userSession.CurrentQuestion > session.QuestionPaidForFamily {
 			summaryParts = append(summaryParts, fmt.Sprintf("*Paid for Family:* %t", answers.PaidForFamily))
 		}
 		if answers.Category != "" { // Category can be autofilled
 			summaryParts = append(summaryParts, fmt.Sprintf("*Category:* %s", tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, answers.Category)))
 		}
+		if answers.AccountID != 0 {
+			summaryParts = append(summaryParts, fmt.Sprintf("*Account:* %s", tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, userSession.AccountName(answers.AccountID))))
+		}
+		if len(answers.Tags) > 0 {
+			summaryParts = append(summaryParts, fmt.Sprintf("*Tags:* %s", tgbotapi.EscapeText(tgbotapi.ModeMarkdownV2, strings.Join(answers.Tags, ", "))))
+		}
 
 		if len(summaryParts) > 0 {
 			messageBuilder.WriteString("*Your progress so far:*\n")
@@ -204,20 +247,30 @@ func (b *Bot) askCurrentQuestion(chatID int64, userSessions map[int64]*session.U
 	msg.ParseMode = tgbotapi.ModeMarkdownV2
 
 	// ... (The rest of your keyboard logic remains the same) ...
-	if userSession.CurrentQuestion == session.QuestionName {
+	// The frequent expenses are not offered for income.
+	if userSession.CurrentQuestion == session.QuestionName && userSession.Answers.TransactionType() == transaction.TypeExpense {
 		var keyboardRows [][]tgbotapi.InlineKeyboardButton // Slice of rows
 
-		// Iterate through TransactionCategory, taking two items at a time
-		for i := 0; i < len(b.preFilledFrequentExpenses); i += 2 {
+		// Suggestions follow the saved frequent expenses and are marked with a sparkle.
+		var labels, names []string
+		for _, e := range userSession.FrequentExpenses {
+			labels, names = append(labels, e.Name), append(names, e.Name)
+		}
+		for _, suggestion := range userSession.Suggestions {
+			labels, names = append(labels, "✨ "+suggestion.Name), append(names, suggestion.Name)
+		}
+
+		// Iterate through the frequent expenses, taking two items at a time
+		for i := 0; i < len(names); i += 2 {
 			// Create the first button for the row
-			button1 := tgbotapi.NewInlineKeyboardButtonData(b.preFilledFrequentExpenses[i].Name, b.preFilledFrequentExpenses[i].Name)
+			button1 := tgbotapi.NewInlineKeyboardButtonData(labels[i], names[i])
 
 			var rowButtons []tgbotapi.InlineKeyboardButton
 			rowButtons = append(rowButtons, button1)
 
 			// Check if there's a second item for this row
-			if i+1 < len(b.preFilledFrequentExpenses) {
-				button2 := tgbotapi.NewInlineKeyboardButtonData(b.preFilledFrequentExpenses[i+1].Name, b.preFilledFrequentExpenses[i+1].Name)
+			if i+1 < len(names) {
+				button2 := tgbotapi.NewInlineKeyboardButtonData(labels[i+1], names[i+1])
 				rowButtons = append(rowButtons, button2)
 			}
 
@@ -231,6 +284,16 @@ func (b *Bot) askCurrentQuestion(chatID int64, userSessions map[int64]*session.U
 		}
 	}
 
+	// A suggested expense offers its typical amount, carried in minor units.
+	if userSession.CurrentQuestion == session.QuestionAmount {
+		if amount, ok := userSession.TypicalAmount(); ok {
+			typical := transaction.Transaction{Amount: amount, Currency: userSession.Answers.Currency}
+			msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(typical.FormattedAmount(), strconv.FormatInt(amount, 10)),
+			))
+		}
+	}
+
 	if userSession.CurrentQuestion == session.QuestionIsClaimable || userSession.CurrentQuestion == session.QuestionPaidForFamily {
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
@@ -253,17 +316,27 @@ func (b *Bot) askCurrentQuestion(chatID int64, userSessions map[int64]*session.U
 	if userSession.CurrentQuestion == session.QuestionCategory {
 		var keyboardRows [][]tgbotapi.InlineKeyboardButton // Slice of rows
 
+		// Subcategories follow their parent and are labelled with it, e.g. "Transport › Taxi".
+		userCategories := b.userCategories(chatID)
+		parents := category.Parents(userCategories)
+		var categories []category.Category
+		for _, c := range category.Ordered(userCategories) {
+			if c.Type == userSession.Answers.TransactionType() {
+				categories = append(categories, c)
+			}
+		}
+
 		// Iterate through TransactionCategory, taking two items at a time
-		for i := 0; i < len(b.categories); i += 2 {
+		for i := 0; i < len(categories); i += 2 {
 			// Create the first button for the row
-			button1 := tgbotapi.NewInlineKeyboardButtonData(b.categories[i], b.categories[i])
+			button1 := tgbotapi.NewInlineKeyboardButtonData(categoryLabel(categories[i], parents), categories[i].Name)
 
 			var rowButtons []tgbotapi.InlineKeyboardButton
 			rowButtons = append(rowButtons, button1)
 
 			// Check if there's a second item for this row
-			if i+1 < len(b.categories) {
-				button2 := tgbotapi.NewInlineKeyboardButtonData(b.categories[i+1], b.categories[i+1])
+			if i+1 < len(categories) {
+				button2 := tgbotapi.NewInlineKeyboardButtonData(categoryLabel(categories[i+1], parents), categories[i+1].Name)
 				rowButtons = append(rowButtons, button2)
 			}
 
@@ -277,6 +350,24 @@ func (b *Bot) askCurrentQuestion(chatID int64, userSessions map[int64]*session.U
 		}
 	}
 
+	if userSession.CurrentQuestion == session.QuestionAccount {
+		var keyboardRows [][]tgbotapi.InlineKeyboardButton
+		for _, a := range userSession.Accounts {
+			keyboardRows = append(keyboardRows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s (%s)", a.Name, a.Currency), strconv.FormatInt(a.ID, 10)),
+			))
+		}
+		keyboardRows = append(keyboardRows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Skip", session.SkipAnswer)))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboardRows...)
+	}
+
+	if userSession.CurrentQuestion == session.QuestionTags {
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Skip", session.SkipAnswer)),
+		)
+		msg.ReplyMarkup = keyboard
+	}
+
 	sentMsg, err := b.api.Send(msg)
 	if err != nil {
 		return err
@@ -300,17 +391,30 @@ func (b *Bot) handleAnswer(message *tgbotapi.Message, userSessions map[int64]*se
 		_, _ = b.api.Request(deleteUserMsg)
 	}()
 
-	if answer == addOption {
+	if answer == addOption || answer == incomeOption {
 		// If the user starts a new session, clean up the old question.
 		if userSession, ok := userSessions[chatID]; ok && userSession.LastQuestionMessageID != 0 {
 			deleteBotQuestion := tgbotapi.NewDeleteMessage(chatID, userSession.LastQuestionMessageID)
 			_, _ = b.api.Request(deleteBotQuestion)
 		}
-		return b.startSession(chatID, userSessions)
+		txType := transaction.TypeExpense
+		if answer == incomeOption {
+			txType = transaction.TypeIncome
+		}
+		return b.startSession(chatID, txType, userSessions)
 	}
 
 	userSession := userSessions[chatID]
 
+	// A transaction that looks like a duplicate waits for a yes or no.
+	if userSession.DuplicateOf != 0 {
+		save, err := transaction.ValidateBool(answer)
+		if err != nil {
+			return b.sendText(chatID, "⚠️ Please answer 'yes' to save it anyway or 'no' to discard it.")
+		}
+		return b.answerDuplicate(chatID, save, userSessions)
+	}
+
 	// Delegate validation to the session handler
 	err := userSession.HandleAnswer(answer)
 	if err != nil {
@@ -348,35 +452,50 @@ func (b *Bot) handleAnswer(message *tgbotapi.Message, userSessions map[int64]*se
 
 // completeSession finishes the session.
 func (b *Bot) completeSession(chatID int64, session *session.UserSession) error {
-	if b.botFeatures.SaveToDB {
-		// Save the responses to the database
-		err := storage.SaveTransactionToDB(session.Answers)
-		if err != nil {
-			// Inform the user if saving failed
-			errMsg := tgbotapi.NewMessage(chatID, "Sorry, there was an error saving your transaction. Please try again later.")
-			_, sendErr := b.api.Send(errMsg)
-			if sendErr != nil {
-				log.Printf("Error sending save error message: %v", sendErr)
-			}
-			// Also return the original save error
-			return fmt.Errorf("failed to save transaction to DB: %w", err)
+	// Transactions are owned by the chat they were recorded from.
+	session.Answers.UserID = chatID
+	if !session.DuplicateConfirmed {
+		if duplicateID := b.findDuplicate(chatID, session.Answers); duplicateID != 0 {
+			session.DuplicateOf = duplicateID
+			return b.askSaveDuplicate(chatID, session)
 		}
-	} else {
-		// Save the responses to file
-		storage.SaveResponseToFile(session.Answers)
+	}
+	// A missing rate never blocks saving, the transaction is then converted at report time.
+	if err := b.rates.Stamp(&session.Answers); err != nil {
+		log.Printf("Chat %d: Saving transaction without exchange rate: %v", chatID, err)
 	}
 
+	// Save the responses to the database, or to the responses file without one
+	id, err := b.store.InsertTransaction(session.Answers)
+	if err != nil {
+		// Inform the user if saving failed
+		errMsg := tgbotapi.NewMessage(chatID, "Sorry, there was an error saving your transaction. Please try again later.")
+		_, sendErr := b.api.Send(errMsg)
+		if sendErr != nil {
+			log.Printf("Error sending save error message: %v", sendErr)
+		}
+		// Also return the original save error
+		return fmt.Errorf("failed to save transaction: %w", err)
+	}
+	session.Answers.ID = id
+	b.lastTransactions[chatID] = id
+	b.saveAttachments(chatID, id, session.PendingAttachments)
+
 	// Send a thank-you message and confirmation
 	msg := tgbotapi.NewMessage(chatID,
-		fmt.Sprintf("Thank you for your responses!\n\nHere are your answers:\nName: %s\nAmount: %f\nCurrency: %s\nDate: %s\nIs Claimable: %t\nPaid for Family: %t\nCategory: %s",
-			session.Answers.Name, session.Answers.Amount, session.Answers.Currency, session.Answers.Date, session.Answers.IsClaimable, session.Answers.PaidForFamily, session.Answers.Category))
+		fmt.Sprintf("Thank you for your responses!\n\nHere are your answers:\nType: %s\nName: %s\nAmount: %s\nCurrency: %s\nDate: %s\nIs Claimable: %t\nPaid for Family: %t\nCategory: %s\nAccount: %s\nTags: %s",
+			session.Answers.TransactionType(), session.Answers.Name, session.Answers.FormattedAmount(), session.Answers.Currency, session.Answers.Date, session.Answers.IsClaimable, session.Answers.PaidForFamily, session.Answers.Category, formatAccount(session), formatTags(session.Answers.Tags)))
 
-	_, err := b.api.Send(msg)
+	_, err = b.api.Send(msg)
 	if err != nil {
 		log.Printf("Error sending confirmation message: %v", err)
 		return err
 	}
 
+	if b.botFeatures.SaveToDB {
+		b.sendBudgetAlerts(chatID, session.Answers)
+	}
+
 	err = b.sendDefaultMessage(chatID)
 	if err != nil {
 		return err
@@ -388,6 +507,11 @@ func (b *Bot) completeSession(chatID int64, session *session.UserSession) error
 
 // handleCallbackQuery handles callback queries.
 func (b *Bot) handleCallbackQuery(callbackQuery *tgbotapi.CallbackQuery, userSessions map[int64]*session.UserSession) error {
+	// The buttons of the claims list work outside of a session and keep their message.
+	if strings.HasPrefix(callbackQuery.Data, claimCallbackPrefix) {
+		return b.handleClaimCallback(callbackQuery)
+	}
+
 	chatID := callbackQuery.Message.Chat.ID
 	messageID := callbackQuery.Message.MessageID // Get the ID of the message to delete
 
@@ -417,6 +541,10 @@ func (b *Bot) handleCallbackQuery(callbackQuery *tgbotapi.CallbackQuery, userSes
 		log.Printf("Could not answer callback query %s: %v", callbackQuery.ID, err)
 	}
 
+	if userSession.DuplicateOf != 0 {
+		return b.answerDuplicate(chatID, callbackQuery.Data == "yes", userSessions)
+	}
+
 	// The rest of your existing logic for handling the callback data follows.
 	// I've included a refactored version below that is much cleaner.
 	switch callbackQuery.Data {
@@ -437,12 +565,32 @@ func (b *Bot) handleCallbackQuery(callbackQuery *tgbotapi.CallbackQuery, userSes
 			userSession.Answers.Name = callbackQuery.Data
 		} else if userSession.CurrentQuestion == session.QuestionCurrency {
 			userSession.Answers.Currency = callbackQuery.Data
+		} else if userSession.CurrentQuestion == session.QuestionAmount {
+			// The only button is the typical amount of a suggestion.
+			amount, err := strconv.ParseInt(callbackQuery.Data, 10, 64)
+			if err != nil || amount <= 0 {
+				return b.askCurrentQuestion(chatID, userSessions)
+			}
+			userSession.Answers.Amount = amount
 		} else if userSession.CurrentQuestion == session.QuestionCategory {
 			userSession.Answers.Category = callbackQuery.Data
+		} else if userSession.CurrentQuestion == session.QuestionAccount {
+			// The buttons carry the account ID, Skip leaves the transaction without an account.
+			userSession.Answers.AccountID, _ = strconv.ParseInt(callbackQuery.Data, 10, 64)
+		} else if userSession.CurrentQuestion == session.QuestionTags {
+			userSession.Answers.Tags = nil // The only button is Skip
 		}
 	}
 
-	preFilledExpense := session.CheckPreFilledExpense(userSession.Answers.Name, b.preFilledFrequentExpenses)
+	var preFilledExpense *frequent.Expense
+	if userSession.Answers.TransactionType() == transaction.TypeExpense {
+		preFilledExpense = session.CheckPreFilledExpense(userSession.Answers.Name, userSession.PreFilledExpenses())
+	}
+
+	// A frequent expense may fill in the account it is paid from.
+	if userSession.CurrentQuestion == session.QuestionName && preFilledExpense != nil && userSession.AccountName(preFilledExpense.AccountID) != "" {
+		userSession.Answers.AccountID = preFilledExpense.AccountID
+	}
 
 	if userSession.CurrentQuestion == session.QuestionIsClaimable && preFilledExpense != nil {
 		userSession.Answers.PaidForFamily = preFilledExpense.PaidForFamily
@@ -454,12 +602,13 @@ func (b *Bot) handleCallbackQuery(callbackQuery *tgbotapi.CallbackQuery, userSes
 		userSession.CurrentQuestion++
 	}
 
-	if userSession.CurrentQuestion == session.QuestionAmount && preFilledExpense != nil && len(preFilledExpense.Currency) > 0 {
+	if userSession.CurrentQuestion == session.QuestionName && preFilledExpense != nil && len(preFilledExpense.Currency) > 0 {
 		userSession.Answers.Currency = preFilledExpense.Currency
 		userSession.CurrentQuestion++
 	}
 
 	userSession.CurrentQuestion++
+	userSession.SkipInapplicable()
 
 	if userSession.IsSessionComplete() {
 		return b.completeSession(chatID, userSession)
@@ -468,8 +617,25 @@ func (b *Bot) handleCallbackQuery(callbackQuery *tgbotapi.CallbackQuery, userSes
 	return b.askCurrentQuestion(chatID, userSessions)
 }
 
+// formatAccount renders the name of the session's account, or "none".
+func formatAccount(s *session.UserSession) string {
+	if name := s.AccountName(s.Answers.AccountID); name != "" {
+		return name
+	}
+	return "none"
+}
+
+// formatTags renders tags as "#japan-trip-2025 #wedding", or "none".
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "none"
+	}
+	return "#" + strings.Join(tags, " #")
+}
+
 func (b *Bot) sendDefaultMessage(chatID int64) error {
-	messageText := fmt.Sprintf("Send %v to add new transaction or %v to view summary!", addOption, transactionsSummaryOption)
+	messageText := fmt.Sprintf("Send %v to add new transaction, %v to record income or %v to view summary, or %v to download your transactions as CSV! Use %v to check exchange rates. Send a photo or PDF of a receipt to attach it, with the caption %v <id> for an older transaction. Use %v for expenses that repeat, %v to set monthly budgets, %v to track your claims, %v to manage your cards, cash and e-wallets, %v to organise your categories and %v to save an expense you add often.",
+		addOption, incomeOption, transactionsSummaryOption, exportOption, exchangeRateOption, attachOption, recurringOption, budgetOption, claimsOption, accountsOption, categoriesOption, favouriteOption)
 	_, err := b.api.Send(tgbotapi.NewMessage(chatID, messageText))
 
 	return err
@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"main/pkg/budget"
+	"main/pkg/category"
+	"main/pkg/report"
+	"main/pkg/transaction"
+	"strings"
+	"time"
+)
+
+const budgetUsage = "Usage:\n" +
+	"/budget - this month's budgets and spending\n" +
+	"/budget set Food 500 [currency] - set the monthly budget of a category\n" +
+	"/budget delete Food [currency] - remove a budget"
+
+// handleBudget answers the /budget command and its set and delete subcommands.
+func (b *Bot) handleBudget(chatID int64, args string) error {
+	if !b.botFeatures.SaveToDB {
+		return b.sendText(chatID, "⚠️ Budgets are only available when transactions are saved to the database.")
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return b.sendText(chatID, b.budgetsText(chatID))
+	}
+	switch strings.ToLower(fields[0]) {
+	case "set":
+		return b.setBudget(chatID, fields[1:])
+	case "delete", "remove":
+		return b.deleteBudget(chatID, fields[1:])
+	default:
+		return b.sendText(chatID, budgetUsage)
+	}
+}
+
+// budgetsText compares the user's budgets with this month's spending.
+func (b *Bot) budgetsText(chatID int64) string {
+	month := report.CurrentMonth(time.Now())
+	lines, err := b.reports.Budgets(chatID, month)
+	if err != nil {
+		log.Printf("Chat %d: Error getting budgets: %v", chatID, err)
+		return "Sorry, I couldn't retrieve your budgets at this time. Please try again later."
+	}
+	if len(lines) == 0 {
+		return "You have no budgets.\n\n" + budgetUsage
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Budgets for %s:", month.Label))
+	writeBudgetLines(&builder, lines)
+	return builder.String()
+}
+
+// setBudget handles "/budget set <category> <amount> [currency]". Without a currency the budget is in the
+// base currency, covering every currency, or else in the first supported currency.
+func (b *Bot) setBudget(chatID int64, args []string) error {
+	currency := b.defaultBudgetCurrency()
+	if len(args) > 2 && len(args[len(args)-1]) == 3 && !isAmount(args[len(args)-1]) {
+		currency = strings.ToUpper(args[len(args)-1])
+		args = args[:len(args)-1]
+	}
+	if len(args) < 2 {
+		return b.sendText(chatID, budgetUsage)
+	}
+	name, ok := b.findCategory(chatID, strings.Join(args[:len(args)-1], " "))
+	if !ok {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Unknown category. Use one of: %s",
+			strings.Join(category.Names(b.userCategories(chatID), transaction.TypeExpense), ", ")))
+	}
+	amount, err := transaction.ValidateAmount(args[len(args)-1], currency)
+	if err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ %s\n\n%s", err, budgetUsage))
+	}
+
+	bud := budget.Budget{UserID: chatID, Category: name, Currency: currency, Amount: amount}
+	if err = bud.Validate(); err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Invalid budget: %s", err))
+	}
+	if _, err = b.store.SetBudget(bud); err != nil {
+		log.Printf("Chat %d: Error saving budget: %v", chatID, err)
+		return b.sendText(chatID, "Sorry, there was an error saving your budget. Please try again later.")
+	}
+	return b.sendText(chatID, fmt.Sprintf("💰 Your monthly %s budget is %s %s. I'll warn you at %d%% and %d%%.",
+		bud.Category, bud.FormattedAmount(), bud.Currency, budget.WarningPercent, budget.ExceededPercent))
+}
+
+// deleteBudget handles "/budget delete <category> [currency]".
+func (b *Bot) deleteBudget(chatID int64, args []string) error {
+	currency := b.defaultBudgetCurrency()
+	if len(args) > 1 && len(args[len(args)-1]) == 3 {
+		if _, ok := b.findCategory(chatID, strings.Join(args, " ")); !ok {
+			currency = strings.ToUpper(args[len(args)-1])
+			args = args[:len(args)-1]
+		}
+	}
+	name, ok := b.findCategory(chatID, strings.Join(args, " "))
+	if !ok {
+		return b.sendText(chatID, budgetUsage)
+	}
+
+	budgets, err := b.store.GetBudgets(chatID)
+	if err != nil {
+		log.Printf("Chat %d: Error getting budgets: %v", chatID, err)
+		return b.sendText(chatID, "Sorry, the budget could not be removed. Please try again later.")
+	}
+	for _, bud := range budgets {
+		if bud.Category != name || bud.Currency != currency {
+			continue
+		}
+		if err = b.store.DeleteBudget(chatID, bud.ID); err != nil {
+			log.Printf("Chat %d: Error deleting budget %d: %v", chatID, bud.ID, err)
+			return b.sendText(chatID, "Sorry, the budget could not be removed. Please try again later.")
+		}
+		return b.sendText(chatID, fmt.Sprintf("Removed your %s budget in %s.", name, currency))
+	}
+	return b.sendText(chatID, fmt.Sprintf("⚠️ You have no %s budget in %s.", name, currency))
+}
+
+// sendBudgetAlerts warns the user when the saved transaction took its category past 80% or 100% of a budget.
+func (b *Bot) sendBudgetAlerts(chatID int64, t transaction.Transaction) {
+	alerts, err := b.reports.BudgetAlerts(chatID, t)
+	if err != nil {
+		log.Printf("Chat %d: Error checking budgets: %v", chatID, err)
+		return
+	}
+	for _, alert := range alerts {
+		line := alert.Line
+		text := fmt.Sprintf("⚠️ You have used %d%% of your %s budget for %s: %s of %s spent.",
+			line.Percent, line.Category, alert.Month.Label, line.Spent, line.Budget)
+		if alert.Threshold >= budget.ExceededPercent {
+			text = fmt.Sprintf("🚨 You are over your %s budget for %s: %s of %s spent.",
+				line.Category, alert.Month.Label, line.Spent, line.Budget)
+		}
+		if err := b.sendText(chatID, text); err != nil {
+			log.Printf("Chat %d: Error sending budget alert: %v", chatID, err)
+		}
+	}
+}
+
+// writeBudgetLines writes one "- category: spent of budget (percent)" row per budget.
+func writeBudgetLines(builder *strings.Builder, lines []report.BudgetLine) {
+	for _, line := range lines {
+		marker := ""
+		switch {
+		case line.Percent >= budget.ExceededPercent:
+			marker = " 🚨"
+		case line.Percent >= budget.WarningPercent:
+			marker = " ⚠️"
+		}
+		builder.WriteString(fmt.Sprintf("\n- %s: %s of %s (%d%%)%s", line.Category, line.Spent, line.Budget, line.Percent, marker))
+		if len(line.MissingRates) > 0 {
+			builder.WriteString(fmt.Sprintf(", excluding %s without exchange rate", strings.Join(line.MissingRates, ", ")))
+		}
+	}
+}
+
+// defaultBudgetCurrency is the base currency, so a budget covers spending in every currency,
+// or the first supported currency when there is no base currency.
+func (b *Bot) defaultBudgetCurrency() string {
+	if base := b.rates.BaseCurrency(); base != "" {
+		return base
+	}
+	if len(b.currencies) > 0 {
+		return b.currencies[0]
+	}
+	return ""
+}
+
+// findCategory returns the name of the user's expense category with the name, ignoring case.
+func (b *Bot) findCategory(chatID int64, name string) (string, bool) {
+	c, ok := category.Find(b.userCategories(chatID), name)
+	if !ok || c.Type != transaction.TypeExpense {
+		return "", false
+	}
+	return c.Name, true
+}
+
+// isAmount reports whether the argument looks like an amount rather than a currency code.
+func isAmount(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }) < 0
+}
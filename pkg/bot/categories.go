@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/category"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"strings"
+)
+
+const categoriesUsage = "Usage:\n" +
+	"/categories - your expense and income categories\n" +
+	"/categories add Groceries - add an expense category\n" +
+	"/categories add Transport > Taxi - add a subcategory, counted towards Transport in summaries\n" +
+	"/categories add income Bonus - add an income category\n" +
+	"/categories rename Taxi to Cab - rename a category and its transactions, budgets and recurring expenses\n" +
+	"/categories delete Taxi - remove a category, its transactions keep their category"
+
+// userCategories returns the user's categories, seeding them from the configuration for new users.
+// Without the database the configured categories are used as they are.
+func (b *Bot) userCategories(chatID int64) []category.Category {
+	if !b.botFeatures.SaveToDB {
+		return categoriesFromSeeds(b.categorySeeds)
+	}
+	if err := b.store.SeedCategories(chatID, b.categorySeeds); err != nil {
+		log.Printf("Chat %d: Could not seed categories: %v", chatID, err)
+	}
+	categories, err := b.store.GetCategories(chatID)
+	if err != nil {
+		log.Printf("Chat %d: Could not load categories, using the configured ones: %v", chatID, err)
+		return categoriesFromSeeds(b.categorySeeds)
+	}
+	return categories
+}
+
+// categoriesFromSeeds numbers the seeds like the store would when seeding a new user.
+func categoriesFromSeeds(seeds []category.Seed) []category.Category {
+	ids := make(map[string]int64, len(seeds))
+	categories := make([]category.Category, 0, len(seeds))
+	for i, seed := range seeds {
+		id := int64(i + 1)
+		ids[strings.ToLower(seed.Name)] = id
+		categories = append(categories, category.Category{ID: id, Name: seed.Name, Type: seed.Type, ParentID: ids[strings.ToLower(seed.Parent)]})
+	}
+	return categories
+}
+
+// categoryLabel renders a subcategory with its parent, e.g. "Transport › Taxi".
+func categoryLabel(c category.Category, parents map[string]string) string {
+	if parent, ok := parents[c.Name]; ok {
+		return parent + " › " + c.Name
+	}
+	return c.Name
+}
+
+// handleCategories answers the /categories command and its add, rename and delete subcategories.
+func (b *Bot) handleCategories(chatID int64, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return b.sendText(chatID, b.categoriesText(chatID))
+	}
+	if !b.botFeatures.SaveToDB {
+		return b.sendText(chatID, "⚠️ Categories can only be changed when transactions are saved to the database.")
+	}
+	rest := strings.TrimSpace(strings.Join(fields[1:], " "))
+	switch strings.ToLower(fields[0]) {
+	case "add":
+		return b.addCategory(chatID, rest)
+	case "rename":
+		return b.renameCategory(chatID, rest)
+	case "delete", "remove":
+		return b.deleteCategory(chatID, rest)
+	default:
+		return b.sendText(chatID, categoriesUsage)
+	}
+}
+
+// categoriesText lists the user's categories as a tree, expenses first.
+func (b *Bot) categoriesText(chatID int64) string {
+	categories := b.userCategories(chatID)
+	parents := category.Parents(categories)
+
+	var builder strings.Builder
+	for _, section := range []struct {
+		title string
+		t     transaction.Type
+	}{{"Expense categories:", transaction.TypeExpense}, {"Income categories:", transaction.TypeIncome}} {
+		builder.WriteString(section.title)
+		count := 0
+		for _, c := range category.Ordered(categories) {
+			if c.Type != section.t {
+				continue
+			}
+			if _, ok := parents[c.Name]; ok {
+				builder.WriteString("\n    └ " + c.Name)
+			} else {
+				builder.WriteString("\n- " + c.Name)
+			}
+			count++
+		}
+		if count == 0 {
+			builder.WriteString("\nnone")
+		}
+		builder.WriteString("\n\n")
+	}
+	builder.WriteString(categoriesUsage)
+	return builder.String()
+}
+
+// addCategory handles "/categories add [income] [<parent> >] <name>".
+func (b *Bot) addCategory(chatID int64, args string) error {
+	c := category.Category{UserID: chatID, Type: transaction.TypeExpense}
+	if first, rest, _ := strings.Cut(args, " "); strings.EqualFold(first, string(transaction.TypeIncome)) {
+		c.Type, args = transaction.TypeIncome, rest
+	}
+	parentName, name := category.SplitPath(args)
+	if name == "" {
+		return b.sendText(chatID, categoriesUsage)
+	}
+	c.Name = name
+
+	categories := b.userCategories(chatID)
+	if parentName != "" {
+		parent, ok := category.Find(categories, parentName)
+		if !ok {
+			return b.sendText(chatID, fmt.Sprintf("⚠️ You have no category called %s.", parentName))
+		}
+		// A subcategory always has the type of its parent.
+		c.ParentID, c.Type = parent.ID, parent.Type
+	}
+	if err := c.Validate(); err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Invalid category: %s", err))
+	}
+	if _, err := b.store.InsertCategory(c); err != nil {
+		return b.sendText(chatID, b.categoryErrorText(chatID, c.Name, err))
+	}
+	if parentName != "" {
+		return b.sendText(chatID, fmt.Sprintf("🗂 Added %s under %s.", c.Name, parentName))
+	}
+	return b.sendText(chatID, fmt.Sprintf("🗂 Added the %s category %s.", c.Type, c.Name))
+}
+
+// renameCategory handles "/categories rename <name> to <new name>".
+func (b *Bot) renameCategory(chatID int64, args string) error {
+	oldName, newName, found := strings.Cut(args, " to ")
+	if !found || strings.TrimSpace(oldName) == "" || strings.TrimSpace(newName) == "" {
+		return b.sendText(chatID, categoriesUsage)
+	}
+	c, ok := category.Find(b.userCategories(chatID), oldName)
+	if !ok {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ You have no category called %s.", strings.TrimSpace(oldName)))
+	}
+	oldName, c.Name = c.Name, newName
+	if err := c.Validate(); err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Invalid category: %s", err))
+	}
+	if err := b.store.UpdateCategory(c); err != nil {
+		return b.sendText(chatID, b.categoryErrorText(chatID, c.Name, err))
+	}
+	return b.sendText(chatID, fmt.Sprintf("Renamed %s to %s, together with its transactions, budgets and recurring expenses.", oldName, c.Name))
+}
+
+// deleteCategory handles "/categories delete <name>".
+func (b *Bot) deleteCategory(chatID int64, name string) error {
+	if name == "" {
+		return b.sendText(chatID, categoriesUsage)
+	}
+	c, ok := category.Find(b.userCategories(chatID), name)
+	if !ok {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ You have no category called %s.", name))
+	}
+	if err := b.store.DeleteCategory(chatID, c.ID); err != nil {
+		return b.sendText(chatID, b.categoryErrorText(chatID, c.Name, err))
+	}
+	return b.sendText(chatID, fmt.Sprintf("Removed the category %s. Its transactions keep their category.", c.Name))
+}
+
+// categoryErrorText explains why a category could not be saved or removed.
+func (b *Bot) categoryErrorText(chatID int64, name string, err error) string {
+	switch {
+	case errors.Is(err, storage.ErrCategoryExists):
+		return fmt.Sprintf("⚠️ You already have a category called %s.", name)
+	case errors.Is(err, storage.ErrInvalidParent), errors.Is(err, storage.ErrNotFound):
+		return fmt.Sprintf("⚠️ %s", err)
+	default:
+		log.Printf("Chat %d: Error changing category %s: %v", chatID, name, err)
+		return "Sorry, there was an error changing your categories. Please try again later."
+	}
+}
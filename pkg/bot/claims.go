@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"main/pkg/report"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const claimsUsage = "Usage:\n" +
+	"/claims - list your outstanding claims\n" +
+	"/claims submitted 12 [reference] - mark claim 12 as submitted, e.g. with the expense report number\n" +
+	"/claims reimbursed 12 [reference] - mark claim 12 as paid back\n" +
+	"/claims rejected 12 [reference] - mark claim 12 as rejected"
+
+// claimCallbackPrefix marks the inline buttons of the claims list, "claim:<id>:<status>".
+const claimCallbackPrefix = "claim:"
+
+// maxListedClaims caps the claims listed with buttons in one message.
+const maxListedClaims = 20
+
+// handleClaims answers the /claims command, which lists the outstanding claims or changes the status of one.
+func (b *Bot) handleClaims(chatID int64, args string) error {
+	if !b.botFeatures.SaveToDB {
+		return b.sendText(chatID, "⚠️ Claims are only available when transactions are saved to the database.")
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		text, keyboard := b.claimsList(chatID)
+		msg := tgbotapi.NewMessage(chatID, text)
+		if keyboard != nil {
+			msg.ReplyMarkup = *keyboard
+		}
+		_, err := b.api.Send(msg)
+		return err
+	}
+
+	if len(fields) < 2 {
+		return b.sendText(chatID, claimsUsage)
+	}
+	status, err := transaction.ParseClaimStatus(fields[0])
+	id, idErr := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || idErr != nil || id < 1 {
+		return b.sendText(chatID, claimsUsage)
+	}
+	return b.sendText(chatID, b.moveClaim(chatID, id, status, strings.Join(fields[2:], " ")))
+}
+
+// handleClaimCallback handles a button of the claims list and refreshes the list in place.
+func (b *Bot) handleClaimCallback(callbackQuery *tgbotapi.CallbackQuery) error {
+	chatID := callbackQuery.Message.Chat.ID
+
+	parts := strings.Split(strings.TrimPrefix(callbackQuery.Data, claimCallbackPrefix), ":")
+	var text string
+	if len(parts) != 2 {
+		text = "Unknown claim action."
+	} else if id, err := strconv.ParseInt(parts[0], 10, 64); err != nil {
+		text = "Unknown claim action."
+	} else if status, err := transaction.ParseClaimStatus(parts[1]); err != nil {
+		text = "Unknown claim action."
+	} else {
+		text = b.moveClaim(chatID, id, status, "")
+	}
+
+	// The answer is shown as a short notification, the list is then updated with the new status.
+	if _, err := b.api.Request(tgbotapi.NewCallback(callbackQuery.ID, text)); err != nil {
+		log.Printf("Could not answer callback query %s: %v", callbackQuery.ID, err)
+	}
+
+	listText, keyboard := b.claimsList(chatID)
+	var edit tgbotapi.EditMessageTextConfig
+	if keyboard != nil {
+		edit = tgbotapi.NewEditMessageTextAndMarkup(chatID, callbackQuery.Message.MessageID, listText, *keyboard)
+	} else {
+		edit = tgbotapi.NewEditMessageText(chatID, callbackQuery.Message.MessageID, listText)
+	}
+	if _, err := b.api.Send(edit); err != nil {
+		log.Printf("Chat %d: Could not update claims list: %v", chatID, err)
+		return b.sendText(chatID, text)
+	}
+	return nil
+}
+
+// moveClaim changes the status of a claim and returns the reply to the user.
+func (b *Bot) moveClaim(chatID, id int64, status transaction.ClaimStatus, reference string) string {
+	t, err := b.store.UpdateClaim(chatID, id, status, reference, time.Now())
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return fmt.Sprintf("⚠️ Transaction %d not found.", id)
+	case errors.Is(err, transaction.ErrClaimTransition):
+		return fmt.Sprintf("⚠️ Claim %d cannot be marked %s: %v", id, status, err)
+	case err != nil:
+		log.Printf("Chat %d: Error updating claim %d: %v", chatID, id, err)
+		return "Sorry, the claim could not be updated. Please try again later."
+	}
+	text := fmt.Sprintf("Claim %d (%s, %s %s) is now %s.", t.ID, t.Name, t.FormattedAmount(), t.Currency, t.ClaimStatus)
+	if t.ClaimReference != "" {
+		text += fmt.Sprintf(" Reference: %s.", t.ClaimReference)
+	}
+	return text
+}
+
+// claimsList lists the outstanding claims, oldest first, with buttons to move each one along,
+// followed by the outstanding and reimbursed totals. The keyboard is nil when nothing is outstanding.
+func (b *Bot) claimsList(chatID int64) (string, *tgbotapi.InlineKeyboardMarkup) {
+	page, err := b.store.GetAllTransactions(chatID,
+		storage.TransactionFilter{ClaimStatuses: []transaction.ClaimStatus{transaction.ClaimClaimable, transaction.ClaimSubmitted}},
+		storage.PageRequest{Sort: storage.Sort{Field: storage.SortByDate}, Limit: maxListedClaims, IncludeTotal: true},
+	)
+	if err != nil {
+		log.Printf("Chat %d: Error getting claims: %v", chatID, err)
+		return "Sorry, I couldn't retrieve your claims at this time. Please try again later.", nil
+	}
+	totals, err := b.reports.Claims(chatID, report.Period{Label: "All time"})
+	if err != nil {
+		log.Printf("Chat %d: Error getting claim totals: %v", chatID, err)
+		return "Sorry, I couldn't retrieve your claims at this time. Please try again later.", nil
+	}
+
+	var builder strings.Builder
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(page.Transactions) == 0 {
+		builder.WriteString("You have no outstanding claims.")
+	} else {
+		builder.WriteString("Outstanding claims:")
+	}
+	for _, t := range page.Transactions {
+		builder.WriteString(fmt.Sprintf("\n%d. %s: %s %s on %s, %s", t.ID, t.Name, t.FormattedAmount(), t.Currency, t.Date, describeClaim(t)))
+		rows = append(rows, claimButtons(t))
+	}
+	if page.Total != nil && *page.Total > len(page.Transactions) {
+		builder.WriteString(fmt.Sprintf("\n…and %d more", *page.Total-len(page.Transactions)))
+	}
+	builder.WriteString("\n")
+	writeClaimTotals(&builder, totals)
+
+	if len(rows) == 0 {
+		return builder.String(), nil
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return builder.String(), &keyboard
+}
+
+// claimButtons offers the next steps of an outstanding claim.
+func claimButtons(t transaction.Transaction) []tgbotapi.InlineKeyboardButton {
+	next := []transaction.ClaimStatus{transaction.ClaimSubmitted, transaction.ClaimReimbursed}
+	if t.CurrentClaimStatus() == transaction.ClaimSubmitted {
+		next = []transaction.ClaimStatus{transaction.ClaimReimbursed, transaction.ClaimRejected}
+	}
+	labels := map[transaction.ClaimStatus]string{
+		transaction.ClaimSubmitted:  "📤 Submitted",
+		transaction.ClaimReimbursed: "✅ Reimbursed",
+		transaction.ClaimRejected:   "❌ Rejected",
+	}
+	buttons := make([]tgbotapi.InlineKeyboardButton, 0, len(next))
+	for _, status := range next {
+		data := fmt.Sprintf("%s%d:%s", claimCallbackPrefix, t.ID, status)
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s %d", labels[status], t.ID), data))
+	}
+	return buttons
+}
+
+// describeClaim renders the status of a claim with its submission date and reference.
+func describeClaim(t transaction.Transaction) string {
+	text := string(t.CurrentClaimStatus())
+	if t.ClaimStatus == transaction.ClaimSubmitted && t.ClaimSubmittedAt != nil {
+		text += " " + t.ClaimSubmittedAt.Format("2006-01-02")
+	}
+	if t.ClaimReference != "" {
+		text += fmt.Sprintf(" (ref %s)", t.ClaimReference)
+	}
+	return text
+}
+
+// writeClaimTotals writes the outstanding and reimbursed totals.
+func writeClaimTotals(builder *strings.Builder, totals report.ClaimTotals) {
+	for _, line := range []report.Line{totals.Outstanding, totals.Reimbursed} {
+		builder.WriteString(fmt.Sprintf("\n- %s: %s", line.Group, formatOptionalLine(line)))
+	}
+}
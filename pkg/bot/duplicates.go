@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"main/pkg/session"
+	"main/pkg/transaction"
+)
+
+// findDuplicate returns the ID of a saved transaction of the chat that looks like t, or 0.
+// Duplicates are never checked when the check is disabled or fails.
+func (b *Bot) findDuplicate(chatID int64, t transaction.Transaction) int64 {
+	if b.duplicates.Disabled {
+		return 0
+	}
+	id, err := b.store.FindDuplicate(t, b.duplicates.WindowDays)
+	if err != nil {
+		log.Printf("Chat %d: Could not check for duplicate transactions: %v", chatID, err)
+		return 0
+	}
+	return id
+}
+
+// askSaveDuplicate asks whether to save the session's transaction although it looks like one already saved.
+func (b *Bot) askSaveDuplicate(chatID int64, s *session.UserSession) error {
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Looks like a duplicate of transaction %d: %s, %s %s on %s. Save anyway?",
+		s.DuplicateOf, s.Answers.Name, s.Answers.FormattedAmount(), s.Answers.Currency, s.Answers.Date))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Save anyway", "yes"),
+		tgbotapi.NewInlineKeyboardButtonData("Discard", "no"),
+	))
+	sent, err := b.api.Send(msg)
+	if err != nil {
+		return err
+	}
+	s.LastQuestionMessageID = sent.MessageID
+	return nil
+}
+
+// answerDuplicate saves the session's transaction after all, or discards it and ends the session.
+func (b *Bot) answerDuplicate(chatID int64, save bool, userSessions map[int64]*session.UserSession) error {
+	s := userSessions[chatID]
+	// Typed answers leave the question behind, the buttons already removed it.
+	if s.LastQuestionMessageID != 0 {
+		_, _ = b.api.Request(tgbotapi.NewDeleteMessage(chatID, s.LastQuestionMessageID))
+		s.LastQuestionMessageID = 0
+	}
+	if save {
+		s.DuplicateOf, s.DuplicateConfirmed = 0, true
+		return b.completeSession(chatID, s)
+	}
+
+	delete(userSessions, chatID)
+	if err := b.sendText(chatID, "Discarded, nothing was saved."); err != nil {
+		return err
+	}
+	return b.sendDefaultMessage(chatID)
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"main/pkg/exchange"
+	"main/pkg/money"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const exchangeRateUsage = "Usage:\n/rate USD - today's rate\n/rate USD 2025-01-31 - the rate on a day\n/rate 42 - the rate recorded for transaction 42"
+
+// sendExchangeRate answers the /rate command. With a currency (and optional date) it shows the rate
+// currently used for conversion, with a transaction id it shows the rate recorded when it was saved.
+func (b *Bot) sendExchangeRate(chatID int64, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return b.sendText(chatID, exchangeRateUsage)
+	}
+	if id, err := strconv.ParseInt(args[0], 10, 64); err == nil && len(args) == 1 {
+		return b.sendText(chatID, b.transactionRateText(chatID, id))
+	}
+
+	date := time.Now().Format("2006-01-02")
+	if len(args) == 2 {
+		parsed, err := transaction.ParseDate(args[1])
+		if err != nil {
+			return b.sendText(chatID, fmt.Sprintf("⚠️ %s\n\n%s", err, exchangeRateUsage))
+		}
+		date = parsed.Format("2006-01-02")
+	}
+	return b.sendText(chatID, b.currencyRateText(strings.ToUpper(args[0]), date))
+}
+
+// currencyRateText describes the rate that converts the currency into the base currency on the date.
+func (b *Bot) currencyRateText(currency, date string) string {
+	quote, err := b.rates.Lookup(currency, date)
+	if errors.Is(err, exchange.ErrNoRate) {
+		return fmt.Sprintf("No exchange rate for %s on %s.", currency, date)
+	}
+	if err != nil {
+		log.Printf("Error looking up exchange rate for %s on %s: %v", currency, date, err)
+		return "Sorry, I couldn't look up the exchange rate. Please try again later."
+	}
+
+	source := "from the configuration"
+	if quote.Source == exchange.SourceHistory && quote.Date != "" {
+		source = fmt.Sprintf("published %s", quote.Date)
+	}
+	return fmt.Sprintf("1 %s = %s %s on %s (%s).", quote.Currency, quote.Rate, quote.BaseCurrency, date, source)
+}
+
+// transactionRateText describes the rate recorded on one of the user's transactions.
+func (b *Bot) transactionRateText(chatID, id int64) string {
+	t, err := b.store.GetTransaction(chatID, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Sprintf("Transaction %d not found.", id)
+	}
+	if err != nil {
+		log.Printf("Chat %d: Error getting transaction %d: %v", chatID, id, err)
+		return "Sorry, I couldn't retrieve the transaction. Please try again later."
+	}
+
+	if t.BaseCurrency == "" {
+		return fmt.Sprintf("%s (%s %s on %s) was saved without an exchange rate.", t.Name, t.FormattedAmount(), t.Currency, t.Date)
+	}
+	return fmt.Sprintf("%s: %s %s on %s at 1 %s = %s %s, recorded as %s %s.",
+		t.Name, t.FormattedAmount(), t.Currency, t.Date, t.Currency, t.ExchangeRate, t.BaseCurrency,
+		money.Format(t.BaseAmount, t.BaseCurrency), t.BaseCurrency)
+}
+
+// sendText sends a plain text message.
+func (b *Bot) sendText(chatID int64, text string) error {
+	_, err := b.api.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
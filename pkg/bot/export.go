@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"main/pkg/export"
+	"main/pkg/report"
+	"main/pkg/storage"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// sendExport sends the user's transactions of the period given as the command's argument as a CSV document,
+// e.g. "/export last month". Without an argument the current month is exported.
+func (b *Bot) sendExport(chatID int64, periodArg string) error {
+	period, err := report.ParsePeriod(periodArg, time.Now())
+	if err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ %s\n%s", err, report.PeriodUsage))
+	}
+
+	var csv bytes.Buffer
+	written, err := b.exporter.Write(&csv, b.store, chatID, storage.TransactionFilter{DateRange: period.DateRange()}, export.DefaultSort)
+	if err != nil {
+		log.Printf("Chat %d: Error exporting transactions: %v", chatID, err)
+		return b.sendText(chatID, "Sorry, I couldn't export your transactions at this time. Please try again later.")
+	}
+	if written == 0 {
+		return b.sendText(chatID, fmt.Sprintf("You have no transactions in %s.", period.Label))
+	}
+
+	document := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: exportFileName(period), Bytes: csv.Bytes()})
+	document.Caption = fmt.Sprintf("%d transactions for %s", written, period.Label)
+	if _, err = b.api.Send(document); err != nil {
+		log.Printf("Chat %d: Error sending export: %v", chatID, err)
+		return err
+	}
+	return nil
+}
+
+// exportFileName names the CSV of a period after its label, e.g. "transactions-october-2025.csv".
+func exportFileName(period report.Period) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, period.Label)
+	return "transactions-" + strings.Trim(name, "-") + ".csv"
+}
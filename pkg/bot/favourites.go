@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/frequent"
+	"main/pkg/storage"
+	"strings"
+	"time"
+)
+
+const favouriteUsage = "Usage:\n" +
+	"/favourite - your frequent expenses\n" +
+	"/favourite save - save the expense you just added as a frequent expense\n" +
+	"/favourite save Coffee - the same, under another name\n" +
+	"/favourite delete Coffee - remove a frequent expense"
+
+// frequentExpenses returns the user's frequent expenses, seeding them from the configuration for new users.
+// Without the database the configured frequent expenses are used as they are.
+func (b *Bot) frequentExpenses(chatID int64) []frequent.Expense {
+	if !b.botFeatures.SaveToDB {
+		return frequent.FromConfig(b.preFilledFrequentExpenses)
+	}
+	if err := b.store.SeedFrequentExpenses(chatID, b.preFilledFrequentExpenses); err != nil {
+		log.Printf("Chat %d: Could not seed frequent expenses: %v", chatID, err)
+	}
+	expenses, err := b.store.GetFrequentExpenses(chatID)
+	if err != nil {
+		log.Printf("Chat %d: Could not load frequent expenses, using the configured ones: %v", chatID, err)
+		return frequent.FromConfig(b.preFilledFrequentExpenses)
+	}
+	return expenses
+}
+
+// frequentSuggestions returns the frequent expenses suggested from the user's history, if suggestions are enabled.
+func (b *Bot) frequentSuggestions(chatID int64) []frequent.Suggestion {
+	if !b.botFeatures.SaveToDB || !b.suggestions.Enabled {
+		return nil
+	}
+	suggestions, err := b.reports.Suggestions(chatID, b.suggestions, time.Now())
+	if err != nil {
+		// Suggestions are a convenience, the expense can still be entered by hand.
+		log.Printf("Chat %d: Could not suggest frequent expenses: %v", chatID, err)
+		return nil
+	}
+	return suggestions
+}
+
+// handleFavourite answers the /favourite command and its save and delete subcommands.
+func (b *Bot) handleFavourite(chatID int64, args string) error {
+	if !b.botFeatures.SaveToDB {
+		return b.sendText(chatID, "⚠️ Frequent expenses can only be changed when transactions are saved to the database.")
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return b.sendText(chatID, b.favouritesText(chatID))
+	}
+	rest := strings.TrimSpace(strings.Join(fields[1:], " "))
+	switch strings.ToLower(fields[0]) {
+	case "save", "add":
+		return b.saveFavourite(chatID, rest)
+	case "delete", "remove":
+		return b.deleteFavourite(chatID, rest)
+	default:
+		return b.sendText(chatID, favouriteUsage)
+	}
+}
+
+// favouritesText lists the user's frequent expenses, followed by the suggested ones.
+func (b *Bot) favouritesText(chatID int64) string {
+	var builder strings.Builder
+	builder.WriteString("Frequent expenses:")
+	expenses := b.frequentExpenses(chatID)
+	if len(expenses) == 0 {
+		builder.WriteString("\nnone")
+	}
+	for _, e := range expenses {
+		builder.WriteString(fmt.Sprintf("\n- %s: %s", e.Name, describeFavourite(e)))
+	}
+
+	if suggestions := b.frequentSuggestions(chatID); len(suggestions) > 0 {
+		builder.WriteString("\n\nSuggested from your recent expenses:")
+		for _, s := range suggestions {
+			builder.WriteString(fmt.Sprintf("\n- %s: %s, usually %s %s (%d times)", s.Name, describeFavourite(s.Expense()),
+				s.FormattedTypicalAmount(), s.Currency, s.Count))
+		}
+	}
+
+	builder.WriteString("\n\n" + favouriteUsage)
+	return builder.String()
+}
+
+// describeFavourite renders the category and currency a frequent expense fills in, e.g. "Food in SGD".
+func describeFavourite(e frequent.Expense) string {
+	category, currency := e.Category, e.Currency
+	if category == "" {
+		category = "any category"
+	}
+	if currency == "" {
+		currency = "any currency"
+	}
+	return fmt.Sprintf("%s in %s", category, currency)
+}
+
+// saveFavourite handles "/favourite save [name]", saving the expense last added in the chat.
+func (b *Bot) saveFavourite(chatID int64, name string) error {
+	id, ok := b.lastTransactions[chatID]
+	if !ok {
+		return b.sendText(chatID, "⚠️ Add an expense with /add first, then send /favourite save to reuse it.")
+	}
+	t, err := b.store.GetTransaction(chatID, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return b.sendText(chatID, "⚠️ The expense you added last no longer exists.")
+	}
+	if err != nil {
+		log.Printf("Chat %d: Error loading transaction %d: %v", chatID, id, err)
+		return b.sendText(chatID, "Sorry, there was an error saving your frequent expense. Please try again later.")
+	}
+
+	e := frequent.FromTransaction(t)
+	if name != "" {
+		e.Name = name
+	}
+	if err = e.Validate(); err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Invalid frequent expense: %s", err))
+	}
+	// Seeding first keeps a new user's first own frequent expense from replacing the configured ones.
+	if err = b.store.SeedFrequentExpenses(chatID, b.preFilledFrequentExpenses); err != nil {
+		log.Printf("Chat %d: Could not seed frequent expenses: %v", chatID, err)
+	}
+	if _, err = b.store.InsertFrequentExpense(e); err != nil {
+		if errors.Is(err, storage.ErrFrequentExpenseExists) {
+			return b.sendText(chatID, fmt.Sprintf("⚠️ You already have a frequent expense called %s. Save it under another name with /favourite save <name>.", e.Name))
+		}
+		log.Printf("Chat %d: Error saving frequent expense: %v", chatID, err)
+		return b.sendText(chatID, "Sorry, there was an error saving your frequent expense. Please try again later.")
+	}
+	return b.sendText(chatID, fmt.Sprintf("⭐ Saved %s (%s) as a frequent expense. Pick it next time you /add.", e.Name, describeFavourite(e)))
+}
+
+// deleteFavourite handles "/favourite delete <name>".
+func (b *Bot) deleteFavourite(chatID int64, name string) error {
+	if name == "" {
+		return b.sendText(chatID, favouriteUsage)
+	}
+	e, ok := frequent.Find(b.frequentExpenses(chatID), name)
+	if !ok {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ You have no frequent expense called %s.", name))
+	}
+	if err := b.store.DeleteFrequentExpense(chatID, e.ID); err != nil {
+		log.Printf("Chat %d: Error deleting frequent expense %d: %v", chatID, e.ID, err)
+		return b.sendText(chatID, "Sorry, there was an error removing your frequent expense. Please try again later.")
+	}
+	return b.sendText(chatID, fmt.Sprintf("Removed the frequent expense %s.", e.Name))
+}
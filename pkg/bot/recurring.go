@@ -0,0 +1,175 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/frequent"
+	"main/pkg/recurring"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const recurringUsage = "Usage:\n" +
+	"/recurring - list your recurring expenses\n" +
+	"/recurring add Rent 1500 monthly [start YYYY-MM-DD] [end YYYY-MM-DD] - record a frequent expense daily, weekly, monthly or yearly\n" +
+	"/recurring stop 3 - stop recurring expense 3, keeping what it recorded"
+
+// maxListedDates caps the dates listed in one notification after a long catch-up.
+const maxListedDates = 10
+
+// RunRecurringRules records the transactions of recurring rules as they come due, catching up on
+// the ones missed while the bot was down, and tells their owners. It blocks until ctx is cancelled.
+func (b *Bot) RunRecurringRules(ctx context.Context) {
+	b.scheduler.Run(ctx)
+}
+
+// handleRecurring answers the /recurring command and its add and stop subcommands.
+func (b *Bot) handleRecurring(chatID int64, args string) error {
+	// Without a database the rules would be lost on restart and the scheduler does not run.
+	if !b.botFeatures.SaveToDB {
+		return b.sendText(chatID, "⚠️ Recurring expenses are only available when transactions are saved to the database.")
+	}
+
+	subcommand, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	switch strings.ToLower(subcommand) {
+	case "":
+		return b.sendText(chatID, b.recurringRulesText(chatID))
+	case "add":
+		return b.addRecurringRule(chatID, strings.Fields(rest))
+	case "stop":
+		id, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+		if err != nil || id < 1 {
+			return b.sendText(chatID, recurringUsage)
+		}
+		err = b.store.DeleteRecurringRule(chatID, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			return b.sendText(chatID, fmt.Sprintf("⚠️ Recurring expense %d not found.", id))
+		}
+		if err != nil {
+			log.Printf("Chat %d: Error deleting recurring rule %d: %v", chatID, id, err)
+			return b.sendText(chatID, "Sorry, the recurring expense could not be stopped. Please try again later.")
+		}
+		return b.sendText(chatID, fmt.Sprintf("🔁 Stopped recurring expense %d. The expenses it recorded are kept.", id))
+	default:
+		return b.sendText(chatID, recurringUsage)
+	}
+}
+
+// recurringRulesText lists the user's rules with their next occurrence.
+func (b *Bot) recurringRulesText(chatID int64) string {
+	rules, err := b.store.GetRecurringRules(chatID)
+	if err != nil {
+		log.Printf("Chat %d: Error getting recurring rules: %v", chatID, err)
+		return "Sorry, I couldn't retrieve your recurring expenses at this time. Please try again later."
+	}
+	if len(rules) == 0 {
+		return "You have no recurring expenses.\n\n" + recurringUsage
+	}
+
+	today := time.Now().Format("2006-01-02")
+	var builder strings.Builder
+	builder.WriteString("Recurring expenses:")
+	for _, rule := range rules {
+		builder.WriteString(fmt.Sprintf("\n%d. %s: %s %s, %s", rule.ID, rule.Name, rule.FormattedAmount(), rule.Currency, rule.Describe()))
+		if next, ok, err := rule.Next(today); err == nil && ok {
+			builder.WriteString(fmt.Sprintf(" (next %s)", next))
+		} else if err == nil {
+			builder.WriteString(" (ended)")
+		}
+	}
+	return builder.String()
+}
+
+// addRecurringRule creates a rule from a frequent expense, e.g. "Rent 1500 monthly 2025-01-01",
+// and immediately records the occurrences that are already due.
+func (b *Bot) addRecurringRule(chatID int64, fields []string) error {
+	// The name may contain spaces, so the arguments are taken from the end: up to two dates,
+	// then the frequency and the amount.
+	var dates []string
+	for len(fields) > 0 && len(dates) < 2 {
+		date, err := transaction.ParseDate(fields[len(fields)-1])
+		if err != nil {
+			break
+		}
+		dates = append([]string{date.Format("2006-01-02")}, dates...)
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) < 3 {
+		return b.sendText(chatID, recurringUsage)
+	}
+	frequency, err := recurring.ParseFrequency(fields[len(fields)-1])
+	if err != nil || frequency == recurring.Cron {
+		return b.sendText(chatID, "⚠️ Use daily, weekly, monthly or yearly. Cron schedules can be set up through the API.")
+	}
+	amountText := fields[len(fields)-2]
+	name := strings.Join(fields[:len(fields)-2], " ")
+
+	expenses := b.frequentExpenses(chatID)
+	expense, ok := frequent.Find(expenses, name)
+	if !ok {
+		names := make([]string, 0, len(expenses))
+		for _, e := range expenses {
+			names = append(names, e.Name)
+		}
+		return b.sendText(chatID, fmt.Sprintf("⚠️ %q is not one of your frequent expenses (%s).", name, strings.Join(names, ", ")))
+	}
+	amount, err := transaction.ValidateAmount(amountText, expense.Currency)
+	if err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ %s\n\n%s", err, recurringUsage))
+	}
+
+	rule := recurring.Rule{
+		UserID:        chatID,
+		Name:          expense.Name,
+		Amount:        amount,
+		Currency:      expense.Currency,
+		Category:      expense.Category,
+		IsClaimable:   expense.IsClaimable,
+		PaidForFamily: expense.PaidForFamily,
+		Frequency:     frequency,
+		StartDate:     time.Now().Format("2006-01-02"),
+	}
+	if len(dates) > 0 {
+		rule.StartDate = dates[0]
+	}
+	if len(dates) > 1 {
+		rule.EndDate = dates[1]
+	}
+	if err = rule.Validate(); err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ Invalid recurring expense: %s", err))
+	}
+
+	if rule.ID, err = b.store.InsertRecurringRule(rule); err != nil {
+		log.Printf("Chat %d: Error saving recurring rule: %v", chatID, err)
+		return b.sendText(chatID, "Sorry, there was an error saving your recurring expense. Please try again later.")
+	}
+	if err = b.sendText(chatID, fmt.Sprintf("🔁 Saved recurring expense %d: %s %s %s, %s.",
+		rule.ID, rule.Name, rule.FormattedAmount(), rule.Currency, rule.Describe())); err != nil {
+		return err
+	}
+
+	// Occurrences from a start date in the past are recorded right away rather than at the next check.
+	if _, err = b.scheduler.Record(rule, time.Now().Format("2006-01-02")); err != nil {
+		log.Printf("Chat %d: Error recording recurring rule %d, retrying at the next check: %v", chatID, rule.ID, err)
+	}
+	return nil
+}
+
+// notifyRecurring tells the owner of a rule about the transactions the scheduler recorded for it.
+func (b *Bot) notifyRecurring(rule recurring.Rule, recorded []transaction.Transaction) error {
+	dates := make([]string, 0, len(recorded))
+	for i, t := range recorded {
+		if i == maxListedDates {
+			dates = append(dates, fmt.Sprintf("and %d more", len(recorded)-maxListedDates))
+			break
+		}
+		dates = append(dates, t.Date)
+	}
+	return b.sendText(rule.UserID, fmt.Sprintf("🔁 Recorded your recurring expense %s (%s %s) for %s.",
+		rule.Name, rule.FormattedAmount(), rule.Currency, strings.Join(dates, ", ")))
+}
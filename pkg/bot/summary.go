@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"main/pkg/report"
+	"strings"
+	"time"
+)
+
+// sendSummary sends the user's spending summary for the period given as the command's argument,
+// e.g. "/summary last month". Without an argument the current month is summarised.
+func (b *Bot) sendSummary(chatID int64, periodArg string) error {
+	period, err := report.ParsePeriod(periodArg, time.Now())
+	if err != nil {
+		return b.sendText(chatID, fmt.Sprintf("⚠️ %s\n%s", err, report.PeriodUsage))
+	}
+
+	summary, err := b.reports.Build(chatID, period)
+	if err != nil {
+		log.Printf("Chat %d: Error getting transaction summary: %v", chatID, err)
+		// Send a generic error message to the user
+
+		errMsg := tgbotapi.NewMessage(chatID, "Sorry, I couldn't retrieve the transaction summary at this time. Please try again later.")
+		_, sendErr := b.api.Send(errMsg)
+		if sendErr != nil {
+			log.Printf("Chat %d: Error sending summary error message: %v", chatID, sendErr)
+		}
+
+		if err := b.sendDefaultMessage(chatID); err != nil {
+			log.Printf("Chat %d: Error sending default message: %v", chatID, err)
+		}
+
+		return err // Return the original error
+	}
+
+	var summaryMessageBuilder strings.Builder
+	summaryMessageBuilder.WriteString(fmt.Sprintf("Transaction Summary for %s", summary.Period.Label))
+	if summary.Period.From != "" && !strings.Contains(summary.Period.Label, summary.Period.From) {
+		summaryMessageBuilder.WriteString(fmt.Sprintf(" (%s to %s)", summary.Period.From, summary.Period.To))
+	}
+	summaryMessageBuilder.WriteString("\n\nBy Category:")
+	if len(summary.Categories) == 0 {
+		summaryMessageBuilder.WriteString("\nNo transactions found.")
+	} else {
+		writeCategoryLines(&summaryMessageBuilder, summary.CategoryRollups)
+		summaryMessageBuilder.WriteString(fmt.Sprintf("\n- Total Expenses: %s", formatSummaryLine(summary.Total)))
+	}
+
+	summaryMessageBuilder.WriteString("\n\nCash flow:")
+	for _, line := range []report.Line{summary.Income, summary.Expense, summary.Net} {
+		summaryMessageBuilder.WriteString(fmt.Sprintf("\n- %s: %s", line.Group, formatOptionalLine(line)))
+	}
+
+	summaryMessageBuilder.WriteString("\n\nTotal claimable:")
+	writeSummaryLines(&summaryMessageBuilder, summary.Claimable)
+	writeClaimTotals(&summaryMessageBuilder, summary.Claims)
+
+	summaryMessageBuilder.WriteString("\n\nTotal paid for family:")
+	writeSummaryLines(&summaryMessageBuilder, summary.PaidForFamily)
+
+	if len(summary.Tags) > 0 {
+		summaryMessageBuilder.WriteString("\n\nBy tag:")
+		writeSummaryLines(&summaryMessageBuilder, summary.Tags)
+	}
+
+	if len(summary.Budgets) > 0 {
+		summaryMessageBuilder.WriteString("\n\nBudgets:")
+		writeBudgetLines(&summaryMessageBuilder, summary.Budgets)
+	}
+
+	if summary.BaseCurrency != "" {
+		summaryMessageBuilder.WriteString(fmt.Sprintf("\n\n≈ amounts are converted into %s.", summary.BaseCurrency))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, summaryMessageBuilder.String())
+	_, sendErr := b.api.Send(msg)
+	if sendErr != nil {
+		log.Printf("Chat %d: Error sending summary message: %v", chatID, sendErr)
+	}
+	return sendErr
+}
+
+// writeSummaryLines writes one "- group: amounts" row per line.
+func writeSummaryLines(builder *strings.Builder, lines []report.Line) {
+	if len(lines) == 0 {
+		builder.WriteString("\nNo transactions found.")
+		return
+	}
+	for _, line := range lines {
+		builder.WriteString(fmt.Sprintf("\n- %v: %s", line.Group, formatSummaryLine(line)))
+	}
+}
+
+// writeCategoryLines writes the top-level category lines, each followed by its subcategories.
+func writeCategoryLines(builder *strings.Builder, lines []report.Line) {
+	for _, line := range lines {
+		builder.WriteString(fmt.Sprintf("\n- %v: %s", line.Group, formatSummaryLine(line)))
+		for _, sub := range line.Subcategories {
+			builder.WriteString(fmt.Sprintf("\n    └ %v: %s", sub.Group, formatSummaryLine(sub)))
+		}
+	}
+}
+
+// formatOptionalLine renders the line like formatSummaryLine, or "nothing" when it has no totals.
+func formatOptionalLine(line report.Line) string {
+	if len(line.Totals) == 0 {
+		return "nothing"
+	}
+	return formatSummaryLine(line)
+}
+
+// formatSummaryLine renders the per-currency totals followed by the converted total,
+// e.g. "12.50 SGD + 1500 JPY (≈ 26.15 SGD)".
+func formatSummaryLine(line report.Line) string {
+	parts := make([]string, 0, len(line.Totals))
+	for _, total := range line.Totals {
+		parts = append(parts, total.String())
+	}
+	text := strings.Join(parts, " + ")
+
+	// A single total already in the base currency needs no conversion.
+	if line.Converted == nil || (len(line.Totals) == 1 && line.Totals[0].Currency == line.Converted.Currency) {
+		return text
+	}
+	converted := fmt.Sprintf("≈ %s", line.Converted)
+	if len(line.MissingRates) > 0 {
+		converted += fmt.Sprintf(", excluding %s without exchange rate", strings.Join(line.MissingRates, ", "))
+	}
+	return fmt.Sprintf("%s (%s)", text, converted)
+}
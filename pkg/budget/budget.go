@@ -0,0 +1,92 @@
+package budget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/pkg/money"
+	"strings"
+	"time"
+)
+
+// Alert thresholds, in percent of a budget.
+const (
+	WarningPercent  = 80
+	ExceededPercent = 100
+)
+
+// Budget is a monthly spending limit for a category.
+// A budget in the reporting base currency covers the category's spending in every currency,
+// converted into the base currency; a budget in any other currency only covers spending in that currency.
+type Budget struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"` // Telegram chat ID of the owner
+	Category  string    `json:"category"`
+	Currency  string    `json:"currency"`
+	Amount    int64     `json:"-"` // Per month, in minor units of Currency, see budgetJSON
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate checks that the budget can be stored and normalises its category and currency.
+func (b *Budget) Validate() error {
+	b.Category = strings.TrimSpace(b.Category)
+	if b.Category == "" {
+		return errors.New("category is required")
+	}
+	b.Currency = strings.ToUpper(strings.TrimSpace(b.Currency))
+	if len(b.Currency) != 3 {
+		return errors.New("currency must be a 3-letter ISO 4217 code")
+	}
+	if b.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+	return nil
+}
+
+// FormattedAmount renders the amount as a decimal in the budget's currency, e.g. "500.00".
+func (b Budget) FormattedAmount() string {
+	return money.Format(b.Amount, b.Currency)
+}
+
+// budgetAlias has the fields of Budget without its JSON methods.
+type budgetAlias Budget
+
+// budgetJSON is the wire format of a Budget, with the amount written like a transaction's.
+type budgetJSON struct {
+	*budgetAlias
+	Amount      json.Number `json:"amount"`
+	AmountMinor *int64      `json:"amountMinor,omitempty"`
+}
+
+// MarshalJSON writes the amount losslessly as both a decimal and minor units.
+func (b Budget) MarshalJSON() ([]byte, error) {
+	alias := budgetAlias(b)
+	minor := b.Amount
+	return json.Marshal(budgetJSON{
+		budgetAlias: &alias,
+		Amount:      json.Number(money.Format(b.Amount, b.Currency)),
+		AmountMinor: &minor,
+	})
+}
+
+// UnmarshalJSON accepts the amount either as "amountMinor" or as a decimal "amount" in the budget's currency.
+func (b *Budget) UnmarshalJSON(data []byte) error {
+	aux := budgetJSON{budgetAlias: (*budgetAlias)(b)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	switch {
+	case aux.AmountMinor != nil:
+		b.Amount = *aux.AmountMinor
+	case aux.Amount != "":
+		amount, err := money.Parse(aux.Amount.String(), b.Currency)
+		if err != nil {
+			return fmt.Errorf("invalid amount: %w", err)
+		}
+		b.Amount = amount
+	default:
+		b.Amount = 0
+	}
+	return nil
+}
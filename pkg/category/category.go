@@ -0,0 +1,165 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+	"main/pkg/transaction"
+	"strings"
+	"time"
+)
+
+// PathSeparator separates a parent from its subcategory in seeds and bot commands, e.g. "Transport > Taxi".
+const PathSeparator = ">"
+
+// maxNameLength matches the categories.name column.
+const maxNameLength = 50
+
+// Category is one of a user's expense or income categories. Categories have at most two levels:
+// a top-level category such as Transport can have subcategories such as Taxi, which have none.
+// Transactions refer to their category by name, names are unique per user.
+type Category struct {
+	ID        int64            `json:"id"`
+	UserID    int64            `json:"-"` // Telegram chat ID of the owner
+	Name      string           `json:"name"`
+	Type      transaction.Type `json:"type"`               // TypeExpense or TypeIncome
+	ParentID  int64            `json:"parentId,omitempty"` // 0 for top-level categories
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+// Validate checks that the category can be stored and normalises its name and type.
+// Whether the parent exists is checked by the store.
+func (c *Category) Validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(c.Name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	if strings.Contains(c.Name, PathSeparator) {
+		return fmt.Errorf("name must not contain %q", PathSeparator)
+	}
+	if c.Type == "" {
+		c.Type = transaction.TypeExpense
+	}
+	t, err := transaction.ParseType(string(c.Type))
+	if err != nil {
+		return err
+	}
+	if t == transaction.TypeTransfer {
+		return errors.New("categories are for expenses or income, not transfers")
+	}
+	c.Type = t
+	if c.ParentID < 0 {
+		return errors.New("parent ID must be positive")
+	}
+	if c.ParentID != 0 && c.ParentID == c.ID {
+		return errors.New("a category cannot be its own parent")
+	}
+	return nil
+}
+
+// Seed is a category created for every user from the configuration, before they manage their own.
+type Seed struct {
+	Name   string
+	Parent string // Name of the parent category, "" for top-level categories
+	Type   transaction.Type
+}
+
+// ParseSeeds turns the configured expense and income categories into seeds. An entry such as
+// "Transport > Taxi" seeds Taxi as a subcategory of Transport, creating Transport if it is not listed.
+func ParseSeeds(expense, income []string) []Seed {
+	var seeds []Seed
+	seen := make(map[string]bool)
+	add := func(name, parent string, t transaction.Type) {
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		seeds = append(seeds, Seed{Name: name, Parent: parent, Type: t})
+	}
+	for _, list := range []struct {
+		names []string
+		t     transaction.Type
+	}{{expense, transaction.TypeExpense}, {income, transaction.TypeIncome}} {
+		for _, entry := range list.names {
+			parent, name := SplitPath(entry)
+			if parent != "" {
+				add(parent, "", list.t)
+			}
+			add(name, parent, list.t)
+		}
+	}
+	return seeds
+}
+
+// SplitPath splits "Transport > Taxi" into its parent and name; a name without a parent is returned as is.
+func SplitPath(path string) (parent, name string) {
+	parent, name, found := strings.Cut(path, PathSeparator)
+	if !found {
+		return "", strings.TrimSpace(path)
+	}
+	return strings.TrimSpace(parent), strings.TrimSpace(name)
+}
+
+// Names returns the names of the categories of the type in tree order, see Ordered.
+func Names(categories []Category, t transaction.Type) []string {
+	var names []string
+	for _, c := range Ordered(categories) {
+		if c.Type == t {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// Ordered returns the categories in tree order, each top-level category followed by its subcategories,
+// otherwise keeping their order. Subcategories whose parent is missing are listed as top-level categories.
+func Ordered(categories []Category) []Category {
+	byID := make(map[int64]bool, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = true
+	}
+	children := make(map[int64][]Category)
+	var roots []Category
+	for _, c := range categories {
+		if c.ParentID != 0 && byID[c.ParentID] {
+			children[c.ParentID] = append(children[c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+	ordered := make([]Category, 0, len(categories))
+	for _, root := range roots {
+		ordered = append(ordered, root)
+		ordered = append(ordered, children[root.ID]...)
+	}
+	return ordered
+}
+
+// Parents maps the name of every subcategory to the name of its parent.
+func Parents(categories []Category) map[string]string {
+	names := make(map[int64]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+	parents := make(map[string]string)
+	for _, c := range categories {
+		if parent, ok := names[c.ParentID]; ok && c.ParentID != 0 {
+			parents[c.Name] = parent
+		}
+	}
+	return parents
+}
+
+// Find returns the category with the name, ignoring case.
+func Find(categories []Category, name string) (Category, bool) {
+	name = strings.TrimSpace(name)
+	for _, c := range categories {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return Category{}, false
+}
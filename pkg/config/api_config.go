@@ -0,0 +1,12 @@
+package config
+
+// APIConfig defines the configs of the HTTP API.
+type APIConfig struct {
+	Tokens []APIToken `yaml:"tokens"`
+}
+
+// APIToken maps a bearer token to the Telegram user whose transactions it may access.
+type APIToken struct {
+	Token  string `yaml:"token"`
+	UserID int64  `yaml:"user_id"`
+}
@@ -0,0 +1,31 @@
+package config
+
+// Defaults for AttachmentsConfig.
+const (
+	DefaultAttachmentsDir    = "attachments"
+	DefaultAttachmentMaxSize = 10 << 20 // 10 MiB
+)
+
+// DefaultAttachmentTypes are the MIME types accepted when AllowedTypes is empty.
+var DefaultAttachmentTypes = []string{"image/jpeg", "image/png", "image/webp", "application/pdf"}
+
+// AttachmentsConfig defines where receipt attachments are stored and which files are accepted.
+type AttachmentsConfig struct {
+	Dir          string   `yaml:"dir"`            // Local directory of the blob store
+	MaxSizeBytes int64    `yaml:"max_size_bytes"` // Largest accepted file
+	AllowedTypes []string `yaml:"allowed_types"`  // MIME types, detected from the file content
+}
+
+// WithDefaults fills in the defaults for unset fields.
+func (c AttachmentsConfig) WithDefaults() AttachmentsConfig {
+	if c.Dir == "" {
+		c.Dir = DefaultAttachmentsDir
+	}
+	if c.MaxSizeBytes <= 0 {
+		c.MaxSizeBytes = DefaultAttachmentMaxSize
+	}
+	if len(c.AllowedTypes) == 0 {
+		c.AllowedTypes = DefaultAttachmentTypes
+	}
+	return c
+}
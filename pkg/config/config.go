@@ -5,9 +5,16 @@ type Config struct {
 	FeaturesConfig      FeaturesConfig    `yaml:"features"`
 	Database            DatabaseConfig    `yaml:"database"`
 	TelegramConfig      TelegramConfig    `yaml:"telegram"`
+	APIConfig           APIConfig         `yaml:"api"`
 	ExpenseCategories   []string          `yaml:"expense_categories"`
-	FrequentExpenses    []FrequentExpense `yaml:"frequent_expenses"`
+	IncomeCategories    []string          `yaml:"income_categories"` // Offered by /income, e.g. Salary
+	FrequentExpenses    []FrequentExpense `yaml:"frequent_expenses"` // Seed the frequent expenses of new users
+	Suggestions         SuggestionsConfig `yaml:"frequent_expense_suggestions"`
 	SupportedCurrencies []string          `yaml:"supported_currencies"`
+	Reporting           ReportingConfig   `yaml:"reporting"`
+	Attachments         AttachmentsConfig `yaml:"attachments"`
+	Duplicates          DuplicatesConfig  `yaml:"duplicates"`
+	Export              ExportConfig      `yaml:"export"`
 }
 
 /*func GetConfig() Config {
@@ -1,7 +1,15 @@
 package config
 
+// Supported values for DatabaseConfig.Driver.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 // DatabaseConfig define the configs needed to connect to the DB
 type DatabaseConfig struct {
+	Driver   string `yaml:"driver"` // "postgres" (default) or "sqlite"
+	Path     string `yaml:"path"`   // SQLite database file, only used by the sqlite driver
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
@@ -14,6 +22,9 @@ type FeaturesConfig struct {
 	/*EnableCache bool `yaml:"enableCache"`
 	MaxItems    int  `yaml:"maxItems"`*/
 	SaveToDB bool `yaml:"save_to_database"`
+
+	// ResponsesFile is the JSON lines file transactions are kept in when SaveToDB is false, responses.txt by default.
+	ResponsesFile string `yaml:"responses_file"`
 }
 
 /*type ServerConfig struct {
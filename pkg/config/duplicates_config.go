@@ -0,0 +1,8 @@
+package config
+
+// DuplicatesConfig defines when a new transaction is taken for a duplicate of one already saved:
+// same type, name (ignoring case), amount and currency, dated at most WindowDays days apart.
+type DuplicatesConfig struct {
+	Disabled   bool `yaml:"disabled"`    // Save every transaction without checking
+	WindowDays int  `yaml:"window_days"` // 0, the default, only compares transactions of the same day
+}
@@ -0,0 +1,24 @@
+package config
+
+// Defaults for ExportConfig.
+const DefaultExportDateFormat = "2006-01-02"
+
+// DefaultExportColumns are the columns of a CSV export when Columns is empty.
+var DefaultExportColumns = []string{"date", "type", "name", "category", "amount", "currency", "is_claimable", "paid_for_family", "tags"}
+
+// ExportConfig defines the CSV export of transactions.
+type ExportConfig struct {
+	Columns    []string `yaml:"columns"`     // In order, see the README for the available columns
+	DateFormat string   `yaml:"date_format"` // Go time layout of the date column, e.g. 02/01/2006
+}
+
+// WithDefaults fills in the defaults for unset fields.
+func (c ExportConfig) WithDefaults() ExportConfig {
+	if len(c.Columns) == 0 {
+		c.Columns = DefaultExportColumns
+	}
+	if c.DateFormat == "" {
+		c.DateFormat = DefaultExportDateFormat
+	}
+	return c
+}
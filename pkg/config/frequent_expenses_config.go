@@ -6,4 +6,34 @@ type FrequentExpense struct {
 	Currency      string `yaml:"currency"`
 	IsClaimable   bool   `yaml:"is_claimable"`
 	PaidForFamily bool   `yaml:"paid_for_family"`
+	Account       string `yaml:"account"` // Name of one of the user's accounts, skips the account question
+}
+
+// Defaults for SuggestionsConfig.
+const (
+	DefaultSuggestionMinCount     = 3
+	DefaultSuggestionLookbackDays = 90
+	DefaultSuggestionLimit        = 4
+)
+
+// SuggestionsConfig defines how frequent expenses are suggested from a user's history.
+type SuggestionsConfig struct {
+	Enabled      bool `yaml:"enabled"`       // Offer the suggestions when adding an expense in the bot
+	MinCount     int  `yaml:"min_count"`     // Expenses with the same name, category and currency needed
+	LookbackDays int  `yaml:"lookback_days"` // How far back the history is looked at
+	Limit        int  `yaml:"limit"`         // Most suggestions offered at once
+}
+
+// WithDefaults fills in the defaults for unset fields.
+func (c SuggestionsConfig) WithDefaults() SuggestionsConfig {
+	if c.MinCount <= 0 {
+		c.MinCount = DefaultSuggestionMinCount
+	}
+	if c.LookbackDays <= 0 {
+		c.LookbackDays = DefaultSuggestionLookbackDays
+	}
+	if c.Limit <= 0 {
+		c.Limit = DefaultSuggestionLimit
+	}
+	return c
 }
@@ -0,0 +1,9 @@
+package config
+
+// ReportingConfig defines how summaries combine amounts in different currencies.
+type ReportingConfig struct {
+	// BaseCurrency is the currency totals are converted into. Leave empty to only show per-currency totals.
+	BaseCurrency string `yaml:"base_currency"`
+	// ExchangeRates maps a currency to the value of one unit of it in BaseCurrency, as a decimal string.
+	ExchangeRates map[string]string `yaml:"exchange_rates"`
+}
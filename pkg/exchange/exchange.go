@@ -0,0 +1,107 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"main/pkg/money"
+	"math/big"
+	"strings"
+)
+
+// ErrNoRate is returned when there is no exchange rate for a currency.
+var ErrNoRate = errors.New("no exchange rate")
+
+// Converter converts amounts into the base currency used for reporting.
+type Converter interface {
+	// BaseCurrency returns the reporting currency, or "" when conversion is disabled.
+	BaseCurrency() string
+	// ToBase converts minor units of the currency into minor units of the base currency.
+	ToBase(amount int64, currency string) (int64, error)
+}
+
+// StaticRates is a Converter backed by a fixed table of rates, e.g. from config.ReportingConfig.
+type StaticRates struct {
+	base  string
+	rates map[string]*big.Rat // Value of one unit of the currency in the base currency
+}
+
+// NewStaticRates parses a table mapping currencies to the value of one unit in the base currency.
+// Rates are decimal strings such as "1.35" and are kept exact.
+func NewStaticRates(baseCurrency string, rates map[string]string) (*StaticRates, error) {
+	s := &StaticRates{base: normalize(baseCurrency), rates: make(map[string]*big.Rat)}
+	for currency, rateStr := range rates {
+		rate, err := ParseRate(rateStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange rate for %s: %w", currency, err)
+		}
+		s.rates[normalize(currency)] = rate
+	}
+	return s, nil
+}
+
+// BaseCurrency returns the reporting currency.
+func (s *StaticRates) BaseCurrency() string {
+	return s.base
+}
+
+// ToBase converts minor units of the currency into minor units of the base currency.
+func (s *StaticRates) ToBase(amount int64, currency string) (int64, error) {
+	currency = normalize(currency)
+	if s.base == "" {
+		return 0, fmt.Errorf("%w: no base currency configured", ErrNoRate)
+	}
+	if currency == s.base {
+		return amount, nil
+	}
+	rate, ok := s.rates[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w for %s to %s", ErrNoRate, currency, s.base)
+	}
+	return Convert(amount, currency, s.base, rate), nil
+}
+
+// ParseRate parses a positive decimal exchange rate exactly.
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil, fmt.Errorf("%q is not a decimal number", s)
+	}
+	if rate.Sign() <= 0 {
+		return nil, fmt.Errorf("rate %q must be positive", s)
+	}
+	return rate, nil
+}
+
+// Convert applies a rate (units of `to` per unit of `from`) to minor units of `from`,
+// rounding half away from zero to minor units of `to`.
+func Convert(amount int64, from, to string, rate *big.Rat) int64 {
+	// minorTo = minorFrom / 10^expFrom * rate * 10^expTo
+	value := new(big.Rat).SetInt64(amount)
+	value.Mul(value, rate)
+	value.Mul(value, pow10(money.Exponent(to)))
+	value.Quo(value, pow10(money.Exponent(from)))
+	return round(value)
+}
+
+// pow10 returns 10^n as a rational number.
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+// round rounds half away from zero.
+func round(r *big.Rat) int64 {
+	num := new(big.Int).Abs(r.Num())
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if new(big.Int).Mul(rem, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+	return quo.Int64()
+}
+
+// normalize upper-cases a currency code.
+func normalize(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
@@ -0,0 +1,114 @@
+package exchange
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ecbBaseCurrency is the base of every rate in the ECB reference rate files.
+const ecbBaseCurrency = "EUR"
+
+// ecbEnvelope mirrors the eurofxref XML published by the European Central Bank
+// (eurofxref-daily.xml, eurofxref-hist.xml):
+//
+//	<Cube><Cube time="2025-01-02"><Cube currency="USD" rate="1.0321"/>...</Cube></Cube>
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB reads an ECB eurofxref XML file. Every rate is quoted against EUR.
+func ParseECB(r io.Reader) ([]Rate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("invalid ECB rate file: %w", err)
+	}
+
+	var rates []Rate
+	for _, day := range envelope.Days {
+		if err := validateDate(day.Time); err != nil {
+			return nil, err
+		}
+		for _, cube := range day.Rates {
+			value, err := ParseRate(cube.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ECB rate for %s on %s: %w", cube.Currency, day.Time, err)
+			}
+			rates = append(rates, Rate{Date: day.Time, Base: ecbBaseCurrency, Quote: normalize(cube.Currency), Value: value})
+		}
+	}
+	if len(rates) == 0 {
+		return nil, errors.New("ECB rate file contains no rates")
+	}
+	return rates, nil
+}
+
+// ParseCSV reads rates from CSV rows of "date,base,quote,rate", where one unit of base is worth
+// rate units of quote on date (YYYY-MM-DD). A header row is skipped.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var rates []Rate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate CSV: %w", err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+
+		date := strings.TrimSpace(record[0])
+		if err := validateDate(date); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		value, err := ParseRate(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, Rate{Date: date, Base: normalize(record[1]), Quote: normalize(record[2]), Value: value})
+	}
+	if len(rates) == 0 {
+		return nil, errors.New("rate CSV contains no rates")
+	}
+	return rates, nil
+}
+
+// ParseFile reads either format, recognising ECB XML by its leading '<'.
+func ParseFile(r io.Reader) ([]Rate, error) {
+	buffered := bufio.NewReader(r)
+	head, err := buffered.Peek(64)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read rate file: %w", err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(head), []byte("<")) {
+		return ParseECB(buffered)
+	}
+	return ParseCSV(buffered)
+}
+
+// validateDate checks a YYYY-MM-DD date.
+func validateDate(date string) error {
+	if _, err := time.Parse(dateFormat, date); err != nil {
+		return fmt.Errorf("invalid rate date %q, expected YYYY-MM-DD", date)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package exchange
+
+import (
+	"fmt"
+	"main/pkg/transaction"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// dateFormat is the format of rate and transaction dates.
+const dateFormat = "2006-01-02"
+
+// Sources of a Quote.
+const (
+	SourceHistory = "history" // The exchange_rates table
+	SourceConfig  = "config"  // reporting.exchange_rates in config.yaml
+)
+
+// Rate says that on Date one unit of Base was worth Value units of Quote.
+type Rate struct {
+	Date  string
+	Base  string
+	Quote string
+	Value *big.Rat
+}
+
+// RateSource provides historical exchange rates, e.g. the exchange_rates table.
+type RateSource interface {
+	// GetExchangeRates returns, for every currency pair, the most recent rate on or before the date.
+	GetExchangeRates(date string) ([]Rate, error)
+}
+
+// Quote is the value of one unit of Currency in BaseCurrency that applies on a given day.
+type Quote struct {
+	Currency     string `json:"currency"`
+	BaseCurrency string `json:"baseCurrency"`
+	Rate         string `json:"rate"`           // Decimal, e.g. "1.3512"
+	Date         string `json:"date,omitempty"` // Day the rate was published, empty for configured rates
+	Source       string `json:"source"`         // SourceHistory or SourceConfig
+	value        *big.Rat
+}
+
+// Rates converts into the base currency with the historical rate in effect on a date,
+// falling back to the configured static rates when no history is available.
+type Rates struct {
+	source   RateSource
+	fallback *StaticRates
+}
+
+// NewRates creates a converter over historical rates with a static fallback.
+// The fallback also determines the base currency.
+func NewRates(source RateSource, fallback *StaticRates) *Rates {
+	return &Rates{source: source, fallback: fallback}
+}
+
+// BaseCurrency returns the reporting currency, or "" when conversion is disabled.
+func (r *Rates) BaseCurrency() string {
+	return r.fallback.BaseCurrency()
+}
+
+// ToBase converts minor units of the currency with today's rate.
+func (r *Rates) ToBase(amount int64, currency string) (int64, error) {
+	quote, err := r.Lookup(currency, time.Now().Format(dateFormat))
+	if err != nil {
+		return 0, err
+	}
+	return Convert(amount, currency, r.BaseCurrency(), quote.value), nil
+}
+
+// Lookup returns the value of one unit of the currency in the base currency on the date (YYYY-MM-DD).
+// The most recent published rate on or before the date is used; rates quoted the other way round
+// or against a common currency (such as the ECB's EUR rates) are inverted or crossed.
+func (r *Rates) Lookup(currency, date string) (Quote, error) {
+	currency = normalize(currency)
+	base := r.BaseCurrency()
+	if base == "" {
+		return Quote{}, fmt.Errorf("%w: no base currency configured", ErrNoRate)
+	}
+	if currency == base {
+		return newQuote(currency, base, big.NewRat(1, 1), date, SourceHistory), nil
+	}
+
+	history, err := r.source.GetExchangeRates(date)
+	if err != nil {
+		return Quote{}, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+	if value, rateDate, ok := resolve(history, currency, base); ok {
+		return newQuote(currency, base, value, rateDate, SourceHistory), nil
+	}
+
+	if value, ok := r.fallback.rates[currency]; ok {
+		return newQuote(currency, base, value, "", SourceConfig), nil
+	}
+	return Quote{}, fmt.Errorf("%w for %s to %s on %s", ErrNoRate, currency, base, date)
+}
+
+// Stamp records the rate in effect on the transaction's date and its amount in the base currency,
+// so later reports do not change when new rates are imported.
+// Without a base currency or a rate the transaction is left unstamped.
+func (r *Rates) Stamp(t *transaction.Transaction) error {
+	t.BaseCurrency, t.ExchangeRate, t.BaseAmount = "", "", 0
+
+	base := r.BaseCurrency()
+	if base == "" {
+		return nil
+	}
+	quote, err := r.Lookup(t.Currency, t.Date)
+	if err != nil {
+		return err
+	}
+	t.BaseCurrency = base
+	t.ExchangeRate = quote.Rate
+	t.BaseAmount = Convert(t.Amount, t.Currency, base, quote.value)
+	return nil
+}
+
+// resolve finds the value of one unit of `from` in `to` among the latest rates, directly,
+// inverted, or crossed through a currency both are quoted against. The returned date is the
+// oldest publication date involved.
+func resolve(rates []Rate, from, to string) (*big.Rat, string, bool) {
+	type pair struct{ base, quote string }
+	latest := make(map[pair]Rate, len(rates))
+	for _, rate := range rates {
+		latest[pair{normalize(rate.Base), normalize(rate.Quote)}] = rate
+	}
+
+	if rate, ok := latest[pair{from, to}]; ok {
+		return rate.Value, rate.Date, true
+	}
+	if rate, ok := latest[pair{to, from}]; ok {
+		return new(big.Rat).Inv(rate.Value), rate.Date, true
+	}
+
+	// Several currencies may quote both, so the pivot is chosen in a fixed order:
+	// the ECB's EUR first, then the newest rates, then the first currency code.
+	var value *big.Rat
+	var pivot, date string
+	for p, fromRate := range latest {
+		if p.quote != from {
+			continue
+		}
+		toRate, ok := latest[pair{p.base, to}]
+		if !ok {
+			continue
+		}
+		rateDate := minDate(fromRate.Date, toRate.Date)
+		if value != nil && !betterPivot(p.base, rateDate, pivot, date) {
+			continue
+		}
+		// 1 pivot = a from and 1 pivot = b to, so 1 from = b/a to.
+		value = new(big.Rat).Quo(toRate.Value, fromRate.Value)
+		pivot, date = p.base, rateDate
+	}
+	return value, date, value != nil
+}
+
+// betterPivot reports whether crossing through pivot with rates from date is preferred over
+// crossing through other with rates from otherDate.
+func betterPivot(pivot, date, other, otherDate string) bool {
+	if (pivot == ecbBaseCurrency) != (other == ecbBaseCurrency) {
+		return pivot == ecbBaseCurrency
+	}
+	if date != otherDate {
+		return date > otherDate
+	}
+	return pivot < other
+}
+
+// newQuote builds a quote, formatting the rate with up to 10 decimals.
+func newQuote(currency, base string, value *big.Rat, date, source string) Quote {
+	return Quote{
+		Currency:     currency,
+		BaseCurrency: base,
+		Rate:         FormatRate(value),
+		Date:         date,
+		Source:       source,
+		value:        value,
+	}
+}
+
+// FormatRate renders a rate as a decimal with up to 10 decimals and no trailing zeros.
+func FormatRate(value *big.Rat) string {
+	s := value.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// minDate returns the earlier of two YYYY-MM-DD dates.
+func minDate(a, b string) string {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,65 @@
+package exchange
+
+import (
+	"math/big"
+	"testing"
+)
+
+func rate(date, base, quote, value string) Rate {
+	v, _ := new(big.Rat).SetString(value)
+	return Rate{Date: date, Base: base, Quote: quote, Value: v}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		rates    []Rate
+		want     string
+		wantDate string
+	}{
+		{"direct", []Rate{rate("2026-01-02", "USD", "SGD", "1.35")}, "1.35", "2026-01-02"},
+		{"inverted", []Rate{rate("2026-01-02", "SGD", "USD", "0.8")}, "1.25", "2026-01-02"},
+		{"crossed", []Rate{
+			rate("2026-01-02", "EUR", "USD", "1.1"),
+			rate("2026-01-01", "EUR", "SGD", "1.43"),
+		}, "1.3", "2026-01-01"},
+		// Crossing through GBP gives 1.4 with newer rates, but EUR comes first.
+		{"EUR pivot first", []Rate{
+			rate("2026-01-03", "GBP", "USD", "1.25"),
+			rate("2026-01-03", "GBP", "SGD", "1.75"),
+			rate("2026-01-02", "EUR", "USD", "1.1"),
+			rate("2026-01-02", "EUR", "SGD", "1.43"),
+		}, "1.3", "2026-01-02"},
+		{"newest pivot", []Rate{
+			rate("2026-01-02", "CHF", "USD", "1.1"),
+			rate("2026-01-02", "CHF", "SGD", "1.43"),
+			rate("2026-01-03", "GBP", "USD", "1.25"),
+			rate("2026-01-03", "GBP", "SGD", "1.75"),
+		}, "1.4", "2026-01-03"},
+		{"first pivot by code", []Rate{
+			rate("2026-01-03", "GBP", "USD", "1.25"),
+			rate("2026-01-03", "GBP", "SGD", "1.75"),
+			rate("2026-01-03", "CHF", "USD", "1.1"),
+			rate("2026-01-03", "CHF", "SGD", "1.43"),
+		}, "1.3", "2026-01-03"},
+	}
+	for _, tt := range tests {
+		// Map iteration changes between runs, so a random choice would show up here.
+		for i := 0; i < 20; i++ {
+			value, date, ok := resolve(tt.rates, "USD", "SGD")
+			if !ok {
+				t.Fatalf("%s: no rate", tt.name)
+			}
+			if got := FormatRate(value); got != tt.want || date != tt.wantDate {
+				t.Fatalf("%s: resolve = %s on %s, want %s on %s", tt.name, got, date, tt.want, tt.wantDate)
+			}
+		}
+	}
+}
+
+func TestResolveWithoutPivot(t *testing.T) {
+	rates := []Rate{rate("2026-01-02", "EUR", "USD", "1.1"), rate("2026-01-02", "GBP", "SGD", "1.75")}
+	if value, _, ok := resolve(rates, "USD", "SGD"); ok {
+		t.Errorf("resolve = %s, want no rate", FormatRate(value))
+	}
+}
@@ -0,0 +1,159 @@
+// Package export writes transactions as CSV for spreadsheets.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"main/pkg/config"
+	"main/pkg/money"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageSize is how many transactions are read from the store at a time, so that an export of any size
+// only holds one page in memory.
+const pageSize = 500
+
+// DefaultSort lists the oldest transactions first, the way a spreadsheet is usually read.
+var DefaultSort = storage.Sort{Field: storage.SortByDate}
+
+// column renders one field of a transaction.
+type column func(e *Exporter, t transaction.Transaction) string
+
+// columns are the available columns by name.
+var columns = map[string]column{
+	"id":                func(_ *Exporter, t transaction.Transaction) string { return strconv.FormatInt(t.ID, 10) },
+	"date":              func(e *Exporter, t transaction.Transaction) string { return e.formatDate(t.Date) },
+	"created_at":        func(_ *Exporter, t transaction.Transaction) string { return t.CreatedAt.UTC().Format(time.RFC3339) },
+	"type":              func(_ *Exporter, t transaction.Transaction) string { return string(t.TransactionType()) },
+	"name":              func(_ *Exporter, t transaction.Transaction) string { return text(t.Name) },
+	"category":          func(_ *Exporter, t transaction.Transaction) string { return text(t.Category) },
+	"amount":            func(_ *Exporter, t transaction.Transaction) string { return t.FormattedAmount() },
+	"amount_minor":      func(_ *Exporter, t transaction.Transaction) string { return strconv.FormatInt(t.Amount, 10) },
+	"currency":          func(_ *Exporter, t transaction.Transaction) string { return t.Currency },
+	"base_amount":       baseAmount,
+	"base_currency":     func(_ *Exporter, t transaction.Transaction) string { return t.BaseCurrency },
+	"exchange_rate":     func(_ *Exporter, t transaction.Transaction) string { return t.ExchangeRate },
+	"account_id":        func(_ *Exporter, t transaction.Transaction) string { return optionalID(t.AccountID) },
+	"to_account_id":     func(_ *Exporter, t transaction.Transaction) string { return optionalID(t.ToAccountID) },
+	"is_claimable":      func(_ *Exporter, t transaction.Transaction) string { return strconv.FormatBool(t.IsClaimable) },
+	"claim_status":      func(_ *Exporter, t transaction.Transaction) string { return string(t.CurrentClaimStatus()) },
+	"claim_reference":   func(_ *Exporter, t transaction.Transaction) string { return text(t.ClaimReference) },
+	"paid_for_family":   func(_ *Exporter, t transaction.Transaction) string { return strconv.FormatBool(t.PaidForFamily) },
+	"tags":              func(_ *Exporter, t transaction.Transaction) string { return text(strings.Join(t.Tags, " ")) },
+	"recurring_rule_id": func(_ *Exporter, t transaction.Transaction) string { return optionalID(t.RecurringRuleID) },
+}
+
+// Exporter writes transactions with the configured columns and date format.
+type Exporter struct {
+	names      []string
+	columns    []column
+	dateFormat string
+}
+
+// New creates an exporter for the configuration, or returns an error for an unknown column or a date format
+// without any date element.
+func New(cfg config.ExportConfig) (*Exporter, error) {
+	cfg = cfg.WithDefaults()
+	e := &Exporter{dateFormat: cfg.DateFormat}
+	for _, name := range cfg.Columns {
+		name = strings.ToLower(strings.TrimSpace(name))
+		c, ok := columns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown export column %q", name)
+		}
+		e.names = append(e.names, name)
+		e.columns = append(e.columns, c)
+	}
+	// A layout without date elements formats every date as itself.
+	probe := time.Date(1999, time.November, 30, 0, 0, 0, 0, time.UTC)
+	if probe.Format(cfg.DateFormat) == cfg.DateFormat {
+		return nil, fmt.Errorf("invalid export date format %q, use a Go time layout such as 02/01/2006", cfg.DateFormat)
+	}
+	return e, nil
+}
+
+// Write writes a header row and the user's transactions matching the filter in the sort order, one page at
+// a time, and returns how many it wrote. The first page is read before anything is written, so that a store
+// error can still be reported to the reader. w is flushed after every page if it has a Flush method.
+func (e *Exporter) Write(w io.Writer, store storage.TransactionStore, userID int64, filter storage.TransactionFilter, sort storage.Sort) (int, error) {
+	req := storage.PageRequest{Sort: sort, Limit: pageSize}
+	page, err := store.GetAllTransactions(userID, filter, req)
+	if err != nil {
+		return 0, err
+	}
+
+	out := csv.NewWriter(w)
+	if err = out.Write(e.names); err != nil {
+		return 0, err
+	}
+	written := 0
+	row := make([]string, len(e.columns))
+	for {
+		for _, t := range page.Transactions {
+			for i, c := range e.columns {
+				row[i] = c(e, t)
+			}
+			if err = out.Write(row); err != nil {
+				return written, err
+			}
+			written++
+		}
+		out.Flush()
+		if err = out.Error(); err != nil {
+			return written, err
+		}
+		if f, ok := w.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+
+		if page.NextCursor == "" {
+			return written, nil
+		}
+		req.Cursor = page.NextCursor
+		if page, err = store.GetAllTransactions(userID, filter, req); err != nil {
+			return written, err
+		}
+	}
+}
+
+// formatDate renders a YYYY-MM-DD date, possibly followed by a time as some drivers return it,
+// in the configured format.
+func (e *Exporter) formatDate(date string) string {
+	if len(date) < len("2006-01-02") {
+		return date
+	}
+	parsed, err := time.Parse("2006-01-02", date[:len("2006-01-02")])
+	if err != nil {
+		return date
+	}
+	return parsed.Format(e.dateFormat)
+}
+
+// baseAmount renders the amount converted into the base currency, empty when it was not converted.
+func baseAmount(_ *Exporter, t transaction.Transaction) string {
+	if t.BaseCurrency == "" {
+		return ""
+	}
+	return money.Format(t.BaseAmount, t.BaseCurrency)
+}
+
+// optionalID renders an ID, empty for 0.
+func optionalID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// text keeps text typed by users from being taken for a formula by spreadsheets.
+func text(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,205 @@
+package frequent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/pkg/config"
+	"main/pkg/money"
+	"main/pkg/transaction"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxNameLength matches the frequent_expenses.name column and keeps the name within Telegram's
+// 64 bytes of callback data.
+const maxNameLength = 50
+
+// Expense is a frequent expense. Picking it when adding an expense fills in everything but the amount and date.
+type Expense struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"-"` // Telegram chat ID of the owner
+	Name          string    `json:"name"`
+	Category      string    `json:"category"`
+	Currency      string    `json:"currency"`
+	IsClaimable   bool      `json:"isClaimable"`
+	PaidForFamily bool      `json:"paidForFamily"`
+	AccountID     int64     `json:"accountId,omitempty"` // One of the owner's accounts, 0 for none
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Validate checks that the frequent expense can be stored and normalises its name, category and currency.
+func (e *Expense) Validate() error {
+	e.Name = strings.TrimSpace(e.Name)
+	if e.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(e.Name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	e.Category = strings.TrimSpace(e.Category)
+	e.Currency = strings.ToUpper(strings.TrimSpace(e.Currency))
+	if e.Currency != "" && len(e.Currency) != 3 {
+		return errors.New("currency must be a 3-letter ISO 4217 code")
+	}
+	if e.AccountID < 0 {
+		return errors.New("accountId must be a positive integer")
+	}
+	return nil
+}
+
+// FromTransaction returns a frequent expense that fills in the transaction's details.
+func FromTransaction(t transaction.Transaction) Expense {
+	return Expense{
+		UserID:        t.UserID,
+		Name:          t.Name,
+		Category:      t.Category,
+		Currency:      t.Currency,
+		IsClaimable:   t.IsClaimable,
+		PaidForFamily: t.PaidForFamily,
+		AccountID:     t.AccountID,
+	}
+}
+
+// FromConfig returns the configured frequent expenses. Their accounts are named in the configuration,
+// so they are only linked when seeded into a user's store.
+func FromConfig(configured []config.FrequentExpense) []Expense {
+	expenses := make([]Expense, 0, len(configured))
+	for _, c := range configured {
+		expenses = append(expenses, Expense{
+			Name:          c.Name,
+			Category:      c.Category,
+			Currency:      c.Currency,
+			IsClaimable:   c.IsClaimable,
+			PaidForFamily: c.PaidForFamily,
+		})
+	}
+	return expenses
+}
+
+// Find returns the frequent expense with the name, ignoring case and surrounding spaces.
+func Find(expenses []Expense, name string) (Expense, bool) {
+	for _, e := range expenses {
+		if strings.EqualFold(e.Name, strings.TrimSpace(name)) {
+			return e, true
+		}
+	}
+	return Expense{}, false
+}
+
+// Occurrence is one expense of a user's history, as far as suggestions are concerned.
+type Occurrence struct {
+	Name     string
+	Category string
+	Currency string
+	Amount   int64 // In minor units of Currency
+}
+
+// Suggestion is a name, category and currency combination the user spends on often.
+type Suggestion struct {
+	Name          string `json:"name"`
+	Category      string `json:"category"`
+	Currency      string `json:"currency"`
+	Count         int    `json:"count"` // Number of expenses with the combination
+	TypicalAmount int64  `json:"-"`     // Median amount in minor units of Currency, see suggestionJSON
+}
+
+// Expense returns the frequent expense the suggestion stands for.
+func (s Suggestion) Expense() Expense {
+	return Expense{Name: s.Name, Category: s.Category, Currency: s.Currency}
+}
+
+// FormattedTypicalAmount renders the typical amount as a decimal in the suggestion's currency, e.g. "4.50".
+func (s Suggestion) FormattedTypicalAmount() string {
+	return money.Format(s.TypicalAmount, s.Currency)
+}
+
+// suggestionAlias has the fields of Suggestion without its JSON methods.
+type suggestionAlias Suggestion
+
+// suggestionJSON is the wire format of a Suggestion, with the typical amount written like a transaction's amount.
+type suggestionJSON struct {
+	suggestionAlias
+	TypicalAmount      json.Number `json:"typicalAmount"`
+	TypicalAmountMinor int64       `json:"typicalAmountMinor"`
+}
+
+// MarshalJSON writes the typical amount losslessly as both a decimal and minor units.
+func (s Suggestion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(suggestionJSON{
+		suggestionAlias:    suggestionAlias(s),
+		TypicalAmount:      json.Number(s.FormattedTypicalAmount()),
+		TypicalAmountMinor: s.TypicalAmount,
+	})
+}
+
+// Suggest returns up to limit combinations of name, category and currency that occur at least minCount times
+// in the history, most frequent first. Names are compared ignoring case, and names that are already saved
+// as frequent expenses are left out. The typical amount is the median amount of the combination.
+func Suggest(history []Occurrence, saved []Expense, minCount, limit int) []Suggestion {
+	type key struct{ name, category, currency string }
+	amounts := make(map[key][]int64)
+	names := make(map[key]string)
+	for _, o := range history {
+		name := strings.TrimSpace(o.Name)
+		if name == "" || len(name) > maxNameLength {
+			continue
+		}
+		if _, ok := Find(saved, name); ok {
+			continue
+		}
+		k := key{name: strings.ToLower(name), category: o.Category, currency: o.Currency}
+		amounts[k] = append(amounts[k], o.Amount)
+		if _, ok := names[k]; !ok {
+			names[k] = name // The first spelling in the history is kept
+		}
+	}
+
+	suggestions := []Suggestion{}
+	for k, values := range amounts {
+		if len(values) < minCount {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			Name:          names[k],
+			Category:      k.category,
+			Currency:      k.currency,
+			Count:         len(values),
+			TypicalAmount: median(values),
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		if suggestions[i].Name != suggestions[j].Name {
+			return suggestions[i].Name < suggestions[j].Name
+		}
+		if suggestions[i].Category != suggestions[j].Category {
+			return suggestions[i].Category < suggestions[j].Category
+		}
+		return suggestions[i].Currency < suggestions[j].Currency
+	})
+
+	// A name is suggested once, with its most frequent category and currency.
+	seen := make(map[string]bool)
+	unique := suggestions[:0]
+	for _, s := range suggestions {
+		if name := strings.ToLower(s.Name); !seen[name] {
+			seen[name] = true
+			unique = append(unique, s)
+		}
+	}
+	if limit > 0 && len(unique) > limit {
+		unique = unique[:limit]
+	}
+	return unique
+}
+
+// median returns the middle value, or the lower of the two middle values of an even number of values.
+func median(values []int64) int64 {
+	sorted := append([]int64{}, values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)-1)/2]
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/patrickmn/go-cache"
+	"log"
+	"main/pkg/account"
+	"main/pkg/report"
+	"main/pkg/storage"
+	"net/http"
+	"strconv"
+)
+
+// NewAccountsHandler creates an HTTP handler for /api/v1/accounts:
+// GET lists the caller's accounts with their current balances, POST creates an account.
+// It must be wrapped by Authenticate.
+func NewAccountsHandler(store storage.AccountStore, reports *report.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			cacheKey := fmt.Sprintf("%d:%s", userID, r.URL.String())
+			if cachedResponse, found := c.Get(cacheKey); found {
+				writeJSON(w, http.StatusOK, cachedResponse)
+				log.Printf("Served %s %s from cache", r.Method, r.URL.Path)
+				return
+			}
+			balances, err := reports.AccountBalances(userID)
+			if err != nil {
+				log.Printf("Error building account balances: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			c.Set(cacheKey, balances, cache.DefaultExpiration)
+			writeJSON(w, http.StatusOK, balances)
+		case http.MethodPost:
+			a, ok := decodeAccount(w, r)
+			if !ok {
+				return
+			}
+			a.UserID = userID
+			id, err := store.InsertAccount(a)
+			if err != nil {
+				writeAccountStoreError(w, 0, err)
+				return
+			}
+			invalidateTransactionsCache("new account")
+			if a, err = store.GetAccount(userID, id); err != nil {
+				writeAccountStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, a)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// NewAccountHandler creates an HTTP handler for /api/v1/accounts/{id}:
+// GET returns the account with its current balance, PUT replaces it and DELETE removes it,
+// keeping its transactions. It must be wrapped by Authenticate.
+func NewAccountHandler(store storage.AccountStore, reports *report.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id, ok := accountIDFromPath(w, r)
+		if !ok {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			balance, err := reports.AccountBalance(userID, id)
+			if err != nil {
+				writeAccountStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, balance)
+		case http.MethodPut:
+			a, ok := decodeAccount(w, r)
+			if !ok {
+				return
+			}
+			// The identity of the account comes from the URL and the caller, never from the body.
+			a.ID, a.UserID = id, userID
+			if err := store.UpdateAccount(a); err != nil {
+				writeAccountStoreError(w, id, err)
+				return
+			}
+			invalidateTransactionsCache("account change")
+			a, err := store.GetAccount(userID, id)
+			if err != nil {
+				writeAccountStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, a)
+		case http.MethodDelete:
+			if err := store.DeleteAccount(userID, id); err != nil {
+				writeAccountStoreError(w, id, err)
+				return
+			}
+			invalidateTransactionsCache("account deletion")
+			writeJSON(w, http.StatusOK, MessageResponse{Message: "Account deleted successfully"})
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// NewAccountBalancesHandler creates an HTTP handler for GET /api/v1/accounts/{id}/balances, which returns
+// the running balance of the account on each day money moved in or out of it. The optional `from` and `to`
+// parameters (YYYY-MM-DD, inclusive) limit the days. It must be wrapped by Authenticate.
+func NewAccountBalancesHandler(reports *report.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id, ok := accountIDFromPath(w, r)
+		if !ok {
+			return
+		}
+
+		cacheKey := fmt.Sprintf("%d:%s", userID, r.URL.String())
+		if cachedResponse, found := c.Get(cacheKey); found {
+			writeJSON(w, http.StatusOK, cachedResponse)
+			log.Printf("Served %s %s from cache", r.Method, r.URL.Path)
+			return
+		}
+
+		var dateRange storage.DateRange
+		var err error
+		queryParams := r.URL.Query()
+		if dateRange.From, err = dateParam(queryParams, "from"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if dateRange.To, err = dateParam(queryParams, "to"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if dateRange.From != "" && dateRange.To != "" && dateRange.To < dateRange.From {
+			http.Error(w, "Invalid date range: 'to' must not be before 'from'.", http.StatusBadRequest)
+			return
+		}
+
+		history, err := reports.BalanceHistory(userID, id, dateRange)
+		if err != nil {
+			writeAccountStoreError(w, id, err)
+			return
+		}
+		c.Set(cacheKey, history, cache.DefaultExpiration)
+		writeJSON(w, http.StatusOK, history)
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// accountIDFromPath reads the account ID from the URL, writing a 400 response if it is invalid.
+func accountIDFromPath(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		http.Error(w, "Invalid account id. Must be a positive integer.", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeAccount reads and validates an account from the request body, writing a 400 response if it is invalid.
+func decodeAccount(w http.ResponseWriter, r *http.Request) (account.Account, bool) {
+	var a account.Account
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return a, false
+	}
+	if err := a.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid account: %v.", err), http.StatusBadRequest)
+		return a, false
+	}
+	return a, true
+}
+
+// writeAccountStoreError maps a store error to a 404, 409 or 500 response.
+func writeAccountStoreError(w http.ResponseWriter, id int64, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Account not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrAccountExists):
+		http.Error(w, "An account with this name already exists.", http.StatusConflict)
+	default:
+		log.Printf("Error accessing account %d: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"main/pkg/attachment"
+	"main/pkg/blob"
+	"main/pkg/storage"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// NewAttachmentsHandler creates an HTTP handler for /api/v1/transactions/{id}/attachments:
+// GET lists the transaction's attachments, POST uploads one as the "file" field of a multipart form.
+// It must be wrapped by Authenticate.
+func NewAttachmentsHandler(attachments *attachment.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		transactionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || transactionID < 1 {
+			http.Error(w, "Invalid transaction id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			list, err := attachments.List(userID, transactionID)
+			if err != nil {
+				writeStoreError(w, transactionID, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+		case http.MethodPost:
+			uploadAttachmentHandler(attachments, userID, transactionID, w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// uploadAttachmentHandler stores the uploaded file and links it to the transaction.
+func uploadAttachmentHandler(attachments *attachment.Service, userID, transactionID int64, w http.ResponseWriter, r *http.Request) {
+	// Leave room for the multipart headers around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, attachments.MaxSize()+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Attachment is too large, the limit is %d bytes.", attachments.MaxSize()), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid request body: expected a multipart form with a 'file' field.", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing uploaded file: %v", err)
+		}
+	}()
+
+	a, err := attachments.Save(userID, transactionID, header.Filename, file)
+	switch {
+	case errors.Is(err, attachment.ErrTooLarge):
+		http.Error(w, fmt.Sprintf("Attachment is too large, the limit is %d bytes.", attachments.MaxSize()), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, attachment.ErrUnsupportedType):
+		http.Error(w, fmt.Sprintf("Unsupported attachment: %v.", err), http.StatusUnsupportedMediaType)
+	case err != nil:
+		writeStoreError(w, transactionID, err)
+	default:
+		writeJSON(w, http.StatusCreated, a)
+	}
+}
+
+// NewAttachmentHandler creates an HTTP handler for /api/v1/transactions/{id}/attachments/{attachmentId}
+// that downloads the file. It must be wrapped by Authenticate.
+func NewAttachmentHandler(attachments *attachment.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		transactionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || transactionID < 1 {
+			http.Error(w, "Invalid transaction id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.ParseInt(r.PathValue("attachmentId"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid attachment id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+
+		a, content, err := attachments.Open(userID, transactionID, id)
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, blob.ErrNotFound) {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error opening attachment %d: %v", id, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer func() {
+			if err := content.Close(); err != nil {
+				log.Printf("Error closing attachment %d: %v", id, err)
+			}
+		}()
+
+		w.Header().Set("Content-Type", a.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if _, err = io.Copy(w, content); err != nil {
+			log.Printf("Error sending attachment %d: %v", id, err)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
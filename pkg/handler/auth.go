@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"main/pkg/config"
+	"net/http"
+	"strings"
+)
+
+type contextKey string
+
+const userIDContextKey contextKey = "userID"
+
+// Authenticate only lets requests with a known "Authorization: Bearer <token>" header through,
+// and records the user the token belongs to in the request context.
+func Authenticate(tokens []config.APIToken, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || bearer == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		for _, token := range tokens {
+			if subtle.ConstantTimeCompare([]byte(bearer), []byte(token.Token)) == 1 {
+				ctx := context.WithValue(r.Context(), userIDContextKey, token.UserID)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+		}
+
+		log.Printf("Rejected %s %s from %s: unknown API token", r.Method, r.URL.Path, r.RemoteAddr)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// userIDFromRequest returns the authenticated caller set by Authenticate.
+func userIDFromRequest(r *http.Request) (int64, bool) {
+	userID, ok := r.Context().Value(userIDContextKey).(int64)
+	return userID, ok
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/budget"
+	"main/pkg/storage"
+	"net/http"
+	"strconv"
+)
+
+// NewBudgetsHandler creates an HTTP handler for /api/v1/budgets:
+// GET lists the caller's monthly budgets, POST sets the budget of a category and currency,
+// replacing the amount of an existing one. It must be wrapped by Authenticate.
+func NewBudgetsHandler(store storage.BudgetStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			budgets, err := store.GetBudgets(userID)
+			if err != nil {
+				log.Printf("Error fetching budgets: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, budgets)
+		case http.MethodPost:
+			b, ok := decodeBudget(w, r)
+			if !ok {
+				return
+			}
+			b.UserID = userID
+			id, err := store.SetBudget(b)
+			if err != nil {
+				log.Printf("Error saving budget: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			// Summaries compare spending with the budgets.
+			invalidateTransactionsCache("budget change")
+			if b, err = store.GetBudget(userID, id); err != nil {
+				writeBudgetStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, b)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// NewBudgetHandler creates an HTTP handler for /api/v1/budgets/{id}:
+// GET returns the budget, PUT replaces it and DELETE removes it. It must be wrapped by Authenticate.
+func NewBudgetHandler(store storage.BudgetStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid budget id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			b, err := store.GetBudget(userID, id)
+			if err != nil {
+				writeBudgetStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, b)
+		case http.MethodPut:
+			b, ok := decodeBudget(w, r)
+			if !ok {
+				return
+			}
+			// The identity of the budget comes from the URL and the caller, never from the body.
+			b.ID, b.UserID = id, userID
+			if err := store.UpdateBudget(b); err != nil {
+				writeBudgetStoreError(w, id, err)
+				return
+			}
+			invalidateTransactionsCache("budget change")
+			if b, err = store.GetBudget(userID, id); err != nil {
+				writeBudgetStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, b)
+		case http.MethodDelete:
+			if err := store.DeleteBudget(userID, id); err != nil {
+				writeBudgetStoreError(w, id, err)
+				return
+			}
+			invalidateTransactionsCache("budget deletion")
+			writeJSON(w, http.StatusOK, MessageResponse{Message: "Budget deleted successfully"})
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// decodeBudget reads and validates a budget from the request body, writing a 400 response if it is invalid.
+func decodeBudget(w http.ResponseWriter, r *http.Request) (budget.Budget, bool) {
+	var b budget.Budget
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return b, false
+	}
+	if err := b.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid budget: %v.", err), http.StatusBadRequest)
+		return b, false
+	}
+	return b, true
+}
+
+// writeBudgetStoreError maps a store error to a 404, 409 or 500 response.
+func writeBudgetStoreError(w http.ResponseWriter, id int64, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Budget not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrBudgetExists):
+		http.Error(w, "A budget for this category and currency already exists.", http.StatusConflict)
+	default:
+		log.Printf("Error accessing budget %d: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/category"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"net/http"
+	"strconv"
+)
+
+// NewCategoriesHandler creates an HTTP handler for /api/v1/categories:
+// GET lists the caller's categories, each top-level category followed by its subcategories,
+// optionally only those of a `type` (expense or income); POST creates a category.
+// Users without categories get the seed categories first. It must be wrapped by Authenticate.
+func NewCategoriesHandler(store storage.CategoryStore, seeds []category.Seed) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Seeding first keeps a user's first own category from replacing the seed.
+		if err := store.SeedCategories(userID, seeds); err != nil {
+			log.Printf("Error seeding categories: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			var txType transaction.Type
+			if value := r.URL.Query().Get("type"); value != "" {
+				t, err := transaction.ParseType(value)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("Invalid value for 'type' parameter: %v.", err), http.StatusBadRequest)
+					return
+				}
+				txType = t
+			}
+			categories, err := store.GetCategories(userID)
+			if err != nil {
+				log.Printf("Error fetching categories: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			ordered := []category.Category{}
+			for _, c := range category.Ordered(categories) {
+				if txType == "" || c.Type == txType {
+					ordered = append(ordered, c)
+				}
+			}
+			writeJSON(w, http.StatusOK, ordered)
+		case http.MethodPost:
+			c, ok := decodeCategory(w, r, 0)
+			if !ok {
+				return
+			}
+			c.UserID = userID
+			id, err := store.InsertCategory(c)
+			if err != nil {
+				writeCategoryStoreError(w, 0, err)
+				return
+			}
+			// Summaries roll subcategories up into their parents.
+			invalidateTransactionsCache("new category")
+			if c, err = store.GetCategory(userID, id); err != nil {
+				writeCategoryStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, c)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// NewCategoryHandler creates an HTTP handler for /api/v1/categories/{id}:
+// GET returns the category, PUT replaces it, renaming the category of the caller's transactions, budgets and
+// recurring rules along with it, and DELETE removes it, keeping its transactions. It must be wrapped by Authenticate.
+func NewCategoryHandler(store storage.CategoryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid category id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			c, err := store.GetCategory(userID, id)
+			if err != nil {
+				writeCategoryStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, c)
+		case http.MethodPut:
+			// The identity of the category comes from the URL and the caller, never from the body.
+			c, ok := decodeCategory(w, r, id)
+			if !ok {
+				return
+			}
+			c.UserID = userID
+			if err := store.UpdateCategory(c); err != nil {
+				writeCategoryStoreError(w, id, err)
+				return
+			}
+			invalidateTransactionsCache("category change")
+			if c, err = store.GetCategory(userID, id); err != nil {
+				writeCategoryStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, c)
+		case http.MethodDelete:
+			if err := store.DeleteCategory(userID, id); err != nil {
+				writeCategoryStoreError(w, id, err)
+				return
+			}
+			invalidateTransactionsCache("category deletion")
+			writeJSON(w, http.StatusOK, MessageResponse{Message: "Category deleted successfully"})
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// decodeCategory reads and validates the category with the ID (0 for a new one) from the request body,
+// writing a 400 response if it is invalid.
+func decodeCategory(w http.ResponseWriter, r *http.Request, id int64) (category.Category, bool) {
+	var c category.Category
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return c, false
+	}
+	c.ID = id // A category cannot be its own parent, so the ID is known before validating
+	if err := c.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid category: %v.", err), http.StatusBadRequest)
+		return c, false
+	}
+	return c, true
+}
+
+// writeCategoryStoreError maps a store error to a 400, 404, 409 or 500 response.
+func writeCategoryStoreError(w http.ResponseWriter, id int64, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Category not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrCategoryExists):
+		http.Error(w, "A category with this name already exists.", http.StatusConflict)
+	case errors.Is(err, storage.ErrInvalidParent):
+		http.Error(w, fmt.Sprintf("Invalid category: %v.", err), http.StatusBadRequest)
+	default:
+		log.Printf("Error accessing category %d: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/patrickmn/go-cache"
+	"log"
+	"main/pkg/report"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ClaimRequest moves the claim of a transaction to a new status.
+type ClaimRequest struct {
+	Status    string `json:"status"`              // submitted, reimbursed or rejected
+	Reference string `json:"reference,omitempty"` // Replaces the current reference when set
+}
+
+// NewClaimHandler creates an HTTP handler for POST /api/v1/transactions/{id}/claim, which moves
+// the claim of a transaction along its lifecycle and returns the updated transaction.
+// Changes the lifecycle does not allow are answered with 409 Conflict. It must be wrapped by Authenticate.
+func NewClaimHandler(store storage.TransactionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid transaction id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+
+		var req ClaimRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		status, err := transaction.ParseClaimStatus(req.Status)
+		if err == nil {
+			err = transaction.ValidateClaimReference(req.Reference)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid claim: %v.", err), http.StatusBadRequest)
+			return
+		}
+
+		t, err := store.UpdateClaim(userID, id, status, req.Reference, time.Now())
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			http.Error(w, "Transaction not found", http.StatusNotFound)
+			return
+		case errors.Is(err, transaction.ErrClaimTransition):
+			http.Error(w, fmt.Sprintf("Cannot update claim: %v.", err), http.StatusConflict)
+			return
+		case err != nil:
+			log.Printf("Error updating claim of transaction %d: %v", id, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		invalidateTransactionsCache("claim update")
+		writeJSON(w, http.StatusOK, t)
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// NewClaimsHandler creates an HTTP handler for GET /api/v1/claims, which sums the caller's claims
+// per status: outstanding (claimable and submitted) versus reimbursed and rejected. The optional
+// `period` parameter takes the same periods as the summary and defaults to all time.
+// It must be wrapped by Authenticate.
+func NewClaimsHandler(reports *report.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		cacheKey := fmt.Sprintf("%d:%s", userID, r.URL.String())
+		if cachedResponse, found := c.Get(cacheKey); found {
+			writeJSON(w, http.StatusOK, cachedResponse)
+			log.Printf("Served %s %s from cache", r.Method, r.URL.Path)
+			return
+		}
+
+		periodParam := r.URL.Query().Get("period")
+		if periodParam == "" {
+			periodParam = "all"
+		}
+		period, err := report.ParsePeriod(periodParam, time.Now())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid value for 'period' parameter: %v. %s", err, report.PeriodUsage), http.StatusBadRequest)
+			return
+		}
+
+		totals, err := reports.Claims(userID, period)
+		if err != nil {
+			log.Printf("Error building claim totals: %v", err)
+			http.Error(w, "Internal Server Error while building the claim totals.", http.StatusInternalServerError)
+			return
+		}
+
+		c.Set(cacheKey, totals, cache.DefaultExpiration)
+		writeJSON(w, http.StatusOK, totals)
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
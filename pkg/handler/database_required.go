@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// RequireDatabase refuses every request for a feature whose data is only kept in memory when transactions
+// are saved to a file (features.save_to_database: false), rather than accept changes lost on restart.
+// feature names it in the plural, e.g. "Budgets".
+func RequireDatabase(saveToDB bool, feature string, next http.Handler) http.Handler {
+	if saveToDB {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refuseWithoutDatabase(w, r, fmt.Sprintf("%s are only available when transactions are saved to the database.", feature))
+	})
+}
+
+// RequireDatabaseForChanges is RequireDatabase for features that can still be listed without a database,
+// from their configured defaults: GET requests go through and the others are refused.
+func RequireDatabaseForChanges(saveToDB bool, feature string, next http.Handler) http.Handler {
+	if saveToDB {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		refuseWithoutDatabase(w, r, fmt.Sprintf("%s can only be changed when transactions are saved to the database.", feature))
+	})
+}
+
+// refuseWithoutDatabase answers 501 Not Implemented with the message.
+func refuseWithoutDatabase(w http.ResponseWriter, r *http.Request, message string) {
+	log.Printf("Refused %s %s from %s: no database", r.Method, r.URL.Path, r.RemoteAddr)
+	http.Error(w, message+" Set features.save_to_database to true.", http.StatusNotImplemented)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/exchange"
+	"main/pkg/transaction"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewExchangeRateHandler creates an HTTP handler that returns the rate used to convert a currency
+// into the base currency on a date: GET /api/v1/exchange-rates?currency=USD&date=2025-01-31.
+// The date defaults to today. It must be wrapped by Authenticate.
+func NewExchangeRateHandler(rates *exchange.Rates) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("currency")))
+		if currency == "" {
+			http.Error(w, "Missing 'currency' parameter.", http.StatusBadRequest)
+			return
+		}
+		date := time.Now().Format("2006-01-02")
+		if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+			parsed, err := transaction.ParseDate(dateStr)
+			if err != nil {
+				http.Error(w, "Invalid value for 'date' parameter. Use YYYY-MM-DD.", http.StatusBadRequest)
+				return
+			}
+			date = parsed.Format("2006-01-02")
+		}
+
+		quote, err := rates.Lookup(currency, date)
+		if errors.Is(err, exchange.ErrNoRate) {
+			http.Error(w, fmt.Sprintf("No exchange rate for %s on %s.", currency, date), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error looking up exchange rate: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, quote)
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// stampExchangeRate records the exchange rate on a transaction before it is saved.
+// A missing rate never blocks saving, the transaction is then converted at report time.
+func stampExchangeRate(rates *exchange.Rates, t *transaction.Transaction) {
+	if err := rates.Stamp(t); err != nil {
+		log.Printf("Saving transaction without exchange rate: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"log"
+	"main/pkg/export"
+	"main/pkg/storage"
+	"net/http"
+)
+
+// NewExportHandler creates an HTTP handler for /api/v1/transactions/export.csv that streams the caller's
+// transactions as CSV. It accepts the filters of the list endpoint and its `sort`, oldest first by default.
+// It must be wrapped by Authenticate.
+func NewExportHandler(store storage.TransactionStore, exporter *export.Exporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filter, err := transactionFilterFromQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sort := export.DefaultSort
+		if param := r.URL.Query().Get("sort"); param != "" {
+			if sort, err = storage.ParseSort(param); err != nil {
+				http.Error(w, "Invalid value for 'sort' parameter. Use date, amount, name or category, optionally followed by ':asc' or ':desc'.", http.StatusBadRequest)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="transactions.csv"`)
+		written, err := exporter.Write(w, store, userID, filter, sort)
+		if err != nil {
+			log.Printf("Error exporting transactions after %d rows: %v", written, err)
+			if written == 0 {
+				// Reading the first page failed before anything was sent, otherwise the CSV is cut short.
+				w.Header().Del("Content-Disposition")
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+		log.Printf("Served %s %s with %d transactions from %s", r.Method, r.URL.Path, written, r.RemoteAddr)
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/config"
+	"main/pkg/frequent"
+	"main/pkg/report"
+	"main/pkg/storage"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// NewFrequentExpensesHandler creates an HTTP handler for /api/v1/prefilled-expenses:
+// GET lists the caller's frequent expenses, POST saves a new one.
+// Users without frequent expenses get the configured ones first. It must be wrapped by Authenticate.
+func NewFrequentExpensesHandler(store storage.FrequentExpenseStore, seeds []config.FrequentExpense) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Seeding first keeps a user's first own frequent expense from replacing the configured ones.
+		if err := store.SeedFrequentExpenses(userID, seeds); err != nil {
+			log.Printf("Error seeding frequent expenses: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			expenses, err := store.GetFrequentExpenses(userID)
+			if err != nil {
+				log.Printf("Error fetching frequent expenses: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, expenses)
+		case http.MethodPost:
+			e, ok := decodeFrequentExpense(w, r)
+			if !ok {
+				return
+			}
+			e.UserID = userID
+			id, err := store.InsertFrequentExpense(e)
+			if err != nil {
+				writeFrequentExpenseStoreError(w, 0, err)
+				return
+			}
+			if e, err = store.GetFrequentExpense(userID, id); err != nil {
+				writeFrequentExpenseStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, e)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// NewFrequentExpenseHandler creates an HTTP handler for /api/v1/prefilled-expenses/{id}:
+// GET returns the frequent expense, PUT replaces it and DELETE removes it. It must be wrapped by Authenticate.
+func NewFrequentExpenseHandler(store storage.FrequentExpenseStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid frequent expense id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			e, err := store.GetFrequentExpense(userID, id)
+			if err != nil {
+				writeFrequentExpenseStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, e)
+		case http.MethodPut:
+			e, ok := decodeFrequentExpense(w, r)
+			if !ok {
+				return
+			}
+			// The identity of the frequent expense comes from the URL and the caller, never from the body.
+			e.ID = id
+			e.UserID = userID
+			if err := store.UpdateFrequentExpense(e); err != nil {
+				writeFrequentExpenseStoreError(w, id, err)
+				return
+			}
+			if e, err = store.GetFrequentExpense(userID, id); err != nil {
+				writeFrequentExpenseStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, e)
+		case http.MethodDelete:
+			if err := store.DeleteFrequentExpense(userID, id); err != nil {
+				writeFrequentExpenseStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, MessageResponse{Message: "Frequent expense deleted successfully"})
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// NewFrequentExpenseSuggestionsHandler creates an HTTP handler for /api/v1/prefilled-expenses/suggestions
+// that suggests frequent expenses from the caller's recent expenses, each with its typical amount.
+// The configured minimum count, lookback and limit can be overridden with `min_count`, `days` and `limit`.
+// It must be wrapped by Authenticate.
+func NewFrequentExpenseSuggestionsHandler(reports *report.Builder, cfg config.SuggestionsConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		requested := cfg
+		for param, value := range map[string]*int{"min_count": &requested.MinCount, "days": &requested.LookbackDays, "limit": &requested.Limit} {
+			text := r.URL.Query().Get(param)
+			if text == "" {
+				continue
+			}
+			n, err := strconv.Atoi(text)
+			if err != nil || n < 1 {
+				http.Error(w, fmt.Sprintf("Invalid value for '%s' parameter. Must be a positive integer.", param), http.StatusBadRequest)
+				return
+			}
+			*value = n
+		}
+
+		suggestions, err := reports.Suggestions(userID, requested, time.Now())
+		if err != nil {
+			log.Printf("Error suggesting frequent expenses: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, suggestions)
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// decodeFrequentExpense reads and validates a frequent expense from the request body,
+// writing a 400 response if it is invalid.
+func decodeFrequentExpense(w http.ResponseWriter, r *http.Request) (frequent.Expense, bool) {
+	var e frequent.Expense
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return e, false
+	}
+	if err := e.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid frequent expense: %v.", err), http.StatusBadRequest)
+		return e, false
+	}
+	return e, true
+}
+
+// writeFrequentExpenseStoreError maps a store error to a 400, 404, 409 or 500 response.
+func writeFrequentExpenseStoreError(w http.ResponseWriter, id int64, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Frequent expense not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrFrequentExpenseExists):
+		http.Error(w, "A frequent expense with this name already exists.", http.StatusConflict)
+	case errors.Is(err, storage.ErrAccountNotFound):
+		http.Error(w, fmt.Sprintf("Invalid frequent expense: %v.", err), http.StatusBadRequest)
+	default:
+		log.Printf("Error accessing frequent expense %d: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
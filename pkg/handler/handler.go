@@ -2,8 +2,15 @@ package handler
 
 import (
 	"github.com/patrickmn/go-cache"
+	"log"
 	"time"
 )
 
 // --- Global Cache ---
 var c = cache.New(5*time.Minute, 10*time.Minute)
+
+// invalidateTransactionsCache drops every cached response after transactions changed.
+func invalidateTransactionsCache(reason string) {
+	c.Flush()
+	log.Printf("Cache flushed due to %s", reason)
+}
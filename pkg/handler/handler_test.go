@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"main/pkg/config"
+	"main/pkg/exchange"
+	"main/pkg/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const lunch = `{"date":"2026-01-02","name":"Lunch","category":"Food","amount":"12.50","currency":"SGD"}`
+
+// newTestHandler serves the transaction endpoints from a memory store, for the tokens "one" (user 1)
+// and "two" (user 2).
+func newTestHandler(t *testing.T) (http.Handler, *storage.MemoryStore) {
+	t.Helper()
+	store := storage.NewMemoryStore()
+	static, err := exchange.NewStaticRates("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates := exchange.NewRates(store, static)
+	tokens := []config.APIToken{{Token: "one", UserID: 1}, {Token: "two", UserID: 2}}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/transactions", Authenticate(tokens, NewTransactionsHandler(store, store, rates, config.DuplicatesConfig{})))
+	mux.Handle("/api/v1/transactions:batch", Authenticate(tokens, NewTransactionsBatchHandler(store, store, rates, config.DuplicatesConfig{})))
+	mux.Handle("/api/v1/transactions/{id}", Authenticate(tokens, NewTransactionItemHandler(store, rates, nil)))
+	return mux, store
+}
+
+// post sends a POST with the token and, unless empty, the idempotency key.
+func post(h http.Handler, path, token, key, body string) *httptest.ResponseRecorder {
+	return send(h, http.MethodPost, path, token, key, body)
+}
+
+// send sends a request with the token and, unless empty, the idempotency key.
+func send(h http.Handler, method, path, token, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+token)
+	if key != "" {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// countTransactions returns how many transactions the user has.
+func countTransactions(t *testing.T, store storage.TransactionStore, userID int64) int {
+	t.Helper()
+	page, err := store.GetAllTransactions(userID, storage.TransactionFilter{}, storage.PageRequest{Sort: storage.DefaultSort, Limit: 1, IncludeTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *page.Total
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"main/pkg/storage"
+	"net/http"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a request that may be retried.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotencyReplayedHeader is set on responses replayed for a repeated request.
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	// IdempotencyKeyLifetime is how long a key is remembered.
+	IdempotencyKeyLifetime = 24 * time.Hour
+
+	// maxIdempotencyKeyLength matches the idempotency_keys.idempotency_key column.
+	maxIdempotencyKeyLength = 255
+)
+
+// withIdempotencyKey serves the request with next, unless it carries an Idempotency-Key that was used within
+// IdempotencyKeyLifetime: a repeat of that request gets its original response, a different request with the
+// same key 422 and a repeat while the first is still being processed 409. Only successful responses are
+// remembered; after a failure the request can be retried with the same key.
+func withIdempotencyKey(keys storage.IdempotencyStore, userID int64, w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		next(w, r)
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		http.Error(w, fmt.Sprintf("%s must be at most %d characters.", IdempotencyKeyHeader, maxIdempotencyKeyLength), http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	now := time.Now()
+	rec := storage.IdempotencyRecord{UserID: userID, Key: key, RequestHash: requestHash(r, body), CreatedAt: now}
+	existing, err := keys.ReserveIdempotencyKey(rec, now.Add(-IdempotencyKeyLifetime))
+	switch {
+	case errors.Is(err, storage.ErrIdempotencyKeyUsed):
+		replayIdempotentResponse(w, r, rec, existing)
+		return
+	case err != nil:
+		log.Printf("Error reserving idempotency key: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	next(recorder, r)
+
+	if recorder.status >= 200 && recorder.status < 300 {
+		rec.StatusCode, rec.Body = recorder.status, recorder.body.Bytes()
+		err = keys.CompleteIdempotencyKey(rec)
+	} else {
+		err = keys.ReleaseIdempotencyKey(userID, key)
+	}
+	if err != nil {
+		// The response was already sent; a retry is then processed again.
+		log.Printf("Error saving the outcome of idempotency key: %v", err)
+	}
+}
+
+// replayIdempotentResponse answers a request whose key was already used.
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, rec, existing storage.IdempotencyRecord) {
+	switch {
+	case existing.RequestHash != rec.RequestHash:
+		http.Error(w, fmt.Sprintf("%s was already used for a different request.", IdempotencyKeyHeader), http.StatusUnprocessableEntity)
+	case existing.StatusCode == 0:
+		http.Error(w, fmt.Sprintf("A request with this %s is still being processed.", IdempotencyKeyHeader), http.StatusConflict)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(IdempotencyReplayedHeader, "true")
+		w.WriteHeader(existing.StatusCode)
+		if _, err := w.Write(existing.Body); err != nil {
+			log.Printf("Error writing replayed response: %v", err)
+		}
+		log.Printf("Replayed %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// requestHash identifies a request by its method, path, query and body.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s?%s\n", r.Method, r.URL.Path, r.URL.RawQuery)
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping its status code and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status code.
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the body.
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
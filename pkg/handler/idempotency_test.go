@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"main/pkg/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyReplaysResponse(t *testing.T) {
+	h, store := newTestHandler(t)
+
+	first := post(h, "/api/v1/transactions", "one", "key-1", lunch)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first request: %d %s", first.Code, first.Body)
+	}
+	if first.Header().Get(IdempotencyReplayedHeader) != "" {
+		t.Error("first response is marked as replayed")
+	}
+
+	retry := post(h, "/api/v1/transactions", "one", "key-1", lunch)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry: %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get(IdempotencyReplayedHeader) != "true" {
+		t.Errorf("retry is not marked as replayed")
+	}
+	if n := countTransactions(t, store, 1); n != 1 {
+		t.Errorf("saved %d transactions, want 1", n)
+	}
+}
+
+func TestIdempotencyKeyMismatch(t *testing.T) {
+	h, store := newTestHandler(t)
+
+	if w := post(h, "/api/v1/transactions", "one", "key-1", lunch); w.Code != http.StatusCreated {
+		t.Fatalf("first request: %d %s", w.Code, w.Body)
+	}
+	dinner := strings.Replace(lunch, "Lunch", "Dinner", 1)
+	if w := post(h, "/api/v1/transactions", "one", "key-1", dinner); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body with the same key: %d %s, want 422", w.Code, w.Body)
+	}
+	if w := post(h, "/api/v1/transactions?force=true", "one", "key-1", lunch); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different query with the same key: %d %s, want 422", w.Code, w.Body)
+	}
+	if w := post(h, "/api/v1/transactions:batch", "one", "key-1", "["+lunch+"]"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different path with the same key: %d %s, want 422", w.Code, w.Body)
+	}
+	if n := countTransactions(t, store, 1); n != 1 {
+		t.Errorf("saved %d transactions, want 1", n)
+	}
+}
+
+func TestIdempotencyKeysArePerUser(t *testing.T) {
+	h, store := newTestHandler(t)
+
+	for _, token := range []string{"one", "two"} {
+		w := post(h, "/api/v1/transactions", token, "key-1", lunch)
+		if w.Code != http.StatusCreated || w.Header().Get(IdempotencyReplayedHeader) != "" {
+			t.Errorf("user %s: %d %s, want a new transaction", token, w.Code, w.Body)
+		}
+	}
+	for _, userID := range []int64{1, 2} {
+		if n := countTransactions(t, store, userID); n != 1 {
+			t.Errorf("saved %d transactions for user %d, want 1", n, userID)
+		}
+	}
+}
+
+func TestIdempotencyKeyIsReleasedAfterFailure(t *testing.T) {
+	h, store := newTestHandler(t)
+
+	if w := post(h, "/api/v1/transactions", "one", "key-1", `{"name":`); w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed request: %d %s, want 400", w.Code, w.Body)
+	}
+	if w := post(h, "/api/v1/transactions", "one", "key-1", lunch); w.Code != http.StatusCreated {
+		t.Errorf("request after a failure: %d %s, want 201", w.Code, w.Body)
+	}
+	if n := countTransactions(t, store, 1); n != 1 {
+		t.Errorf("saved %d transactions, want 1", n)
+	}
+}
+
+func TestIdempotencyKeyInProgress(t *testing.T) {
+	h, store := newTestHandler(t)
+
+	// A request still being processed has reserved its key without an outcome.
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", nil)
+	rec := storage.IdempotencyRecord{UserID: 1, Key: "key-1", RequestHash: requestHash(r, []byte(lunch)), CreatedAt: time.Now()}
+	if _, err := store.ReserveIdempotencyKey(rec, time.Now().Add(-IdempotencyKeyLifetime)); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := post(h, "/api/v1/transactions", "one", "key-1", lunch); w.Code != http.StatusConflict {
+		t.Errorf("repeat while in progress: %d %s, want 409", w.Code, w.Body)
+	}
+	if n := countTransactions(t, store, 1); n != 0 {
+		t.Errorf("saved %d transactions, want 0", n)
+	}
+}
+
+func TestIdempotencyKeyReplaysBatch(t *testing.T) {
+	h, store := newTestHandler(t)
+	batch := "[" + lunch + "," + strings.Replace(lunch, "Lunch", "Dinner", 1) + "]"
+
+	first := post(h, "/api/v1/transactions:batch", "one", "batch-1", batch)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first batch: %d %s", first.Code, first.Body)
+	}
+	retry := post(h, "/api/v1/transactions:batch", "one", "batch-1", batch)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get(IdempotencyReplayedHeader) != "true" {
+		t.Errorf("retry: %d %s, want the replayed %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if n := countTransactions(t, store, 1); n != 2 {
+		t.Errorf("saved %d transactions, want 2", n)
+	}
+}
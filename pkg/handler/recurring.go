@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/recurring"
+	"main/pkg/storage"
+	"net/http"
+	"strconv"
+)
+
+// NewRecurringRulesHandler creates an HTTP handler for /api/v1/recurring-rules:
+// GET lists the caller's recurring rules, POST creates one. The bot's scheduler records
+// the transactions of new rules at its next check. It must be wrapped by Authenticate.
+func NewRecurringRulesHandler(store storage.RecurringStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rules, err := store.GetRecurringRules(userID)
+			if err != nil {
+				log.Printf("Error fetching recurring rules: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, rules)
+		case http.MethodPost:
+			rule, ok := decodeRecurringRule(w, r)
+			if !ok {
+				return
+			}
+			rule.UserID = userID
+			id, err := store.InsertRecurringRule(rule)
+			if err != nil {
+				log.Printf("Error inserting recurring rule: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			if rule, err = store.GetRecurringRule(userID, id); err != nil {
+				writeRecurringStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, rule)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// NewRecurringRuleHandler creates an HTTP handler for /api/v1/recurring-rules/{id}:
+// GET returns the rule, PUT replaces it and DELETE removes it, keeping the transactions it recorded.
+// It must be wrapped by Authenticate.
+func NewRecurringRuleHandler(store storage.RecurringStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid recurring rule id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rule, err := store.GetRecurringRule(userID, id)
+			if err != nil {
+				writeRecurringStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, rule)
+		case http.MethodPut:
+			rule, ok := decodeRecurringRule(w, r)
+			if !ok {
+				return
+			}
+			// The identity of the rule comes from the URL and the caller, never from the body.
+			rule.ID, rule.UserID = id, userID
+			if err := store.UpdateRecurringRule(rule); err != nil {
+				writeRecurringStoreError(w, id, err)
+				return
+			}
+			// Return the stored rule, with its last run date and creation time.
+			if rule, err = store.GetRecurringRule(userID, id); err != nil {
+				writeRecurringStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, rule)
+		case http.MethodDelete:
+			if err := store.DeleteRecurringRule(userID, id); err != nil {
+				writeRecurringStoreError(w, id, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, MessageResponse{Message: "Recurring rule deleted successfully"})
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
+
+// decodeRecurringRule reads and validates a rule from the request body, writing a 400 response if it is invalid.
+func decodeRecurringRule(w http.ResponseWriter, r *http.Request) (recurring.Rule, bool) {
+	var rule recurring.Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return rule, false
+	}
+	if err := rule.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid recurring rule: %v.", err), http.StatusBadRequest)
+		return rule, false
+	}
+	return rule, true
+}
+
+// writeRecurringStoreError maps a store error to a 404 or 500 response.
+func writeRecurringStoreError(w http.ResponseWriter, id int64, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Recurring rule not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("Error accessing recurring rule %d: %v", id, err)
+	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+}
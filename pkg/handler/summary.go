@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/patrickmn/go-cache"
+	"log"
+	"main/pkg/report"
+	"net/http"
+	"time"
+)
+
+// NewSummaryHandler creates an HTTP handler that serves the caller's spending summary:
+// per-currency totals and, when a base currency is configured, the converted totals.
+// The `period` query parameter accepts the same periods as the /summary bot command
+// (e.g. "2025-09", "last month" or "2025-01-01..2025-03-31") and defaults to the current month.
+// It must be wrapped by Authenticate.
+func NewSummaryHandler(reports *report.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		cacheKey := fmt.Sprintf("%d:%s", userID, r.URL.String())
+		if cachedResponse, found := c.Get(cacheKey); found {
+			writeJSON(w, http.StatusOK, cachedResponse)
+			log.Printf("Served %s %s from cache", r.Method, r.URL.Path)
+			return
+		}
+
+		period, err := report.ParsePeriod(r.URL.Query().Get("period"), time.Now())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid value for 'period' parameter: %v. %s", err, report.PeriodUsage), http.StatusBadRequest)
+			return
+		}
+
+		summary, err := reports.Build(userID, period)
+		if err != nil {
+			log.Printf("Error building summary: %v", err)
+			http.Error(w, "Internal Server Error while building the summary.", http.StatusInternalServerError)
+			return
+		}
+
+		c.Set(cacheKey, summary, cache.DefaultExpiration)
+		writeJSON(w, http.StatusOK, summary)
+		log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	}
+}
@@ -2,22 +2,37 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/patrickmn/go-cache"
 	"log"
+	"main/pkg/config"
+	"main/pkg/exchange"
 	"main/pkg/storage"
 	"main/pkg/transaction"
 	"net/http"
 	"strconv"
 )
 
+// maxLimit is the most transactions a page may hold.
+const maxLimit = 500
+
 type MessageResponse struct {
 	Message string `json:"message"`
 }
 
-// getTransactionsHandler retrieves transactions, allowing filtering and pagination.
-func getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+// DuplicateResponse is the 409 response to a new transaction that looks like one already saved.
+type DuplicateResponse struct {
+	Message     string `json:"message"`
+	DuplicateID int64  `json:"duplicateId"` // The saved transaction it looks like
+}
+
+// getTransactionsHandler retrieves transactions, allowing filtering, sorting and pagination.
+// See transactionFilterFromQuery for the filter parameters. Pages are followed with the opaque
+// nextCursor and prevCursor of the response; the page parameter remains for older clients.
+func getTransactionsHandler(store storage.TransactionStore, userID int64, w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
-	cacheKey := r.URL.String() // Use the full URL as the cache key
+	cacheKey := fmt.Sprintf("%d:%s", userID, r.URL.String()) // Use the caller and the full URL as the cache key
 
 	// Check cache first
 	if cachedResponse, found := c.Get(cacheKey); found {
@@ -31,38 +46,23 @@ func getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Filtering parameters
-	categoryFilter := queryParams.Get("category")
-
-	var isClaimableFilter *bool
-	if claimableStr := queryParams.Get("is_claimable"); claimableStr != "" {
-		val, err := strconv.ParseBool(claimableStr)
-		if err != nil {
-			log.Printf("Invalid boolean value for is_claimable: %s. Error: %v", claimableStr, err)
-			http.Error(w, "Invalid value for 'is_claimable' parameter. Use 'true' or 'false'.", http.StatusBadRequest)
-			return
-		}
-		isClaimableFilter = &val
-	}
-
-	var paidForFamilyFilter *bool
-	if paidForFamilyStr := queryParams.Get("paid_for_family"); paidForFamilyStr != "" {
-		val, err := strconv.ParseBool(paidForFamilyStr)
-		if err != nil {
-			log.Printf("Invalid boolean value for paid_for_family: %s. Error: %v", paidForFamilyStr, err)
-			http.Error(w, "Invalid value for 'paid_for_family' parameter. Use 'true' or 'false'.", http.StatusBadRequest)
-			return
-		}
-		paidForFamilyFilter = &val
+	filter, err := transactionFilterFromQuery(queryParams)
+	if err != nil {
+		log.Printf("Invalid transaction filter %q: %v", r.URL.RawQuery, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Pagination parameters
 	pageStr := queryParams.Get("page")
 	limitStr := queryParams.Get("limit")
+	cursor := queryParams.Get("cursor")
 
-	page := 1   // Default page
+	page := 1   // Default page, for clients that do not use cursors
 	limit := 10 // Default limit (items per page)
-	var err error
+	if cursor != "" {
+		page = 0
+	}
 
 	if pageStr != "" {
 		page, err = strconv.Atoi(pageStr)
@@ -71,41 +71,67 @@ func getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid value for 'page' parameter. Must be a positive integer.", http.StatusBadRequest)
 			return
 		}
+		if cursor != "" {
+			http.Error(w, "Use either 'page' or 'cursor', not both.", http.StatusBadRequest)
+			return
+		}
 	}
 
 	if limitStr != "" {
 		limit, err = strconv.Atoi(limitStr)
-		if err != nil || limit < 1 {
-			log.Printf("Invalid value for 'limit' parameter: %s. Must be a positive integer.", limitStr)
-			http.Error(w, "Invalid value for 'limit' parameter. Must be a positive integer.", http.StatusBadRequest)
+		if err != nil || limit < 1 || limit > maxLimit {
+			log.Printf("Invalid value for 'limit' parameter: %s. Must be an integer between 1 and %d.", limitStr, maxLimit)
+			http.Error(w, fmt.Sprintf("Invalid value for 'limit' parameter. Must be an integer between 1 and %d.", maxLimit), http.StatusBadRequest)
 			return
 		}
 	}
 
-	transactions, totalItems, err := storage.GetAllTransactionsFromDB(
-		categoryFilter,
-		isClaimableFilter,
-		paidForFamilyFilter,
-		page,
-		limit,
-	)
+	sort, err := storage.ParseSort(queryParams.Get("sort"))
 	if err != nil {
-		log.Printf("Error fetching transactions: %v", err)
-		http.Error(w, "Internal Server Error while fetching transactions.", http.StatusInternalServerError)
+		log.Printf("Invalid value for 'sort' parameter: %v", err)
+		http.Error(w, "Invalid value for 'sort' parameter. Use date, amount, name or category, optionally followed by ':asc' or ':desc'.", http.StatusBadRequest)
 		return
 	}
 
-	totalPages := 0
-	if totalItems > 0 && limit > 0 {
-		totalPages = (totalItems + limit - 1) / limit // Ceiling division
+	// Counting every match costs an extra query, so cursor clients ask for it explicitly.
+	// Requests without a cursor always get it, as before cursors existed.
+	includeTotal, err := boolParam(queryParams, "include_total")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := store.GetAllTransactions(userID, filter, storage.PageRequest{
+		Sort:         sort,
+		Limit:        limit,
+		Cursor:       cursor,
+		Page:         page,
+		IncludeTotal: page > 0 || (includeTotal != nil && *includeTotal),
+	})
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		http.Error(w, fmt.Sprintf("Invalid value for 'cursor' parameter: %v.", err), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching transactions: %v", err)
+		http.Error(w, "Internal Server Error while fetching transactions.", http.StatusInternalServerError)
+		return
 	}
 
 	response := transaction.PaginatedTransactionsResponse{
-		Transactions: transactions,
-		CurrentPage:  page,
+		Transactions: result.Transactions,
 		PageSize:     limit,
-		TotalItems:   totalItems,
-		TotalPages:   totalPages,
+		Sort:         sort.String(),
+		NextCursor:   result.NextCursor,
+		PrevCursor:   result.PrevCursor,
+		TotalItems:   result.Total,
+	}
+	if page > 0 {
+		response.CurrentPage = &page
+	}
+	if result.Total != nil {
+		totalPages := (*result.Total + limit - 1) / limit // Ceiling division
+		response.TotalPages = &totalPages
 	}
 
 	// Store in cache
@@ -117,27 +143,64 @@ func getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		return
 	}
-	log.Printf("Served %s %s with %d transactions (page %d, limit %d, total %d) from %s",
-		r.Method, r.URL.Path, len(transactions), page, limit, totalItems, r.RemoteAddr)
+	log.Printf("Served %s %s with %d transactions (sort %s, limit %d) from %s",
+		r.Method, r.URL.Path, len(result.Transactions), sort, limit, r.RemoteAddr)
 }
 
 // createTransactionHandler handles the creation of a new transaction.
-func createTransactionHandler(w http.ResponseWriter, r *http.Request) {
+// A transaction that looks like one already saved is rejected with 409 unless `force=true` is passed.
+func createTransactionHandler(store storage.TransactionStore, rates *exchange.Rates, duplicates config.DuplicatesConfig, userID int64, w http.ResponseWriter, r *http.Request) {
+	force, err := boolParam(r.URL.Query(), "force")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var newTransaction transaction.Transaction
 	if err := json.NewDecoder(r.Body).Decode(&newTransaction); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
+	// The owner always comes from the authenticated caller, never from the body.
+	newTransaction.UserID = userID
+	// Only the recurring rule scheduler links transactions to rules.
+	newTransaction.RecurringRuleID = 0
+	// The claim only moves through the claim endpoint.
+	newTransaction.StartClaim()
+	if err := newTransaction.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid transaction: %v.", err), http.StatusBadRequest)
+		return
+	}
+	stampExchangeRate(rates, &newTransaction)
 
-	if err := storage.InsertTransaction(newTransaction); err != nil {
+	if !duplicates.Disabled && (force == nil || !*force) {
+		duplicateID, err := store.FindDuplicate(newTransaction, duplicates.WindowDays)
+		if err != nil {
+			log.Printf("Error checking for duplicate transactions: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if duplicateID != 0 {
+			writeJSON(w, http.StatusConflict, DuplicateResponse{
+				Message:     fmt.Sprintf("Looks like a duplicate of transaction %d. Pass force=true to save it anyway.", duplicateID),
+				DuplicateID: duplicateID,
+			})
+			return
+		}
+	}
+
+	if _, err := store.InsertTransaction(newTransaction); err != nil {
+		if errors.Is(err, storage.ErrAccountNotFound) {
+			http.Error(w, fmt.Sprintf("Invalid transaction: %v.", err), http.StatusBadRequest)
+			return
+		}
 		log.Printf("Error inserting transaction: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
 	// Invalidate cache
-	c.Flush()
-	log.Println("Cache flushed due to new transaction")
+	invalidateTransactionsCache("new transaction")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -147,14 +210,28 @@ func createTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// TransactionsHandler routes to different handlers based on the HTTP method.
-func TransactionsHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		getTransactionsHandler(w, r)
-	case http.MethodPost:
-		createTransactionHandler(w, r)
-	default:
-		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+// NewTransactionsHandler creates an HTTP handler backed by the given store
+// that routes to different handlers based on the HTTP method.
+// New transactions are stamped with the exchange rate in effect on their date and checked for duplicates,
+// and a POST repeated with the same Idempotency-Key gets the original response instead of saving it again.
+// It must be wrapped by Authenticate, every request only sees the caller's transactions.
+func NewTransactionsHandler(store storage.TransactionStore, keys storage.IdempotencyStore, rates *exchange.Rates, duplicates config.DuplicatesConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			getTransactionsHandler(store, userID, w, r)
+		case http.MethodPost:
+			withIdempotencyKey(keys, userID, w, r, func(w http.ResponseWriter, r *http.Request) {
+				createTransactionHandler(store, rates, duplicates, userID, w, r)
+			})
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
 	}
 }
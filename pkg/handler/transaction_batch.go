@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/config"
+	"main/pkg/exchange"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"net/http"
+)
+
+// maxBatchSize is the most transactions a single batch may hold.
+const maxBatchSize = 5000
+
+// Batch modes: an atomic batch saves every transaction or none, a partial batch saves those it can.
+const (
+	batchAtomic  = "atomic"
+	batchPartial = "partial"
+)
+
+// Statuses of the transactions of a batch.
+const (
+	batchCreated   = "created"   // Saved, with its id
+	batchInvalid   = "invalid"   // Rejected, with the error
+	batchDuplicate = "duplicate" // Looks like a saved transaction, with its duplicateId
+	batchFailed    = "failed"    // Could not be saved because of a server error
+	batchSkipped   = "skipped"   // Not saved because another transaction of an atomic batch was not
+)
+
+// BatchResponse reports what happened to every transaction of a batch.
+type BatchResponse struct {
+	Mode    string        `json:"mode"`
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"` // Transactions that were invalid, duplicates or failed
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is the outcome of the transaction at Index of a batch.
+type BatchResult struct {
+	Index       int    `json:"index"`
+	Status      string `json:"status"`
+	ID          int64  `json:"id,omitempty"`
+	DuplicateID int64  `json:"duplicateId,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// NewTransactionsBatchHandler creates an HTTP handler for POST /api/v1/transactions:batch, which creates
+// the transactions of a JSON array. By default, or with `mode=atomic`, they are all saved in one database
+// transaction, or none are if one of them is invalid or a duplicate (422). With `mode=partial` every valid
+// transaction is saved and the others are reported (207). Duplicates are checked like single transactions,
+// against those already saved, unless `force=true` is passed. The transactions cache is invalidated once.
+// It must be wrapped by Authenticate.
+func NewTransactionsBatchHandler(store storage.TransactionStore, keys storage.IdempotencyStore, rates *exchange.Rates, duplicates config.DuplicatesConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		withIdempotencyKey(keys, userID, w, r, func(w http.ResponseWriter, r *http.Request) {
+			createTransactionsBatchHandler(store, rates, duplicates, userID, w, r)
+		})
+	}
+}
+
+// createTransactionsBatchHandler validates and saves the transactions of a batch.
+func createTransactionsBatchHandler(store storage.TransactionStore, rates *exchange.Rates, duplicates config.DuplicatesConfig, userID int64, w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = batchAtomic
+	}
+	if mode != batchAtomic && mode != batchPartial {
+		http.Error(w, "Invalid value for 'mode' parameter. Use 'atomic' or 'partial'.", http.StatusBadRequest)
+		return
+	}
+	force, err := boolParam(r.URL.Query(), "force")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Items are decoded one by one, so that a malformed transaction is reported like an invalid one.
+	var items []json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v. Send a JSON array of transactions.", err), http.StatusBadRequest)
+		return
+	}
+	if len(items) == 0 || len(items) > maxBatchSize {
+		http.Error(w, fmt.Sprintf("A batch must hold between 1 and %d transactions.", maxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	response := BatchResponse{Mode: mode, Results: make([]BatchResult, len(items))}
+	var valid []transaction.Transaction
+	var validIndexes []int
+	for i, item := range items {
+		result := &response.Results[i]
+		result.Index = i
+
+		var t transaction.Transaction
+		if err := json.Unmarshal(item, &t); err != nil {
+			result.Status, result.Error = batchInvalid, fmt.Sprintf("Invalid transaction: %v.", err)
+			continue
+		}
+		// The owner always comes from the authenticated caller, never from the body.
+		t.UserID = userID
+		// Only the recurring rule scheduler links transactions to rules.
+		t.RecurringRuleID = 0
+		// The claim only moves through the claim endpoint.
+		t.StartClaim()
+		if err := t.Validate(); err != nil {
+			result.Status, result.Error = batchInvalid, fmt.Sprintf("Invalid transaction: %v.", err)
+			continue
+		}
+		stampExchangeRate(rates, &t)
+
+		if !duplicates.Disabled && (force == nil || !*force) {
+			duplicateID, err := store.FindDuplicate(t, duplicates.WindowDays)
+			if err != nil {
+				log.Printf("Error checking transaction %d of a batch for duplicates: %v", i, err)
+				if mode == batchAtomic {
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				result.Status, result.Error = batchFailed, "Internal Server Error"
+				continue
+			}
+			if duplicateID != 0 {
+				result.Status, result.DuplicateID = batchDuplicate, duplicateID
+				result.Error = fmt.Sprintf("Looks like a duplicate of transaction %d. Pass force=true to save it anyway.", duplicateID)
+				continue
+			}
+		}
+		valid = append(valid, t)
+		validIndexes = append(validIndexes, i)
+	}
+
+	if mode == batchAtomic {
+		if len(valid) == len(items) {
+			ids, err := store.InsertTransactions(valid)
+			var batchErr *storage.BatchError
+			switch {
+			case errors.As(err, &batchErr) && errors.Is(err, storage.ErrAccountNotFound):
+				result := &response.Results[validIndexes[batchErr.Index]]
+				result.Status, result.Error = batchInvalid, fmt.Sprintf("Invalid transaction: %v.", batchErr.Err)
+			case err != nil:
+				log.Printf("Error inserting a batch of transactions: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			default:
+				for n, id := range ids {
+					response.Results[validIndexes[n]].Status, response.Results[validIndexes[n]].ID = batchCreated, id
+				}
+			}
+		}
+	} else {
+		for n, t := range valid {
+			result := &response.Results[validIndexes[n]]
+			id, err := store.InsertTransaction(t)
+			switch {
+			case errors.Is(err, storage.ErrAccountNotFound):
+				result.Status, result.Error = batchInvalid, fmt.Sprintf("Invalid transaction: %v.", err)
+			case err != nil:
+				log.Printf("Error inserting transaction %d of a batch: %v", result.Index, err)
+				result.Status, result.Error = batchFailed, "Internal Server Error"
+			default:
+				result.Status, result.ID = batchCreated, id
+			}
+		}
+	}
+
+	for i := range response.Results {
+		switch response.Results[i].Status {
+		case batchCreated:
+			response.Created++
+		case "":
+			// Only an atomic batch leaves valid transactions unsaved.
+			response.Results[i].Status = batchSkipped
+		default:
+			response.Failed++
+		}
+	}
+	if response.Created > 0 {
+		invalidateTransactionsCache(fmt.Sprintf("batch of %d new transactions", response.Created))
+	}
+
+	status := http.StatusCreated
+	switch {
+	case response.Failed > 0 && mode == batchAtomic:
+		status = http.StatusUnprocessableEntity
+	case response.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+	writeJSON(w, status, response)
+	log.Printf("Served %s %s with %d of %d transactions created (%s) from %s",
+		r.Method, r.URL.Path, response.Created, len(items), mode, r.RemoteAddr)
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"main/pkg/money"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// transactionFilterFromQuery reads the transaction filter from the query parameters:
+//   - from, to: inclusive YYYY-MM-DD bounds on the transaction date
+//   - min_amount, max_amount: inclusive decimal bounds, in each transaction's own currency
+//   - currency: ISO 4217 code
+//   - type: repeated or comma separated transaction types (expense, income, transfer)
+//   - account: account ID, matches transactions paid from, into or transferred to the account
+//   - category: repeated (category=Food&category=Transport) or comma separated, matches any of them
+//   - is_claimable, paid_for_family: true or false
+//   - claim_status: repeated or comma separated claim statuses, "outstanding" for claimable and submitted
+//   - q: case-insensitive text the name must contain
+//   - tag: repeated or comma separated; tag_match=all requires every tag, the default "any" one of them
+//
+// The returned error is the message of the 400 response.
+func transactionFilterFromQuery(queryParams url.Values) (storage.TransactionFilter, error) {
+	var filter storage.TransactionFilter
+	var err error
+
+	if filter.From, err = dateParam(queryParams, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = dateParam(queryParams, "to"); err != nil {
+		return filter, err
+	}
+	if filter.From != "" && filter.To != "" && filter.To < filter.From {
+		return filter, errors.New("Invalid date range: 'to' must not be before 'from'.")
+	}
+
+	if filter.MinAmount, err = amountParam(queryParams, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = amountParam(queryParams, "max_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && filter.MaxAmount.Cmp(filter.MinAmount) < 0 {
+		return filter, errors.New("Invalid amount range: 'max_amount' must not be less than 'min_amount'.")
+	}
+
+	if currency := strings.TrimSpace(queryParams.Get("currency")); currency != "" {
+		if len(currency) != 3 {
+			return filter, errors.New("Invalid value for 'currency' parameter. Use a three-letter ISO 4217 code.")
+		}
+		filter.Currency = strings.ToUpper(currency)
+	}
+
+	for _, value := range queryParams["type"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			t, err := transaction.ParseType(name)
+			if err != nil {
+				return filter, fmt.Errorf("Invalid value for 'type' parameter: %v.", err)
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+
+	if value := queryParams.Get("account"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 1 {
+			return filter, errors.New("Invalid value for 'account' parameter. Must be a positive integer.")
+		}
+		filter.AccountID = id
+	}
+
+	for _, value := range queryParams["category"] {
+		for _, category := range strings.Split(value, ",") {
+			if category = strings.TrimSpace(category); category != "" {
+				filter.Categories = append(filter.Categories, category)
+			}
+		}
+	}
+
+	if filter.IsClaimable, err = boolParam(queryParams, "is_claimable"); err != nil {
+		return filter, err
+	}
+	if filter.PaidForFamily, err = boolParam(queryParams, "paid_for_family"); err != nil {
+		return filter, err
+	}
+
+	for _, value := range queryParams["claim_status"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if strings.EqualFold(name, "outstanding") {
+				filter.ClaimStatuses = append(filter.ClaimStatuses, transaction.ClaimClaimable, transaction.ClaimSubmitted)
+				continue
+			}
+			status, err := transaction.ParseClaimStatus(name)
+			if err != nil {
+				return filter, fmt.Errorf("Invalid value for 'claim_status' parameter: %v.", err)
+			}
+			filter.ClaimStatuses = append(filter.ClaimStatuses, status)
+		}
+	}
+
+	filter.Query = strings.TrimSpace(queryParams.Get("q"))
+
+	if filter.Tags, err = transaction.ParseTags(strings.Join(queryParams["tag"], ",")); err != nil {
+		return filter, fmt.Errorf("Invalid value for 'tag' parameter: %v.", err)
+	}
+	switch match := storage.TagMatch(queryParams.Get("tag_match")); match {
+	case "", storage.TagMatchAny:
+		filter.TagMatch = storage.TagMatchAny
+	case storage.TagMatchAll:
+		filter.TagMatch = match
+	default:
+		return filter, errors.New("Invalid value for 'tag_match' parameter. Use 'any' or 'all'.")
+	}
+	return filter, nil
+}
+
+// dateParam reads an optional date parameter and normalises it to YYYY-MM-DD.
+func dateParam(queryParams url.Values, name string) (string, error) {
+	value := queryParams.Get(name)
+	if value == "" {
+		return "", nil
+	}
+	date, err := transaction.ParseDate(value)
+	if err != nil {
+		return "", fmt.Errorf("Invalid value for '%s' parameter. Use YYYY-MM-DD.", name)
+	}
+	return date.Format("2006-01-02"), nil
+}
+
+// amountParam reads an optional non-negative decimal parameter.
+func amountParam(queryParams url.Values, name string) (*big.Rat, error) {
+	value := queryParams.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := money.ParseDecimal(value)
+	if err != nil || amount.Sign() < 0 {
+		return nil, fmt.Errorf("Invalid value for '%s' parameter. Must be a non-negative decimal number.", name)
+	}
+	return amount, nil
+}
+
+// boolParam reads an optional boolean parameter.
+func boolParam(queryParams url.Values, name string) (*bool, error) {
+	value := queryParams.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid value for '%s' parameter. Use 'true' or 'false'.", name)
+	}
+	return &parsed, nil
+}
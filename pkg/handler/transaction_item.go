@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/attachment"
+	"main/pkg/exchange"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"net/http"
+	"strconv"
+)
+
+// NewTransactionItemHandler creates an HTTP handler for /api/v1/transactions/{id}
+// that routes to different handlers based on the HTTP method.
+// Edited transactions are stamped again with the exchange rate in effect on their (possibly new) date.
+// Deleting a transaction also deletes its attachments.
+// It must be wrapped by Authenticate, callers can only reach their own transactions.
+func NewTransactionItemHandler(store storage.TransactionStore, rates *exchange.Rates, attachments *attachment.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDFromRequest(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid transaction id. Must be a positive integer.", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			getTransactionHandler(store, userID, id, w, r)
+		case http.MethodPut:
+			replaceTransactionHandler(store, rates, userID, id, w, r)
+		case http.MethodPatch:
+			patchTransactionHandler(store, rates, userID, id, w, r)
+		case http.MethodDelete:
+			deleteTransactionHandler(store, attachments, userID, id, w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// getTransactionHandler returns a single transaction.
+func getTransactionHandler(store storage.TransactionStore, userID, id int64, w http.ResponseWriter, r *http.Request) {
+	t, err := store.GetTransaction(userID, id)
+	if err != nil {
+		writeStoreError(w, id, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+	log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+}
+
+// replaceTransactionHandler replaces every editable field of a transaction.
+func replaceTransactionHandler(store storage.TransactionStore, rates *exchange.Rates, userID, id int64, w http.ResponseWriter, r *http.Request) {
+	existing, err := store.GetTransaction(userID, id)
+	if err != nil {
+		writeStoreError(w, id, err)
+		return
+	}
+
+	var replacement transaction.Transaction
+	if err := json.NewDecoder(r.Body).Decode(&replacement); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	// The identity of the transaction comes from the URL and the caller, never from the body.
+	replacement.ID = id
+	replacement.UserID = userID
+	replacement.CreatedAt = existing.CreatedAt
+	replacement.RecurringRuleID = existing.RecurringRuleID
+	// The claim only moves through the claim endpoint; it is dropped if the transaction is no longer claimable.
+	replacement.ClaimStatus, replacement.ClaimReference = existing.ClaimStatus, existing.ClaimReference
+	replacement.ClaimSubmittedAt, replacement.ClaimResolvedAt = existing.ClaimSubmittedAt, existing.ClaimResolvedAt
+
+	saveTransactionChanges(store, rates, replacement, w, r)
+}
+
+// patchTransactionHandler updates only the fields present in the request body.
+func patchTransactionHandler(store storage.TransactionStore, rates *exchange.Rates, userID, id int64, w http.ResponseWriter, r *http.Request) {
+	existing, err := store.GetTransaction(userID, id)
+	if err != nil {
+		writeStoreError(w, id, err)
+		return
+	}
+
+	var patch transaction.Patch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := patch.ApplyTo(&existing); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid transaction: %v.", err), http.StatusBadRequest)
+		return
+	}
+
+	saveTransactionChanges(store, rates, existing, w, r)
+}
+
+// saveTransactionChanges validates and stores an edited transaction, then responds with it.
+func saveTransactionChanges(store storage.TransactionStore, rates *exchange.Rates, t transaction.Transaction, w http.ResponseWriter, r *http.Request) {
+	if err := t.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid transaction: %v.", err), http.StatusBadRequest)
+		return
+	}
+	stampExchangeRate(rates, &t)
+
+	if err := store.UpdateTransaction(t); err != nil {
+		writeStoreError(w, t.ID, err)
+		return
+	}
+	invalidateTransactionsCache("updated transaction")
+
+	writeJSON(w, http.StatusOK, t)
+	log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+}
+
+// deleteTransactionHandler removes a transaction and the files of its attachments.
+func deleteTransactionHandler(store storage.TransactionStore, attachments *attachment.Service, userID, id int64, w http.ResponseWriter, r *http.Request) {
+	// The attachment rows go with the transaction, so look up their files first.
+	files, err := attachments.List(userID, id)
+	if err != nil {
+		writeStoreError(w, id, err)
+		return
+	}
+	if err := store.DeleteTransaction(userID, id); err != nil {
+		writeStoreError(w, id, err)
+		return
+	}
+	attachments.DeleteFiles(files)
+	invalidateTransactionsCache("deleted transaction")
+
+	writeJSON(w, http.StatusOK, MessageResponse{Message: "Transaction deleted successfully"})
+	log.Printf("Served %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+}
+
+// writeStoreError maps storage errors to HTTP responses.
+func writeStoreError(w http.ResponseWriter, id int64, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, storage.ErrAccountNotFound) {
+		http.Error(w, fmt.Sprintf("Invalid transaction: %v.", err), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Error accessing transaction %d: %v", id, err)
+	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+}
+
+// writeJSON writes the value as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response JSON: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+	"main/pkg/recurring"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// withRule adds a recurringRuleId to a transaction body.
+func withRule(body string, ruleID int64) string {
+	return strings.Replace(body, "{", fmt.Sprintf(`{"recurringRuleId":%d,`, ruleID), 1)
+}
+
+// onlyTransaction returns the user's single transaction.
+func onlyTransaction(t *testing.T, store storage.TransactionStore, userID int64) transaction.Transaction {
+	t.Helper()
+	page, err := store.GetAllTransactions(userID, storage.TransactionFilter{}, storage.PageRequest{Sort: storage.DefaultSort, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Transactions) != 1 {
+		t.Fatalf("user %d has %d transactions, want 1", userID, len(page.Transactions))
+	}
+	return page.Transactions[0]
+}
+
+func TestPostedRecurringRuleIDIsIgnored(t *testing.T) {
+	for _, path := range []string{"/api/v1/transactions", "/api/v1/transactions:batch"} {
+		t.Run(path, func(t *testing.T) {
+			h, store := newTestHandler(t)
+			// Another user's rule, whose occurrence the transaction would otherwise take the place of.
+			ruleID, err := store.InsertRecurringRule(recurring.Rule{UserID: 2, Name: "Rent", Amount: 100000, Currency: "SGD",
+				Category: "Housing", Frequency: recurring.Monthly, Interval: 1, StartDate: "2026-01-02"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := withRule(lunch, ruleID)
+			if strings.HasSuffix(path, ":batch") {
+				body = "[" + body + "]"
+			}
+			if w := post(h, path, "one", "", body); w.Code != http.StatusCreated {
+				t.Fatalf("POST: %d %s", w.Code, w.Body)
+			}
+			if got := onlyTransaction(t, store, 1); got.RecurringRuleID != 0 {
+				t.Errorf("saved with recurring rule %d, want none", got.RecurringRuleID)
+			}
+		})
+	}
+}
+
+func TestReplacedTransactionKeepsItsRecurringRule(t *testing.T) {
+	h, store := newTestHandler(t)
+	rule := recurring.Rule{UserID: 1, Name: "Rent", Amount: 100000, Currency: "SGD", Category: "Housing",
+		Frequency: recurring.Monthly, Interval: 1, StartDate: "2026-01-02"}
+	var err error
+	if rule.ID, err = store.InsertRecurringRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := store.RecordRecurringRun(rule, []transaction.Transaction{rule.Transaction("2026-01-02")}, "2026-01-02")
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("recorded %v, %v", recorded, err)
+	}
+
+	path := fmt.Sprintf("/api/v1/transactions/%d", recorded[0].ID)
+	if w := send(h, http.MethodPut, path, "one", "", withRule(lunch, rule.ID+1)); w.Code != http.StatusOK {
+		t.Fatalf("PUT: %d %s", w.Code, w.Body)
+	}
+	if got := onlyTransaction(t, store, 1); got.RecurringRuleID != rule.ID || got.Name != "Lunch" {
+		t.Errorf("replaced %+v, want Lunch of recurring rule %d", got, rule.ID)
+	}
+}
+
+func TestPostedClaimStartsClaimable(t *testing.T) {
+	claim := `{"isClaimable":true,"claimStatus":"reimbursed","claimReference":"EXP-1",` +
+		`"claimSubmittedAt":"2026-01-03T00:00:00Z","claimResolvedAt":"2026-01-04T00:00:00Z",`
+	for _, path := range []string{"/api/v1/transactions", "/api/v1/transactions:batch"} {
+		t.Run(path, func(t *testing.T) {
+			h, store := newTestHandler(t)
+			body := strings.Replace(lunch, "{", claim, 1)
+			if strings.HasSuffix(path, ":batch") {
+				body = "[" + body + "]"
+			}
+			if w := post(h, path, "one", "", body); w.Code != http.StatusCreated {
+				t.Fatalf("POST: %d %s", w.Code, w.Body)
+			}
+			got := onlyTransaction(t, store, 1)
+			if got.ClaimStatus != transaction.ClaimClaimable || got.ClaimReference != "" || got.ClaimSubmittedAt != nil || got.ClaimResolvedAt != nil {
+				t.Errorf("saved claim %s %q %v %v, want a new claimable one", got.ClaimStatus, got.ClaimReference, got.ClaimSubmittedAt, got.ClaimResolvedAt)
+			}
+		})
+	}
+}
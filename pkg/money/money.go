@@ -0,0 +1,156 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultExponent is the number of minor-unit digits of currencies missing from exponents.
+const defaultExponent = 2
+
+// exponents lists the ISO 4217 currencies whose minor unit is not 1/100.
+// Keep in sync with the backfill in the amount_minor storage migration.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// Exponent returns the number of decimal digits of the currency's minor unit,
+// for example 2 for SGD (cents), 0 for JPY and 3 for KWD.
+func Exponent(currency string) int {
+	if exp, ok := exponents[strings.ToUpper(strings.TrimSpace(currency))]; ok {
+		return exp
+	}
+	return defaultExponent
+}
+
+// Currencies returns the currencies whose exponent differs from the default, grouped by exponent
+// and sorted, e.g. {0: [BIF CLP ...], 3: [BHD ...], 4: [CLF UYW]}.
+// Every other currency has two decimals.
+func Currencies() map[int][]string {
+	groups := make(map[int][]string)
+	for currency, exp := range exponents {
+		groups[exp] = append(groups[exp], currency)
+	}
+	for _, currencies := range groups {
+		sort.Strings(currencies)
+	}
+	return groups
+}
+
+// ParseDecimal parses a decimal value such as "12.5" without a currency, e.g. a filter bound
+// that applies to amounts in any currency.
+func ParseDecimal(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if (whole == "" && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("%q is not a decimal number", s)
+	}
+	value, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a decimal number", s)
+	}
+	return value, nil
+}
+
+// ToMinor scales a decimal value to minor units of a currency with the given exponent.
+// Values between two minor units are rounded up when roundUp is set and down otherwise,
+// so that comparisons against whole minor units keep their meaning.
+func ToMinor(value *big.Rat, exp int, roundUp bool) int64 {
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	// QuoRem truncates towards zero, adjust towards the requested direction.
+	if remainder.Sign() != 0 {
+		if roundUp && remainder.Sign() > 0 {
+			quotient.Add(quotient, big.NewInt(1))
+		} else if !roundUp && remainder.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		}
+	}
+	if !quotient.IsInt64() {
+		if quotient.Sign() > 0 {
+			return math.MaxInt64
+		}
+		return math.MinInt64
+	}
+	return quotient.Int64()
+}
+
+// Parse converts a decimal amount such as "12.5" into integer minor units of the currency (1250 for SGD).
+// It never rounds: amounts with more decimals than the currency allows are rejected.
+func Parse(s string, currency string) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return 0, errors.New("amount is empty")
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%q is not a decimal number", s)
+	}
+
+	exp := Exponent(currency)
+	// Trailing zeros never change the value, so "12.500" is fine for SGD.
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exp {
+		if exp == 0 {
+			return 0, fmt.Errorf("%s amounts cannot have decimals", strings.ToUpper(currency))
+		}
+		return 0, fmt.Errorf("%s amounts can have at most %d decimals", strings.ToUpper(currency), exp)
+	}
+	fraction += strings.Repeat("0", exp-len(fraction))
+
+	digits := strings.TrimLeft(whole+fraction, "0")
+	if digits == "" {
+		return 0, nil
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is out of range", s)
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// Format renders minor units as a decimal string with exactly the currency's number of decimals,
+// for example 1250 SGD as "12.50" and 1500 JPY as "1500".
+func Format(minor int64, currency string) string {
+	exp := Exponent(currency)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+
+	// Work on the unsigned magnitude so math.MinInt64 does not overflow.
+	magnitude := uint64(minor)
+	if minor < 0 {
+		magnitude = uint64(-(minor + 1)) + 1
+	}
+	if exp == 0 {
+		return sign + strconv.FormatUint(magnitude, 10)
+	}
+
+	divisor := uint64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, magnitude/divisor, exp, magnitude%divisor)
+}
+
+// isDigits reports whether s only contains ASCII digits. The empty string is accepted.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
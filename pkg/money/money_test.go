@@ -0,0 +1,107 @@
+package money
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     int64
+	}{
+		{"12.5", "SGD", 1250},
+		{"12.50", "SGD", 1250},
+		{"12.500", "SGD", 1250},
+		{" 12.5 ", "SGD", 1250},
+		{"+12.5", "SGD", 1250},
+		{"-12.5", "SGD", -1250},
+		{".5", "SGD", 50},
+		{"5.", "SGD", 500},
+		{"007.05", "SGD", 705},
+		{"0", "SGD", 0},
+		{"-0.00", "SGD", 0},
+		{"1500", "JPY", 1500},
+		{"1500.0", "jpy", 1500},
+		{"1.234", "KWD", 1234},
+		{"1.2345", "CLF", 12345},
+		{"1.25", "XXX", 125},
+		{"92233720368547758.07", "SGD", math.MaxInt64},
+		{"-92233720368547758.07", "SGD", -math.MaxInt64},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.amount, tt.currency)
+		if err != nil {
+			t.Errorf("Parse(%q, %q) returned error: %v", tt.amount, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q, %q) = %d, want %d", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+	}{
+		{"", "SGD"},
+		{"  ", "SGD"},
+		{".", "SGD"},
+		{"-", "SGD"},
+		{"12.505", "SGD"},
+		{"1.5", "JPY"},
+		{"1.2345", "KWD"},
+		{"1e3", "SGD"},
+		{"1,000", "SGD"},
+		{"12.5.0", "SGD"},
+		{"--5", "SGD"},
+		{"+-5", "SGD"},
+		{"NaN", "SGD"},
+		{"92233720368547758.08", "SGD"},
+		{"99999999999999999999", "JPY"},
+	}
+	for _, tt := range tests {
+		if got, err := Parse(tt.amount, tt.currency); err == nil {
+			t.Errorf("Parse(%q, %q) = %d, want an error", tt.amount, tt.currency, got)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{1250, "SGD", "12.50"},
+		{5, "SGD", "0.05"},
+		{-5, "SGD", "-0.05"},
+		{0, "SGD", "0.00"},
+		{1500, "JPY", "1500"},
+		{-1500, "JPY", "-1500"},
+		{1234, "KWD", "1.234"},
+		{-1, "CLF", "-0.0001"},
+		{math.MaxInt64, "SGD", "92233720368547758.07"},
+		{math.MinInt64, "SGD", "-92233720368547758.08"},
+		{math.MinInt64, "JPY", "-9223372036854775808"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.minor, tt.currency); got != tt.want {
+			t.Errorf("Format(%d, %q) = %q, want %q", tt.minor, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	for _, currency := range []string{"SGD", "JPY", "KWD", "CLF"} {
+		for _, minor := range []int64{0, 1, -1, 99, 100, -12345, 1_000_000_007, math.MaxInt64, math.MinInt64 + 1} {
+			got, err := Parse(Format(minor, currency), currency)
+			if err != nil || got != minor {
+				t.Errorf("Parse(Format(%d, %q)) = %d, %v", minor, currency, got, err)
+			}
+		}
+	}
+}
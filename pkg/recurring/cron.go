@@ -0,0 +1,164 @@
+package recurring
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSpec is a parsed cron-like day schedule, see ParseCron.
+type CronSpec struct {
+	days, months, weekdays uint64 // Bit n is set when value n matches
+	anyDay, anyWeekday     bool
+}
+
+// cronField describes the values accepted in one field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names of the values from min, e.g. "jan" for 1
+}
+
+var (
+	dayField     = cronField{name: "day of month", min: 1, max: 31}
+	monthField   = cronField{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	weekdayField = cronField{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat", "sun"}}
+	minuteField  = cronField{name: "minute", min: 0, max: 59}
+	hourField    = cronField{name: "hour", min: 0, max: 23}
+)
+
+// ParseCron parses the day fields of a cron expression: "day-of-month month day-of-week", e.g.
+// "1 * *" (the 1st of every month), "* * mon-fri" or "15 jan,jul *". A full five-field expression
+// is accepted too; transactions are dated rather than timed, so its minute and hour are ignored.
+// Fields take "*", values, ranges ("1-5"), steps ("*/2", "1-31/7") and comma-separated lists.
+// As in cron, a day that matches either a restricted day of month or a restricted day of week matches.
+func ParseCron(expr string) (CronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return CronSpec{}, errNoCron
+	}
+	switch len(fields) {
+	case 3:
+	case 5:
+		if _, _, err := minuteField.parse(fields[0]); err != nil {
+			return CronSpec{}, err
+		}
+		if _, _, err := hourField.parse(fields[1]); err != nil {
+			return CronSpec{}, err
+		}
+		fields = fields[2:]
+	default:
+		return CronSpec{}, fmt.Errorf("invalid cron schedule %q, expected \"day-of-month month day-of-week\"", expr)
+	}
+
+	var spec CronSpec
+	var err error
+	if spec.days, spec.anyDay, err = dayField.parse(fields[0]); err != nil {
+		return CronSpec{}, err
+	}
+	if spec.months, _, err = monthField.parse(fields[1]); err != nil {
+		return CronSpec{}, err
+	}
+	if spec.weekdays, spec.anyWeekday, err = weekdayField.parse(fields[2]); err != nil {
+		return CronSpec{}, err
+	}
+	// Sunday is both 0 and 7.
+	if spec.weekdays&(1<<7) != 0 {
+		spec.weekdays |= 1
+	}
+	return spec, nil
+}
+
+// Matches reports whether the day is on the schedule.
+func (c CronSpec) Matches(day time.Time) bool {
+	if c.months&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	dayMatches := c.days&(1<<uint(day.Day())) != 0
+	weekdayMatches := c.weekdays&(1<<uint(day.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayMatches
+	case c.anyWeekday:
+		return dayMatches
+	default:
+		return dayMatches || weekdayMatches
+	}
+}
+
+// parse returns the set of values matched by the field and whether it is an unrestricted "*".
+func (f cronField) parse(s string) (uint64, bool, error) {
+	var set uint64
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, false, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case rangePart == "*":
+			if !hasStep && s == "*" {
+				return f.all(), true, nil
+			}
+		default:
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = f.value(lowPart); err != nil {
+				return 0, false, err
+			}
+			high = low
+			if isRange {
+				if high, err = f.value(highPart); err != nil {
+					return 0, false, err
+				}
+				if high < low {
+					return 0, false, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+				}
+			} else if hasStep {
+				high = f.max // "5/10" means from 5 every 10
+			}
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, false, nil
+}
+
+// value parses a number or name within the field's bounds.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if s == name {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %d", f.name, f.min, f.max, v)
+	}
+	return v, nil
+}
+
+// all returns the set of every value of the field.
+func (f cronField) all() uint64 {
+	var set uint64
+	for v := f.min; v <= f.max; v++ {
+		set |= 1 << uint(v)
+	}
+	return set
+}
+
+// errNoCron is returned for a cron rule without a schedule.
+var errNoCron = errors.New("cron schedule is required")
@@ -0,0 +1,88 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	d, err := time.Parse(dateFormat, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestCronMatches(t *testing.T) {
+	// 2026-01-04 is a Sunday.
+	tests := []struct {
+		expr string
+		day  string
+		want bool
+	}{
+		{"* * *", "2026-01-05", true},
+		{"*/2 * *", "2026-01-05", true},
+		{"*/2 * *", "2026-01-04", false},
+		{"5/10 * *", "2026-01-15", true},
+		{"5/10 * *", "2026-01-10", false},
+		{"1-5 * *", "2026-01-05", true},
+		{"1-5 * *", "2026-01-06", false},
+		{"1-31/7 * *", "2026-01-08", true},
+		{"1-31/7 * *", "2026-01-07", false},
+		{"1,15 * *", "2026-01-15", true},
+		{"15 jan,jul *", "2026-07-15", true},
+		{"15 jan,jul *", "2026-02-15", false},
+		{"15 JAN *", "2026-01-15", true},
+		{"* * mon-fri", "2026-01-05", true},
+		{"* * mon-fri", "2026-01-04", false},
+		{"* * 0", "2026-01-04", true},
+		{"* * 7", "2026-01-04", true},
+		{"* * sun", "2026-01-04", true},
+		{"* * 5-7", "2026-01-04", true},
+		{"* * 5-7", "2026-01-05", false},
+		{"* * */2", "2026-01-06", true}, // Tuesday
+		{"* * */2", "2026-01-05", false},
+		// A restricted day of month or day of week matches, as in cron.
+		{"13 * fri", "2026-01-13", true},
+		{"13 * fri", "2026-01-02", true},
+		{"13 * fri", "2026-01-14", false},
+		{"13 feb fri", "2026-01-02", false},
+		{"30 2 *", "2026-02-28", false},
+		{"0 9 1 * *", "2026-01-01", true},
+		{"0 9 1 * *", "2026-01-02", false},
+	}
+	for _, tt := range tests {
+		spec, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) returned error: %v", tt.expr, err)
+			continue
+		}
+		if got := spec.Matches(day(tt.day)); got != tt.want {
+			t.Errorf("ParseCron(%q).Matches(%s) = %v, want %v", tt.expr, tt.day, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronRejects(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* *",
+		"* * * *",
+		"0 * *",
+		"32 * *",
+		"* 13 *",
+		"* * 8",
+		"5-1 * *",
+		"*/0 * *",
+		"1/x * *",
+		"x * *",
+		"* * mon-",
+		"1,,2 * *",
+		"60 0 * * *",
+		"0 24 * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) returned no error", expr)
+		}
+	}
+}
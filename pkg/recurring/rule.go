@@ -0,0 +1,188 @@
+package recurring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/pkg/money"
+	"main/pkg/transaction"
+	"strings"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// Frequency is how often a rule recurs.
+type Frequency string
+
+const (
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly" // On the start date's day, or the last day of shorter months
+	Yearly  Frequency = "yearly"
+	Cron    Frequency = "cron" // On the days matching Rule.Cron
+)
+
+// ParseFrequency parses a frequency name such as "monthly".
+func ParseFrequency(s string) (Frequency, error) {
+	switch f := Frequency(strings.ToLower(strings.TrimSpace(s))); f {
+	case Daily, Weekly, Monthly, Yearly, Cron:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown frequency %q, use daily, weekly, monthly, yearly or cron", s)
+	}
+}
+
+// Rule is a transaction that recurs on a schedule, such as rent or a subscription.
+// The scheduler records a transaction for every occurrence from StartDate up to EndDate.
+type Rule struct {
+	ID            int64    `json:"id"`
+	UserID        int64    `json:"-"` // Telegram chat ID of the owner
+	Name          string   `json:"name"`
+	Amount        int64    `json:"-"` // In minor units of Currency, see ruleJSON
+	Currency      string   `json:"currency"`
+	Category      string   `json:"category"`
+	IsClaimable   bool     `json:"isClaimable"`
+	PaidForFamily bool     `json:"paidForFamily"`
+	Tags          []string `json:"tags"`
+
+	Frequency Frequency `json:"frequency"`
+	Interval  int       `json:"interval"`       // Every Interval days, weeks, months or years; not used with Cron
+	Cron      string    `json:"cron,omitempty"` // Cron-like day schedule, see ParseCron
+	StartDate string    `json:"startDate"`      // YYYY-MM-DD, the first occurrence for interval frequencies
+	EndDate   string    `json:"endDate,omitempty"`
+
+	// LastRunDate is the day up to which occurrences have been recorded, empty before the first run.
+	LastRunDate string    `json:"lastRunDate,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Validate checks that the rule can be stored and normalises its dates, tags and defaults.
+func (r *Rule) Validate() error {
+	t := r.Transaction(r.StartDate)
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	r.StartDate, r.Tags = t.Date, t.Tags
+
+	if r.EndDate != "" {
+		end, err := transaction.ParseDate(r.EndDate)
+		if err != nil {
+			return fmt.Errorf("invalid end date: %w", err)
+		}
+		r.EndDate = end.Format(dateFormat)
+		if r.EndDate < r.StartDate {
+			return errors.New("end date is before the start date")
+		}
+	}
+
+	frequency, err := ParseFrequency(string(r.Frequency))
+	if err != nil {
+		return err
+	}
+	r.Frequency = frequency
+	if r.Frequency == Cron {
+		if r.Interval > 1 {
+			return errors.New("interval cannot be combined with a cron schedule")
+		}
+		if _, err = ParseCron(r.Cron); err != nil {
+			return err
+		}
+		r.Interval = 1
+		return nil
+	}
+	if r.Cron != "" {
+		return fmt.Errorf("cron is only used with the %q frequency", Cron)
+	}
+	if r.Interval < 0 {
+		return errors.New("interval must be a positive number")
+	}
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+	return nil
+}
+
+// Transaction returns the transaction the rule records on the date.
+func (r Rule) Transaction(date string) transaction.Transaction {
+	return transaction.Transaction{
+		UserID:          r.UserID,
+		Type:            transaction.TypeExpense,
+		Name:            r.Name,
+		Amount:          r.Amount,
+		Currency:        r.Currency,
+		Date:            date,
+		IsClaimable:     r.IsClaimable,
+		PaidForFamily:   r.PaidForFamily,
+		Category:        r.Category,
+		Tags:            append([]string{}, r.Tags...),
+		RecurringRuleID: r.ID,
+	}
+}
+
+// FormattedAmount renders the amount as a decimal in the rule's currency, e.g. "1500.00".
+func (r Rule) FormattedAmount() string {
+	return money.Format(r.Amount, r.Currency)
+}
+
+// Describe renders the schedule, e.g. "every 2 weeks from 2025-01-06 until 2025-12-31".
+func (r Rule) Describe() string {
+	var text string
+	switch {
+	case r.Frequency == Cron:
+		text = fmt.Sprintf("on days matching %q", r.Cron)
+	case r.Interval > 1:
+		unit := map[Frequency]string{Daily: "days", Weekly: "weeks", Monthly: "months", Yearly: "years"}[r.Frequency]
+		text = fmt.Sprintf("every %d %s", r.Interval, unit)
+	default:
+		text = string(r.Frequency)
+	}
+	text += " from " + r.StartDate
+	if r.EndDate != "" {
+		text += " until " + r.EndDate
+	}
+	return text
+}
+
+// ruleAlias has the fields of Rule without its JSON methods.
+type ruleAlias Rule
+
+// ruleJSON is the wire format of a Rule, with the amount written like a transaction's.
+type ruleJSON struct {
+	*ruleAlias
+	Amount      json.Number `json:"amount"`
+	AmountMinor *int64      `json:"amountMinor,omitempty"`
+}
+
+// MarshalJSON writes the amount losslessly as both a decimal and minor units.
+func (r Rule) MarshalJSON() ([]byte, error) {
+	alias := ruleAlias(r)
+	minor := r.Amount
+	return json.Marshal(ruleJSON{
+		ruleAlias:   &alias,
+		Amount:      json.Number(money.Format(r.Amount, r.Currency)),
+		AmountMinor: &minor,
+	})
+}
+
+// UnmarshalJSON accepts the amount either as "amountMinor" or as a decimal "amount" in the rule's currency.
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	aux := ruleJSON{ruleAlias: (*ruleAlias)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	switch {
+	case aux.AmountMinor != nil:
+		r.Amount = *aux.AmountMinor
+	case aux.Amount != "":
+		amount, err := money.Parse(aux.Amount.String(), r.Currency)
+		if err != nil {
+			return fmt.Errorf("invalid amount: %w", err)
+		}
+		r.Amount = amount
+	default:
+		r.Amount = 0
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package recurring
+
+import (
+	"fmt"
+	"time"
+)
+
+// lookahead bounds the search for the next occurrence, a cron schedule may never match (e.g. "30 2 *").
+const lookahead = 5 * 366 * 24 * time.Hour
+
+// Due returns the occurrences that have not been recorded yet, from the day after LastRunDate
+// (or from StartDate) up to and including the day until (YYYY-MM-DD), oldest first.
+func (r Rule) Due(until string) ([]string, error) {
+	return r.occurrences(r.LastRunDate, until)
+}
+
+// Next returns the first occurrence after the date (YYYY-MM-DD), or false if there is none.
+func (r Rule) Next(after string) (string, bool, error) {
+	from := r.StartDate
+	if after > from {
+		from = after
+	}
+	fromDate, err := time.Parse(dateFormat, from)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid date %q: %w", from, err)
+	}
+	dates, err := r.occurrencesUntil(after, fromDate.Add(lookahead), 1)
+	if err != nil || len(dates) == 0 {
+		return "", false, err
+	}
+	return dates[0], true, nil
+}
+
+// occurrences returns the occurrences after the day after (exclusive, may be empty) up to until (inclusive).
+func (r Rule) occurrences(after, until string) ([]string, error) {
+	limit, err := time.Parse(dateFormat, until)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", until, err)
+	}
+	return r.occurrencesUntil(after, limit, 0)
+}
+
+// occurrencesUntil walks the schedule from the start date, returning at most maxCount occurrences
+// after the day after and on or before limit (and EndDate). maxCount 0 means no maximum.
+func (r Rule) occurrencesUntil(after string, limit time.Time, maxCount int) ([]string, error) {
+	start, err := time.Parse(dateFormat, r.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date %q: %w", r.StartDate, err)
+	}
+	if r.EndDate != "" {
+		end, err := time.Parse(dateFormat, r.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end date %q: %w", r.EndDate, err)
+		}
+		if end.Before(limit) {
+			limit = end
+		}
+	}
+
+	if r.Frequency == Cron {
+		return r.cronOccurrences(start, after, limit, maxCount)
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	var dates []string
+	for n := 0; ; n++ {
+		date, err := r.nth(start, n*interval)
+		if err != nil {
+			return nil, err
+		}
+		if date.After(limit) {
+			return dates, nil
+		}
+		if formatted := date.Format(dateFormat); formatted > after {
+			dates = append(dates, formatted)
+			if maxCount > 0 && len(dates) == maxCount {
+				return dates, nil
+			}
+		}
+	}
+}
+
+// nth returns the occurrence steps days, weeks, months or years after the start date.
+// Monthly and yearly rules keep the start date's day, moving to the last day of shorter months.
+func (r Rule) nth(start time.Time, steps int) (time.Time, error) {
+	switch r.Frequency {
+	case Daily:
+		return start.AddDate(0, 0, steps), nil
+	case Weekly:
+		return start.AddDate(0, 0, 7*steps), nil
+	case Monthly:
+		return addMonths(start, steps), nil
+	case Yearly:
+		return addMonths(start, 12*steps), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown frequency %q", string(r.Frequency))
+	}
+}
+
+// cronOccurrences checks every day from the start date (or the day after after) up to limit.
+func (r Rule) cronOccurrences(start time.Time, after string, limit time.Time, maxCount int) ([]string, error) {
+	spec, err := ParseCron(r.Cron)
+	if err != nil {
+		return nil, err
+	}
+	if after != "" {
+		last, err := time.Parse(dateFormat, after)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", after, err)
+		}
+		if next := last.AddDate(0, 0, 1); next.After(start) {
+			start = next
+		}
+	}
+
+	var dates []string
+	for day := start; !day.After(limit); day = day.AddDate(0, 0, 1) {
+		if spec.Matches(day) {
+			dates = append(dates, day.Format(dateFormat))
+			if maxCount > 0 && len(dates) == maxCount {
+				break
+			}
+		}
+	}
+	return dates, nil
+}
+
+// addMonths adds months to the date, clamping the day to the length of the resulting month
+// (January 31 plus one month is February 28 or 29, not March 3).
+func addMonths(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,137 @@
+package recurring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		date   string
+		months int
+		want   string
+	}{
+		{"2026-01-15", 1, "2026-02-15"},
+		{"2026-01-31", 1, "2026-02-28"},
+		{"2024-01-31", 1, "2024-02-29"},
+		{"2026-01-31", 2, "2026-03-31"},
+		{"2026-01-31", 3, "2026-04-30"},
+		{"2026-03-31", -1, "2026-02-28"},
+		{"2025-12-31", 2, "2026-02-28"},
+		{"2024-02-29", 12, "2025-02-28"},
+		{"2024-02-29", 48, "2028-02-29"},
+	}
+	for _, tt := range tests {
+		if got := addMonths(day(tt.date), tt.months).Format(dateFormat); got != tt.want {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.date, tt.months, got, tt.want)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		until string
+		want  []string
+	}{
+		{
+			"first run",
+			Rule{Frequency: Monthly, Interval: 1, StartDate: "2026-01-31"},
+			"2026-04-30",
+			[]string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30"},
+		},
+		{
+			"catch up after a gap",
+			Rule{Frequency: Monthly, Interval: 1, StartDate: "2026-01-31", LastRunDate: "2026-02-28"},
+			"2026-05-31",
+			[]string{"2026-03-31", "2026-04-30", "2026-05-31"},
+		},
+		{
+			"up to date",
+			Rule{Frequency: Monthly, Interval: 1, StartDate: "2026-01-31", LastRunDate: "2026-02-28"},
+			"2026-03-30",
+			nil,
+		},
+		{
+			"before the start",
+			Rule{Frequency: Daily, Interval: 1, StartDate: "2026-01-10"},
+			"2026-01-09",
+			nil,
+		},
+		{
+			"every 2 weeks",
+			Rule{Frequency: Weekly, Interval: 2, StartDate: "2026-01-05", LastRunDate: "2026-01-05"},
+			"2026-02-10",
+			[]string{"2026-01-19", "2026-02-02"},
+		},
+		{
+			"yearly on February 29",
+			Rule{Frequency: Yearly, Interval: 1, StartDate: "2024-02-29"},
+			"2028-03-01",
+			[]string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+		{
+			"end date",
+			Rule{Frequency: Daily, Interval: 1, StartDate: "2026-01-01", EndDate: "2026-01-03"},
+			"2026-01-10",
+			[]string{"2026-01-01", "2026-01-02", "2026-01-03"},
+		},
+		{
+			"ended",
+			Rule{Frequency: Daily, Interval: 1, StartDate: "2026-01-01", EndDate: "2026-01-03", LastRunDate: "2026-01-03"},
+			"2026-01-10",
+			nil,
+		},
+		{
+			"cron catch up",
+			Rule{Frequency: Cron, Cron: "* * mon", StartDate: "2026-01-01", LastRunDate: "2026-01-05"},
+			"2026-01-20",
+			[]string{"2026-01-12", "2026-01-19"},
+		},
+		{
+			"cron end date",
+			Rule{Frequency: Cron, Cron: "* * mon", StartDate: "2026-01-01", EndDate: "2026-01-18"},
+			"2026-01-31",
+			[]string{"2026-01-05", "2026-01-12"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.rule.Due(tt.until)
+		if err != nil {
+			t.Errorf("%s: Due(%s) returned error: %v", tt.name, tt.until, err)
+			continue
+		}
+		if len(got) != 0 || len(tt.want) != 0 {
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: Due(%s) = %v, want %v", tt.name, tt.until, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		after  string
+		want   string
+		wantOK bool
+	}{
+		{"first", Rule{Frequency: Monthly, Interval: 1, StartDate: "2026-01-31"}, "", "2026-01-31", true},
+		{"short month", Rule{Frequency: Monthly, Interval: 1, StartDate: "2026-01-31"}, "2026-01-31", "2026-02-28", true},
+		{"cron", Rule{Frequency: Cron, Cron: "1 * *", StartDate: "2026-01-01"}, "2026-01-01", "2026-02-01", true},
+		{"ended", Rule{Frequency: Daily, Interval: 1, StartDate: "2026-01-01", EndDate: "2026-01-03"}, "2026-01-03", "", false},
+		{"never", Rule{Frequency: Cron, Cron: "30 2 *", StartDate: "2026-01-01"}, "", "", false},
+	}
+	for _, tt := range tests {
+		got, ok, err := tt.rule.Next(tt.after)
+		if err != nil {
+			t.Errorf("%s: Next(%q) returned error: %v", tt.name, tt.after, err)
+			continue
+		}
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: Next(%q) = %q, %v, want %q, %v", tt.name, tt.after, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
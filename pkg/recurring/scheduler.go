@@ -0,0 +1,108 @@
+package recurring
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"main/pkg/exchange"
+	"main/pkg/transaction"
+	"time"
+)
+
+// DefaultCheckInterval is how often the scheduler looks for due occurrences.
+// Occurrences are dated, so they are recorded within this long after midnight.
+const DefaultCheckInterval = time.Hour
+
+// Store is the persistence the scheduler needs, implemented by storage.Store.
+type Store interface {
+	GetDueRecurringRules(date string) ([]Rule, error)
+	RecordRecurringRun(rule Rule, due []transaction.Transaction, through string) ([]transaction.Transaction, error)
+}
+
+// Notifier tells the owner of a rule about the transactions recorded for it.
+type Notifier func(rule Rule, recorded []transaction.Transaction) error
+
+// Scheduler records the transactions of recurring rules as their occurrences come due.
+// Every run catches up on all occurrences since the rule's last run, so missed days after
+// downtime are recorded once the scheduler runs again, and none is ever recorded twice.
+type Scheduler struct {
+	store    Store
+	rates    *exchange.Rates
+	notify   Notifier
+	interval time.Duration
+}
+
+// NewScheduler creates a scheduler that checks for due occurrences every interval
+// (DefaultCheckInterval if not positive) and reports recorded transactions to notify.
+func NewScheduler(store Store, rates *exchange.Rates, notify Notifier, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	return &Scheduler{store: store, rates: rates, notify: notify, interval: interval}
+}
+
+// Run records due occurrences immediately and then every interval until the context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(time.Now()); err != nil {
+			log.Printf("Error running recurring rules: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce records every occurrence up to the day of now that has not been recorded yet.
+// A rule that fails is logged and retried on the next run, without holding up the other rules.
+func (s *Scheduler) RunOnce(now time.Time) error {
+	today := now.Format(dateFormat)
+	rules, err := s.store.GetDueRecurringRules(today)
+	if err != nil {
+		return fmt.Errorf("failed to get due recurring rules: %w", err)
+	}
+	for _, rule := range rules {
+		if _, err := s.Record(rule, today); err != nil {
+			log.Printf("Error recording recurring rule %d: %v", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+// Record saves the transactions of the rule's occurrences up to the day today, stamped with the
+// exchange rate of their date, and notifies the owner about them. It returns the saved transactions.
+func (s *Scheduler) Record(rule Rule, today string) ([]transaction.Transaction, error) {
+	dates, err := rule.Due(today)
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]transaction.Transaction, 0, len(dates))
+	for _, date := range dates {
+		t := rule.Transaction(date)
+		// A missing rate never blocks recording, the transaction is then converted at report time.
+		if err := s.rates.Stamp(&t); err != nil {
+			log.Printf("Recording recurring rule %d without exchange rate: %v", rule.ID, err)
+		}
+		due = append(due, t)
+	}
+
+	recorded, err := s.store.RecordRecurringRun(rule, due, today)
+	if err != nil {
+		return nil, err
+	}
+	if len(recorded) > 0 {
+		log.Printf("Recorded %d transactions of recurring rule %d", len(recorded), rule.ID)
+		if s.notify != nil {
+			if err := s.notify(rule, recorded); err != nil {
+				log.Printf("Error notifying user %d about recurring rule %d: %v", rule.UserID, rule.ID, err)
+			}
+		}
+	}
+	return recorded, nil
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"fmt"
+	"main/pkg/account"
+	"main/pkg/storage"
+	"sort"
+)
+
+// AccountBalance is the current balance of an account in its currency.
+type AccountBalance struct {
+	Account account.Account `json:"account"`
+	Balance Amount          `json:"balance"`
+	// MissingRates lists the currencies of transactions left out of Balance because they could not be
+	// converted into the account's currency.
+	MissingRates []string `json:"missingRates,omitempty"`
+}
+
+// BalancePoint is the balance of an account at the end of a day on which money moved in or out of it.
+type BalancePoint struct {
+	Date    string `json:"date"`
+	Change  Amount `json:"change"`
+	Balance Amount `json:"balance"`
+}
+
+// BalanceHistory is the running balance of an account over a date range.
+type BalanceHistory struct {
+	Account account.Account `json:"account"`
+	// Opening is the balance before the first day of the range, the opening balance of the account for an open range.
+	Opening Amount         `json:"opening"`
+	Points  []BalancePoint `json:"points"`
+	Closing Amount         `json:"closing"`
+	// MissingRates lists the currencies of transactions left out of the balances, see AccountBalance.
+	MissingRates []string `json:"missingRates,omitempty"`
+}
+
+// AccountBalances returns the current balance of each of the user's accounts, ordered by name.
+func (b *Builder) AccountBalances(userID int64) ([]AccountBalance, error) {
+	accounts, err := b.store.GetAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	balances := make([]AccountBalance, 0, len(accounts))
+	for _, a := range accounts {
+		balance, err := b.accountBalance(a)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// AccountBalance returns the current balance of an account owned by the user, or storage.ErrNotFound.
+func (b *Builder) AccountBalance(userID, id int64) (AccountBalance, error) {
+	a, err := b.store.GetAccount(userID, id)
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	return b.accountBalance(a)
+}
+
+// accountBalance adds up the opening balance and every change of the account.
+func (b *Builder) accountBalance(a account.Account) (AccountBalance, error) {
+	history, err := b.history(a, storage.DateRange{})
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	return AccountBalance{Account: a, Balance: history.Closing, MissingRates: history.MissingRates}, nil
+}
+
+// BalanceHistory returns the running balance of an account owned by the user on the days within the range
+// on which money moved in or out of it, or storage.ErrNotFound.
+func (b *Builder) BalanceHistory(userID, id int64, dateRange storage.DateRange) (BalanceHistory, error) {
+	a, err := b.store.GetAccount(userID, id)
+	if err != nil {
+		return BalanceHistory{}, err
+	}
+	return b.history(a, dateRange)
+}
+
+// history converts the daily changes of the account into its currency and runs the balance through them.
+func (b *Builder) history(a account.Account, dateRange storage.DateRange) (BalanceHistory, error) {
+	totals, err := b.store.GetAccountTotals(a.UserID, a.ID)
+	if err != nil {
+		return BalanceHistory{}, fmt.Errorf("failed to get totals of account %d: %w", a.ID, err)
+	}
+
+	// Totals come oldest first, with one entry per date and currency.
+	changes := make(map[string]int64)
+	var dates []string
+	missing := make(map[string]bool)
+	for _, t := range totals {
+		change, ok := b.accountChange(a.Currency, t)
+		if !ok {
+			missing[t.Currency] = true
+			continue
+		}
+		if _, seen := changes[t.Group]; !seen {
+			dates = append(dates, t.Group)
+		}
+		changes[t.Group] += change
+	}
+
+	balance := a.OpeningBalance
+	history := BalanceHistory{Account: a, Points: []BalancePoint{}}
+	for _, date := range dates {
+		if dateRange.To != "" && date > dateRange.To {
+			break
+		}
+		if dateRange.From != "" && date < dateRange.From {
+			balance += changes[date]
+			continue
+		}
+		if len(history.Points) == 0 {
+			history.Opening = NewAmount(balance, a.Currency)
+		}
+		balance += changes[date]
+		history.Points = append(history.Points, BalancePoint{
+			Date:    date,
+			Change:  NewAmount(changes[date], a.Currency),
+			Balance: NewAmount(balance, a.Currency),
+		})
+	}
+	if len(history.Points) == 0 {
+		history.Opening = NewAmount(balance, a.Currency)
+	}
+	history.Closing = NewAmount(balance, a.Currency)
+	for currency := range missing {
+		history.MissingRates = append(history.MissingRates, currency)
+	}
+	sort.Strings(history.MissingRates)
+	return history, nil
+}
+
+// accountChange returns a total in the account's currency: as is when the currencies match,
+// or else through the recorded conversion or the converter when the account is in the base currency.
+func (b *Builder) accountChange(currency string, t storage.Total) (int64, bool) {
+	if t.Currency == currency {
+		return t.Amount, true
+	}
+	if t.BaseCurrency != "" && t.BaseCurrency == currency {
+		return t.BaseAmount, true
+	}
+	if base := b.converter.BaseCurrency(); base == "" || currency != base {
+		return 0, false
+	}
+	amount, err := b.converter.ToBase(t.Amount, t.Currency)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
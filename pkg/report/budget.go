@@ -0,0 +1,144 @@
+package report
+
+import (
+	"fmt"
+	"main/pkg/budget"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"time"
+)
+
+// BudgetLine compares a category's monthly budget with what was spent in the month.
+type BudgetLine struct {
+	Category  string `json:"category"`
+	Budget    Amount `json:"budget"`
+	Spent     Amount `json:"spent"`
+	Remaining Amount `json:"remaining"` // Negative when over budget
+	Percent   int    `json:"percent"`   // Spent as a share of the budget, rounded down
+	// MissingRates lists the currencies left out of Spent because they have no exchange rate.
+	MissingRates []string `json:"missingRates,omitempty"`
+}
+
+// BudgetAlert reports that a transaction took a category past a share of its budget.
+type BudgetAlert struct {
+	Line      BudgetLine
+	Month     Period
+	Threshold int // budget.WarningPercent or budget.ExceededPercent
+}
+
+// Month returns the calendar month that the period covers exactly, or false for any other period.
+func (p Period) Month() (Period, bool) {
+	first, err := time.Parse(dateFormat, p.From)
+	if err != nil || first.Day() != 1 {
+		return Period{}, false
+	}
+	month := monthPeriod(first.Year(), first.Month())
+	return month, month.To == p.To
+}
+
+// Budgets compares the user's budgets with the spending in the month.
+func (b *Builder) Budgets(userID int64, month Period) ([]BudgetLine, error) {
+	budgets, err := b.store.GetBudgets(userID)
+	if err != nil || len(budgets) == 0 {
+		return nil, err
+	}
+	totals, err := b.store.GetTotals(userID, storage.GroupByCategory, month.DateRange())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category totals: %w", err)
+	}
+
+	lines := make([]BudgetLine, 0, len(budgets))
+	for _, bud := range budgets {
+		lines = append(lines, b.budgetLine(bud, totals))
+	}
+	return lines, nil
+}
+
+// BudgetAlerts returns the budgets of the transaction's category that the transaction took past
+// budget.WarningPercent or budget.ExceededPercent of their amount in the transaction's month.
+// It must be called after the transaction was saved.
+func (b *Builder) BudgetAlerts(userID int64, t transaction.Transaction) ([]BudgetAlert, error) {
+	if t.TransactionType() != transaction.TypeExpense {
+		return nil, nil // Only expenses count towards budgets
+	}
+	date, err := transaction.ParseDate(t.Date)
+	if err != nil {
+		return nil, err
+	}
+	month := monthPeriod(date.Year(), date.Month())
+
+	lines, err := b.Budgets(userID, month)
+	if err != nil {
+		return nil, err
+	}
+	var alerts []BudgetAlert
+	for _, line := range lines {
+		if line.Category != t.Category {
+			continue
+		}
+		contribution, ok := b.budgetContribution(line.Budget.Currency, t)
+		if !ok {
+			continue
+		}
+		before := line.Spent.AmountMinor - contribution
+		for _, threshold := range []int{budget.ExceededPercent, budget.WarningPercent} {
+			limit := line.Budget.AmountMinor * int64(threshold)
+			if before*100 < limit && line.Spent.AmountMinor*100 >= limit {
+				alerts = append(alerts, BudgetAlert{Line: line, Month: month, Threshold: threshold})
+				break
+			}
+		}
+	}
+	return alerts, nil
+}
+
+// budgetLine sums the spending a budget covers: every currency converted into the base currency
+// for a budget in the base currency, or else only the spending in the budget's currency.
+func (b *Builder) budgetLine(bud budget.Budget, totals []storage.Total) BudgetLine {
+	var spent int64
+	var missingRates []string
+	var categoryTotals []storage.Total
+	for _, t := range totals {
+		if t.Group == bud.Category {
+			categoryTotals = append(categoryTotals, t)
+		}
+	}
+	if base := b.converter.BaseCurrency(); base != "" && bud.Currency == base {
+		converted := b.line(bud.Category, categoryTotals)
+		spent, missingRates = converted.Converted.AmountMinor, converted.MissingRates
+	} else {
+		for _, t := range categoryTotals {
+			if t.Currency == bud.Currency {
+				spent += t.Amount
+			}
+		}
+	}
+
+	return BudgetLine{
+		Category:     bud.Category,
+		Budget:       NewAmount(bud.Amount, bud.Currency),
+		Spent:        NewAmount(spent, bud.Currency),
+		Remaining:    NewAmount(bud.Amount-spent, bud.Currency),
+		Percent:      int(spent * 100 / bud.Amount),
+		MissingRates: missingRates,
+	}
+}
+
+// budgetContribution returns how much of a budget in the currency the transaction used,
+// or false if the budget does not cover the transaction.
+func (b *Builder) budgetContribution(currency string, t transaction.Transaction) (int64, bool) {
+	if t.Currency == currency {
+		return t.Amount, true
+	}
+	if base := b.converter.BaseCurrency(); base == "" || currency != base {
+		return 0, false
+	}
+	if t.BaseCurrency == currency {
+		return t.BaseAmount, true
+	}
+	amount, err := b.converter.ToBase(t.Amount, t.Currency)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
@@ -0,0 +1,39 @@
+package report
+
+import (
+	"fmt"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+)
+
+// ClaimTotals splits the claimable spending by how far its reimbursement has got.
+type ClaimTotals struct {
+	Outstanding Line `json:"outstanding"` // Claimable and submitted, not paid back yet
+	Submitted   Line `json:"submitted"`
+	Reimbursed  Line `json:"reimbursed"`
+	Rejected    Line `json:"rejected"`
+}
+
+// Claims sums the user's claimable transactions dated within the period per claim status.
+func (b *Builder) Claims(userID int64, period Period) (ClaimTotals, error) {
+	totals, err := b.store.GetTotals(userID, storage.GroupByClaimStatus, period.DateRange())
+	if err != nil {
+		return ClaimTotals{}, fmt.Errorf("failed to get claim totals: %w", err)
+	}
+
+	byStatus := make(map[transaction.ClaimStatus][]storage.Total)
+	var outstanding []storage.Total
+	for _, t := range totals {
+		status := transaction.ClaimStatus(t.Group)
+		byStatus[status] = append(byStatus[status], t)
+		if status.Outstanding() {
+			outstanding = append(outstanding, t)
+		}
+	}
+	return ClaimTotals{
+		Outstanding: b.line("Outstanding", regroup(outstanding, "Outstanding")),
+		Submitted:   b.line("Submitted", byStatus[transaction.ClaimSubmitted]),
+		Reimbursed:  b.line("Reimbursed", byStatus[transaction.ClaimReimbursed]),
+		Rejected:    b.line("Rejected", byStatus[transaction.ClaimRejected]),
+	}, nil
+}
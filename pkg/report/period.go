@@ -0,0 +1,108 @@
+package report
+
+import (
+	"fmt"
+	"main/pkg/storage"
+	"strings"
+	"time"
+)
+
+// dateFormat is the format of period boundaries and transaction dates.
+const dateFormat = "2006-01-02"
+
+// PeriodUsage describes the accepted period arguments.
+const PeriodUsage = "Periods: 2025-09, 2025, this month, last month, week, last week, year, " +
+	"2025-01-01..2025-03-31, all"
+
+// Period is an inclusive range of days that a summary covers.
+type Period struct {
+	Label string `json:"label"`
+	From  string `json:"from,omitempty"` // YYYY-MM-DD, empty for no lower bound
+	To    string `json:"to,omitempty"`   // YYYY-MM-DD, empty for no upper bound
+}
+
+// DateRange returns the storage filter for the period.
+func (p Period) DateRange() storage.DateRange {
+	return storage.DateRange{From: p.From, To: p.To}
+}
+
+// CurrentMonth is the default summary period.
+func CurrentMonth(now time.Time) Period {
+	return monthPeriod(now.Year(), now.Month())
+}
+
+// ParsePeriod parses a summary period relative to now. The empty string is the current month.
+// Accepted forms are listed in PeriodUsage.
+func ParsePeriod(s string, now time.Time) (Period, error) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+
+	switch s {
+	case "", "month", "this month":
+		return CurrentMonth(now), nil
+	case "last month":
+		lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+		return monthPeriod(lastMonth.Year(), lastMonth.Month()), nil
+	case "week", "this week":
+		return weekPeriod(now, "This week"), nil
+	case "last week":
+		return weekPeriod(now.AddDate(0, 0, -7), "Last week"), nil
+	case "year", "this year":
+		return yearPeriod(now.Year()), nil
+	case "all", "all time":
+		return Period{Label: "All time"}, nil
+	}
+
+	if from, to, ok := strings.Cut(s, ".."); ok {
+		fromDate, err := time.Parse(dateFormat, strings.TrimSpace(from))
+		if err != nil {
+			return Period{}, fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", from)
+		}
+		toDate, err := time.Parse(dateFormat, strings.TrimSpace(to))
+		if err != nil {
+			return Period{}, fmt.Errorf("invalid end date %q, expected YYYY-MM-DD", to)
+		}
+		if toDate.Before(fromDate) {
+			return Period{}, fmt.Errorf("period ends before it starts")
+		}
+		return dayPeriod(fromDate, toDate), nil
+	}
+	if day, err := time.Parse(dateFormat, s); err == nil {
+		return dayPeriod(day, day), nil
+	}
+	if month, err := time.Parse("2006-01", s); err == nil {
+		return monthPeriod(month.Year(), month.Month()), nil
+	}
+	if year, err := time.Parse("2006", s); err == nil {
+		return yearPeriod(year.Year()), nil
+	}
+	return Period{}, fmt.Errorf("unknown period %q", s)
+}
+
+// monthPeriod covers a calendar month, e.g. "September 2025".
+func monthPeriod(year int, month time.Month) Period {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	return Period{Label: first.Format("January 2006"), From: first.Format(dateFormat), To: last.Format(dateFormat)}
+}
+
+// weekPeriod covers the Monday to Sunday week containing the day.
+func weekPeriod(day time.Time, label string) Period {
+	daysSinceMonday := (int(day.Weekday()) + 6) % 7
+	monday := day.AddDate(0, 0, -daysSinceMonday)
+	sunday := monday.AddDate(0, 0, 6)
+	return Period{Label: label, From: monday.Format(dateFormat), To: sunday.Format(dateFormat)}
+}
+
+// yearPeriod covers a calendar year.
+func yearPeriod(year int) Period {
+	return Period{Label: fmt.Sprint(year), From: fmt.Sprintf("%04d-01-01", year), To: fmt.Sprintf("%04d-12-31", year)}
+}
+
+// dayPeriod covers the days from one date to another, inclusive.
+func dayPeriod(from, to time.Time) Period {
+	label := from.Format(dateFormat)
+	if !to.Equal(from) {
+		label += " to " + to.Format(dateFormat)
+	}
+	return Period{Label: label, From: from.Format(dateFormat), To: to.Format(dateFormat)}
+}
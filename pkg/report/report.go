@@ -0,0 +1,260 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/pkg/category"
+	"main/pkg/exchange"
+	"main/pkg/money"
+	"main/pkg/storage"
+	"main/pkg/transaction"
+	"sort"
+	"strings"
+)
+
+// Amount is a sum of money in one currency, written losslessly as a decimal and in minor units.
+type Amount struct {
+	Currency    string      `json:"currency"`
+	Amount      json.Number `json:"amount"`
+	AmountMinor int64       `json:"amountMinor"`
+}
+
+// NewAmount wraps minor units of a currency.
+func NewAmount(minor int64, currency string) Amount {
+	return Amount{Currency: currency, Amount: json.Number(money.Format(minor, currency)), AmountMinor: minor}
+}
+
+// String renders the amount as "12.50 SGD".
+func (a Amount) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", a.Amount, a.Currency))
+}
+
+// Line is the total of one group of transactions, e.g. one category.
+type Line struct {
+	Group string `json:"group"`
+	// Totals has one entry per currency, amounts in different currencies are never added.
+	Totals []Amount `json:"totals"`
+	// Converted is the sum of Totals in the base currency, nil when no base currency is configured.
+	Converted *Amount `json:"converted,omitempty"`
+	// MissingRates lists the currencies left out of Converted because they have no exchange rate.
+	MissingRates []string `json:"missingRates,omitempty"`
+	// Subcategories breaks down the line of a top-level category in Summary.CategoryRollups.
+	Subcategories []Line `json:"subcategories,omitempty"`
+}
+
+// Summary is the spending overview shown by the /summary bot command and the summary API.
+type Summary struct {
+	Period       Period `json:"period"`
+	BaseCurrency string `json:"baseCurrency,omitempty"`
+	Categories   []Line `json:"categories"`
+	// CategoryRollups has one line per top-level category, including the spending in its subcategories.
+	CategoryRollups []Line `json:"categoryRollups"`
+	Claimable       []Line `json:"claimable"`
+	// Claims shows the claimable spending that is outstanding versus reimbursed.
+	Claims        ClaimTotals `json:"claims"`
+	PaidForFamily []Line      `json:"paidForFamily"`
+	// Tags has one line per tag; a transaction with several tags counts towards each of them.
+	Tags  []Line `json:"tags"`
+	Total Line   `json:"total"`
+	// Income, Expense and Net (income minus expenses) cover every category; transfers count as neither.
+	Income  Line `json:"income"`
+	Expense Line `json:"expense"`
+	Net     Line `json:"net"`
+	// Budgets compares the monthly budgets with the spending, only for periods of one calendar month.
+	Budgets []BudgetLine `json:"budgets,omitempty"`
+}
+
+// Builder assembles summaries from a store, converting totals with the converter.
+type Builder struct {
+	store     storage.Store
+	converter exchange.Converter
+}
+
+// NewBuilder creates a summary builder.
+func NewBuilder(store storage.Store, converter exchange.Converter) *Builder {
+	return &Builder{store: store, converter: converter}
+}
+
+// Build computes the user's summary of the transactions dated within the period.
+func (b *Builder) Build(userID int64, period Period) (Summary, error) {
+	summary := Summary{Period: period, BaseCurrency: b.converter.BaseCurrency()}
+	dateRange := period.DateRange()
+
+	categoryTotals, err := b.store.GetTotals(userID, storage.GroupByCategory, dateRange)
+	if err != nil {
+		return Summary{}, fmt.Errorf("failed to get category totals: %w", err)
+	}
+	summary.Categories = b.lines(categoryTotals)
+	if summary.CategoryRollups, err = b.rollups(userID, categoryTotals); err != nil {
+		return Summary{}, err
+	}
+	summary.Total = b.line("Total", regroup(categoryTotals, "Total"))
+
+	typeTotals, err := b.store.GetTotals(userID, storage.GroupByType, dateRange)
+	if err != nil {
+		return Summary{}, fmt.Errorf("failed to get totals per type: %w", err)
+	}
+	summary.Income, summary.Expense, summary.Net = b.cashflow(typeTotals)
+
+	claimableTotals, err := b.store.GetTotals(userID, storage.GroupByIsClaimable, dateRange)
+	if err != nil {
+		return Summary{}, fmt.Errorf("failed to get claimable totals: %w", err)
+	}
+	summary.Claimable = b.lines(claimableTotals)
+
+	if summary.Claims, err = b.Claims(userID, period); err != nil {
+		return Summary{}, err
+	}
+
+	familyTotals, err := b.store.GetTotals(userID, storage.GroupByPaidForFamily, dateRange)
+	if err != nil {
+		return Summary{}, fmt.Errorf("failed to get paid for family totals: %w", err)
+	}
+	summary.PaidForFamily = b.lines(familyTotals)
+
+	tagTotals, err := b.store.GetTotals(userID, storage.GroupByTag, dateRange)
+	if err != nil {
+		return Summary{}, fmt.Errorf("failed to get tag totals: %w", err)
+	}
+	summary.Tags = b.lines(tagTotals)
+
+	if month, ok := period.Month(); ok {
+		if summary.Budgets, err = b.Budgets(userID, month); err != nil {
+			return Summary{}, fmt.Errorf("failed to get budgets: %w", err)
+		}
+	}
+
+	return summary, nil
+}
+
+// lines turns per-group, per-currency totals into one line per group,
+// ordered by converted amount (largest first) and then by name.
+func (b *Builder) lines(totals []storage.Total) []Line {
+	byGroup := make(map[string][]storage.Total)
+	for _, t := range totals {
+		byGroup[t.Group] = append(byGroup[t.Group], t)
+	}
+
+	lines := make([]Line, 0, len(byGroup))
+	for group, groupTotals := range byGroup {
+		lines = append(lines, b.line(group, groupTotals))
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		ci, cj := convertedMinor(lines[i]), convertedMinor(lines[j])
+		if ci != cj {
+			return ci > cj
+		}
+		return lines[i].Group < lines[j].Group
+	})
+	return lines
+}
+
+// rollups sums the category totals per top-level category, with a line for each subcategory that has spending.
+// Categories the user does not have (any more) are listed as top-level categories.
+func (b *Builder) rollups(userID int64, totals []storage.Total) ([]Line, error) {
+	categories, err := b.store.GetCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	parents := category.Parents(categories)
+
+	rolledUp := make([]storage.Total, 0, len(totals))
+	subcategories := make(map[string][]storage.Total)
+	for _, t := range totals {
+		if parent, ok := parents[t.Group]; ok {
+			subcategories[parent] = append(subcategories[parent], t)
+			t.Group = parent
+		}
+		rolledUp = append(rolledUp, t)
+	}
+	lines := b.lines(rolledUp)
+	for i := range lines {
+		if children := subcategories[lines[i].Group]; len(children) > 0 {
+			lines[i].Subcategories = b.lines(children)
+		}
+	}
+	return lines, nil
+}
+
+// line sums one group's totals per currency and converts them into the base currency.
+// Amounts that were converted when they were saved keep their recorded base amount,
+// the rest is converted with the converter's current rate.
+func (b *Builder) line(group string, totals []storage.Total) Line {
+	base := b.converter.BaseCurrency()
+	byCurrency := make(map[string]int64)
+	unconverted := make(map[string]int64)
+	var converted int64
+	for _, t := range totals {
+		byCurrency[t.Currency] += t.Amount
+		if base != "" && t.BaseCurrency == base {
+			converted += t.BaseAmount
+		} else {
+			unconverted[t.Currency] += t.Amount
+		}
+	}
+	currencies := make([]string, 0, len(byCurrency))
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	line := Line{Group: group, Totals: make([]Amount, 0, len(currencies))}
+	for _, currency := range currencies {
+		line.Totals = append(line.Totals, NewAmount(byCurrency[currency], currency))
+		remaining, ok := unconverted[currency]
+		if base == "" || !ok {
+			continue
+		}
+		amount, err := b.converter.ToBase(remaining, currency)
+		if err != nil {
+			line.MissingRates = append(line.MissingRates, currency)
+			continue
+		}
+		converted += amount
+	}
+	if base != "" {
+		amount := NewAmount(converted, base)
+		line.Converted = &amount
+	}
+	return line
+}
+
+// cashflow sums the income, the expenses and their difference from totals grouped by type.
+func (b *Builder) cashflow(totals []storage.Total) (income, expense, net Line) {
+	var incomeTotals, expenseTotals, netTotals []storage.Total
+	for _, t := range totals {
+		switch transaction.Type(t.Group) {
+		case transaction.TypeIncome:
+			incomeTotals = append(incomeTotals, t)
+		case transaction.TypeExpense:
+			expenseTotals = append(expenseTotals, t)
+			t.Amount, t.BaseAmount = -t.Amount, -t.BaseAmount
+		default:
+			continue
+		}
+		t.Group = "Net"
+		netTotals = append(netTotals, t)
+	}
+	return b.line("Income", regroup(incomeTotals, "Income")), b.line("Expense", regroup(expenseTotals, "Expense")), b.line("Net", netTotals)
+}
+
+// regroup relabels totals so they are summed into a single group.
+func regroup(totals []storage.Total, group string) []storage.Total {
+	regrouped := make([]storage.Total, len(totals))
+	for i, t := range totals {
+		t.Group = group
+		regrouped[i] = t
+	}
+	return regrouped
+}
+
+// convertedMinor is used to order lines; without conversion the first currency total is used.
+func convertedMinor(l Line) int64 {
+	if l.Converted != nil {
+		return l.Converted.AmountMinor
+	}
+	if len(l.Totals) > 0 {
+		return l.Totals[0].AmountMinor
+	}
+	return 0
+}
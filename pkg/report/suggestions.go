@@ -0,0 +1,25 @@
+package report
+
+import (
+	"fmt"
+	"main/pkg/config"
+	"main/pkg/frequent"
+	"time"
+)
+
+// Suggestions returns the name, category and currency combinations the user spent on at least cfg.MinCount times
+// in the cfg.LookbackDays before today, most frequent first, leaving out the names of saved frequent expenses.
+func (b *Builder) Suggestions(userID int64, cfg config.SuggestionsConfig, today time.Time) ([]frequent.Suggestion, error) {
+	cfg = cfg.WithDefaults()
+	since := today.AddDate(0, 0, -cfg.LookbackDays).Format(dateFormat)
+
+	history, err := b.store.GetExpenseHistory(userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense history: %w", err)
+	}
+	saved, err := b.store.GetFrequentExpenses(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get frequent expenses: %w", err)
+	}
+	return frequent.Suggest(history, saved, cfg.MinCount, cfg.Limit), nil
+}
@@ -1,23 +1,32 @@
 package session
 
+// The currency is asked before the amount, because the amount is parsed with the currency's
+// number of decimals (none for JPY, three for KWD).
 const (
 	QuestionName = iota
-	QuestionAmount
 	QuestionCurrency
+	QuestionAmount
 	QuestionDate
 	QuestionIsClaimable
 	QuestionPaidForFamily
 	QuestionCategory
+	QuestionAccount // Only asked when the user has accounts
+	QuestionTags
 	QuestionCount // Should be last; represents the total number of questions
 )
 
 // Questions array for the process
 var Questions = []string{
 	"What is the name of the transaction?",
-	"How much is the transaction?",
 	"What currency is the transaction in?",
+	"How much is the transaction?",
 	"What is the date of transaction? \\(i\\.e\\., DD\\.MM\\.YY\\)", // Added format hint
 	"Is it claimable? \\(yes/no\\)",                                 // Added format hint
 	"Is it paid for the family? \\(yes/no\\)",                       // Added format hint
 	"What is the category of transaction?",
+	"Which account was it paid from, or into? Tap one, or Skip\\.",
+	"Any tags? Send them separated by commas, e\\.g\\. `japan-trip-2025, wedding`, or tap Skip\\.",
 }
+
+// SkipAnswer leaves an optional question unanswered.
+const SkipAnswer = "skip"
@@ -1,8 +1,8 @@
 package session
 
-import "main/pkg/config"
+import "main/pkg/frequent"
 
-func CheckPreFilledExpense(transactionName string, preFilledExpenses []config.FrequentExpense) *config.FrequentExpense {
+func CheckPreFilledExpense(transactionName string, preFilledExpenses []frequent.Expense) *frequent.Expense {
 	for i := 0; i < len(preFilledExpenses); i++ {
 		if preFilledExpenses[i].Name == transactionName {
 			return &preFilledExpenses[i]
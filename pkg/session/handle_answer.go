@@ -3,6 +3,7 @@ package session
 import (
 	"fmt"
 	"main/pkg/transaction"
+	"strings"
 )
 
 // HandleAnswer processes the user's answer, updates the session,
@@ -13,15 +14,18 @@ func (s *UserSession) HandleAnswer(answer string) error {
 	switch s.CurrentQuestion {
 	case QuestionName:
 		s.Answers.Name = answer
+	case QuestionCurrency:
+		// TODO: Consider adding validation for currency (e.g., check if 'answer' is in 'Currencies' list)
+		s.Answers.Currency = answer
 	case QuestionAmount:
-		s.Answers.Amount, err = transaction.ValidateAmount(answer)
+		s.Answers.Amount, err = transaction.ValidateAmount(answer, s.Answers.Currency)
 		if err != nil {
 			// Return a user-friendly error message
 			return fmt.Errorf("invalid amount: %w. Please enter a valid number", err)
 		}
-	case QuestionCurrency:
-		// TODO: Consider adding validation for currency (e.g., check if 'answer' is in 'Currencies' list)
-		s.Answers.Currency = answer
+		if s.Answers.Amount <= 0 {
+			return fmt.Errorf("invalid amount: must be greater than zero")
+		}
 	case QuestionDate:
 		s.Answers.Date = transaction.ProcessDate(answer)
 	case QuestionIsClaimable:
@@ -37,6 +41,23 @@ func (s *UserSession) HandleAnswer(answer string) error {
 	case QuestionCategory:
 		// TODO: Consider adding validation for category (e.g., check if 'answer' is in 'TransactionCategory' list)
 		s.Answers.Category = answer
+	case QuestionAccount:
+		s.Answers.AccountID = 0
+		if !strings.EqualFold(strings.TrimSpace(answer), SkipAnswer) {
+			a, ok := s.AccountByName(answer)
+			if !ok {
+				return fmt.Errorf("unknown account %q, tap one of the buttons or Skip", answer)
+			}
+			s.Answers.AccountID = a.ID
+		}
+	case QuestionTags:
+		s.Answers.Tags = nil
+		if !strings.EqualFold(strings.TrimSpace(answer), SkipAnswer) {
+			s.Answers.Tags, err = transaction.ParseTags(answer)
+			if err != nil {
+				return fmt.Errorf("invalid tags: %w", err)
+			}
+		}
 	default:
 		// This state should ideally not be reached if IsSessionComplete is checked before calling HandleAnswer.
 		return fmt.Errorf("invalid question number: %d", s.CurrentQuestion)
@@ -50,6 +71,7 @@ func (s *UserSession) HandleAnswer(answer string) error {
 
 	// Advance to what would normally be the next question index.
 	s.CurrentQuestion++
+	s.SkipInapplicable()
 
 	return nil
 }
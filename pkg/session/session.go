@@ -1,7 +1,10 @@
 package session
 
 import (
+	"main/pkg/account"
+	"main/pkg/frequent"
 	"main/pkg/transaction"
+	"strings"
 )
 
 // UserSession represents a user's Q&A session.
@@ -9,6 +12,19 @@ type UserSession struct {
 	CurrentQuestion       int
 	Answers               transaction.Transaction // Assuming this struct has Name, Amount, Category etc.
 	LastQuestionMessageID int
+	PendingAttachments    []PendingAttachment   // Files sent during the session, saved with the transaction
+	Accounts              []account.Account     // The user's accounts offered by QuestionAccount
+	FrequentExpenses      []frequent.Expense    // Offered by QuestionName, then filling in the rest
+	Suggestions           []frequent.Suggestion // Suggested from the user's history, offered like FrequentExpenses
+	DuplicateOf           int64                 // Saved transaction the completed one looks like, until the user decides
+	DuplicateConfirmed    bool                  // The user chose to save the transaction despite DuplicateOf
+}
+
+// PendingAttachment is a Telegram file waiting for its transaction to be saved.
+type PendingAttachment struct {
+	FileID   string
+	FileName string
+	FileSize int64 // As reported by Telegram, 0 if unknown
 }
 
 // NewUserSession creates a new user session.
@@ -20,6 +36,73 @@ func NewUserSession() *UserSession {
 	}
 }
 
+// NewTypedSession creates a new user session recording a transaction of the type.
+func NewTypedSession(t transaction.Type) *UserSession {
+	s := NewUserSession()
+	s.Answers.Type = t
+	return s
+}
+
+// SkipInapplicable moves past the questions that do not apply: only expenses can be claimable
+// or paid for the family, and the account is only asked when the user has accounts and none was prefilled.
+func (s *UserSession) SkipInapplicable() {
+	for s.inapplicable(s.CurrentQuestion) {
+		s.CurrentQuestion++
+	}
+}
+
+// inapplicable reports whether the question does not apply to the session's transaction.
+func (s *UserSession) inapplicable(question int) bool {
+	switch question {
+	case QuestionIsClaimable, QuestionPaidForFamily:
+		return s.Answers.TransactionType() != transaction.TypeExpense
+	case QuestionAccount:
+		return len(s.Accounts) == 0 || s.Answers.AccountID != 0
+	default:
+		return false
+	}
+}
+
+// PreFilledExpenses returns the frequent expenses a name picked at QuestionName can fill in,
+// the saved ones before the suggested ones.
+func (s *UserSession) PreFilledExpenses() []frequent.Expense {
+	expenses := append([]frequent.Expense{}, s.FrequentExpenses...)
+	for _, suggestion := range s.Suggestions {
+		expenses = append(expenses, suggestion.Expense())
+	}
+	return expenses
+}
+
+// TypicalAmount returns the typical amount of the suggestion with the session's name and currency.
+func (s *UserSession) TypicalAmount() (int64, bool) {
+	for _, suggestion := range s.Suggestions {
+		if strings.EqualFold(suggestion.Name, s.Answers.Name) && suggestion.Currency == s.Answers.Currency {
+			return suggestion.TypicalAmount, true
+		}
+	}
+	return 0, false
+}
+
+// AccountByName returns the user's account with the name, ignoring case.
+func (s *UserSession) AccountByName(name string) (account.Account, bool) {
+	for _, a := range s.Accounts {
+		if strings.EqualFold(a.Name, strings.TrimSpace(name)) {
+			return a, true
+		}
+	}
+	return account.Account{}, false
+}
+
+// AccountName returns the name of the user's account with the ID, "" if there is none.
+func (s *UserSession) AccountName(id int64) string {
+	for _, a := range s.Accounts {
+		if a.ID == id {
+			return a.Name
+		}
+	}
+	return ""
+}
+
 // IsSessionComplete checks if the session is complete.
 func (s *UserSession) IsSessionComplete() bool {
 	return s.CurrentQuestion >= QuestionCount
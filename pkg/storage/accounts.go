@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/account"
+	"main/pkg/transaction"
+	"time"
+)
+
+var (
+	// ErrAccountExists is returned when an account would duplicate the name of another one of the user's accounts.
+	ErrAccountExists = errors.New("an account with this name already exists")
+
+	// ErrAccountNotFound is returned when a transaction refers to an account the user does not have.
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+// accountColumns lists the account columns read by scanAccount, in order.
+const accountColumns = `id, user_id, name, type, currency, opening_balance_minor, created_at`
+
+// scanAccount reads a row selected with accountColumns.
+func scanAccount(row rowScanner) (account.Account, error) {
+	var a account.Account
+	err := row.Scan(&a.ID, &a.UserID, &a.Name, &a.Type, &a.Currency, &a.OpeningBalance, &a.CreatedAt)
+	return a, err
+}
+
+// InsertAccount saves a new account and returns its ID, or ErrAccountExists if the user already has an account with the name.
+func (s *SQLStore) InsertAccount(a account.Account) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	var id int64
+	err = checkAccountName(tx, a)
+	if err == nil {
+		err = tx.QueryRow(`
+            INSERT INTO accounts (user_id, name, type, currency, opening_balance_minor)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id;
+        `, a.UserID, a.Name, string(a.Type), a.Currency, a.OpeningBalance).Scan(&id)
+		if err != nil {
+			log.Printf("Error inserting account %s: %v", a.Name, err)
+			err = fmt.Errorf("database insert of account failed: %w", err)
+		}
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit account insert: %w", err)
+	}
+
+	log.Printf("Successfully inserted account with ID: %d", id)
+	return id, nil
+}
+
+// UpdateAccount overwrites an account owned by a.UserID. It returns ErrNotFound if the account does not exist
+// and ErrAccountExists if the user already has another account with the new name.
+func (s *SQLStore) UpdateAccount(a account.Account) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	err = checkAccountName(tx, a)
+	if err == nil {
+		var result sql.Result
+		result, err = tx.Exec(`
+            UPDATE accounts SET name = $1, type = $2, currency = $3, opening_balance_minor = $4 WHERE id = $5 AND user_id = $6
+        `, a.Name, string(a.Type), a.Currency, a.OpeningBalance, a.ID, a.UserID)
+		if err != nil {
+			log.Printf("Error updating account %d: %v", a.ID, err)
+			err = fmt.Errorf("database update of account failed: %w", err)
+		} else {
+			err = expectAffected(result)
+		}
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account update: %w", err)
+	}
+
+	log.Printf("Successfully updated account with ID: %d", a.ID)
+	return nil
+}
+
+// checkAccountName returns ErrAccountExists if another of the user's accounts has the name of a.
+func checkAccountName(tx *sql.Tx, a account.Account) error {
+	var otherID int64
+	err := tx.QueryRow(`SELECT id FROM accounts WHERE user_id = $1 AND name = $2 AND id <> $3`, a.UserID, a.Name, a.ID).Scan(&otherID)
+	if err == nil {
+		return ErrAccountExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error checking account %s for duplicates: %v", a.Name, err)
+		return fmt.Errorf("database query for accounts failed: %w", err)
+	}
+	return nil
+}
+
+// checkAccounts returns ErrAccountNotFound unless the accounts of the transaction are owned by its user.
+func checkAccounts(tx *sql.Tx, t transaction.Transaction) error {
+	for _, id := range []int64{t.AccountID, t.ToAccountID} {
+		if id == 0 {
+			continue
+		}
+		var found int64
+		err := tx.QueryRow(`SELECT id FROM accounts WHERE id = $1 AND user_id = $2`, id, t.UserID).Scan(&found)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %d", ErrAccountNotFound, id)
+		}
+		if err != nil {
+			log.Printf("Error checking account %d: %v", id, err)
+			return fmt.Errorf("database query for account failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteAccount removes an account owned by the user, or returns ErrNotFound.
+// Its transactions and frequent expenses are kept and no longer linked to it.
+func (s *SQLStore) DeleteAccount(userID, id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM accounts WHERE id = $1 AND user_id = $2`, id, userID)
+	if err == nil {
+		err = expectAffected(result)
+	}
+	if err == nil {
+		_, err = tx.Exec(`UPDATE transactions SET account_id = NULL WHERE account_id = $1`, id)
+	}
+	if err == nil {
+		_, err = tx.Exec(`UPDATE transactions SET to_account_id = NULL WHERE to_account_id = $1`, id)
+	}
+	if err == nil {
+		_, err = tx.Exec(`UPDATE frequent_expenses SET account_id = NULL WHERE account_id = $1`, id)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, ErrNotFound) {
+			return err
+		}
+		log.Printf("Error deleting account %d: %v", id, err)
+		return fmt.Errorf("database delete of account failed: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account delete: %w", err)
+	}
+
+	log.Printf("Successfully deleted account with ID: %d", id)
+	return nil
+}
+
+// GetAccount returns an account owned by the user, or ErrNotFound.
+func (s *SQLStore) GetAccount(userID, id int64) (account.Account, error) {
+	querySQL := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1 AND user_id = $2`
+
+	a, err := scanAccount(s.db.QueryRow(querySQL, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return account.Account{}, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error querying account %d: %v", id, err)
+		return account.Account{}, fmt.Errorf("database query for account failed: %w", err)
+	}
+	return a, nil
+}
+
+// GetAccounts returns the user's accounts ordered by name.
+func (s *SQLStore) GetAccounts(userID int64) ([]account.Account, error) {
+	querySQL := `SELECT ` + accountColumns + ` FROM accounts WHERE user_id = $1 ORDER BY name`
+
+	rows, err := s.db.Query(querySQL, userID)
+	if err != nil {
+		log.Printf("Error querying accounts: %v", err)
+		return nil, fmt.Errorf("database query for accounts failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for accounts: %v", err)
+		}
+	}(rows)
+
+	accounts := []account.Account{}
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			log.Printf("Error scanning account row: %v", err)
+			return nil, fmt.Errorf("failed to scan account row: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating account rows: %v", err)
+		return nil, fmt.Errorf("error during account row iteration: %w", err)
+	}
+	return accounts, nil
+}
+
+// GetAccountTotals returns the daily net change of an account owned by the user per currency, oldest first,
+// with Group set to the date (YYYY-MM-DD). Income and transfers into the account count as positive,
+// expenses and transfers out of it as negative.
+func (s *SQLStore) GetAccountTotals(userID, accountID int64) ([]Total, error) {
+	// A transfer cannot move money into its own account, so to_account_id decides the direction.
+	querySQL := `
+        SELECT
+            date,
+            currency,
+            SUM(CASE WHEN to_account_id = $2 OR type = $3 THEN amount_minor ELSE -amount_minor END),
+            base_currency,
+            SUM(CASE WHEN to_account_id = $2 OR type = $3 THEN base_amount_minor ELSE -base_amount_minor END)
+        FROM transactions
+        WHERE user_id = $1 AND (account_id = $2 OR to_account_id = $2)
+        GROUP BY date, currency, base_currency
+        ORDER BY date;
+    `
+	rows, err := s.db.Query(querySQL, userID, accountID, string(transaction.TypeIncome))
+	if err != nil {
+		log.Printf("Error querying totals of account %d: %v", accountID, err)
+		return nil, fmt.Errorf("database query for account totals failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for account totals: %v", err)
+		}
+	}(rows)
+
+	var totals []Total
+	for rows.Next() {
+		var date time.Time
+		var baseCurrency sql.NullString
+		var amount, baseAmount sql.NullInt64
+		var total Total
+		if err := rows.Scan(&date, &total.Currency, &amount, &baseCurrency, &baseAmount); err != nil {
+			log.Printf("Error scanning account total row: %v", err)
+			return nil, fmt.Errorf("failed to scan account total row: %w", err)
+		}
+		total.Group = date.Format("2006-01-02")
+		total.Amount, total.BaseCurrency, total.BaseAmount = amount.Int64, baseCurrency.String, baseAmount.Int64
+		totals = append(totals, total)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating account total rows: %v", err)
+		return nil, fmt.Errorf("error during account total row iteration: %w", err)
+	}
+	return totals, nil
+}
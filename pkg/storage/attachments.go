@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/transaction"
+)
+
+// attachmentColumns lists the attachment columns read by scanAttachment, in order.
+const attachmentColumns = `id, transaction_id, user_id, file_name, content_type, size_bytes, blob_key, created_at`
+
+// scanAttachment reads a row selected with attachmentColumns.
+func scanAttachment(row rowScanner) (transaction.Attachment, error) {
+	var a transaction.Attachment
+	err := row.Scan(&a.ID, &a.TransactionID, &a.UserID, &a.FileName, &a.ContentType, &a.Size, &a.BlobKey, &a.CreatedAt)
+	return a, err
+}
+
+// InsertAttachment links an attachment to a transaction owned by a.UserID and returns its ID.
+// It returns ErrNotFound if the transaction does not exist or belongs to someone else.
+func (s *SQLStore) InsertAttachment(a transaction.Attachment) (int64, error) {
+	insertSQL := `
+        INSERT INTO attachments (transaction_id, user_id, file_name, content_type, size_bytes, blob_key)
+        SELECT id, user_id, $3, $4, $5, $6 FROM transactions WHERE id = $1 AND user_id = $2
+        RETURNING id;
+    `
+	var insertedID int64
+	err := s.db.QueryRow(insertSQL, a.TransactionID, a.UserID, a.FileName, a.ContentType, a.Size, a.BlobKey).Scan(&insertedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error inserting attachment for transaction %d: %v", a.TransactionID, err)
+		return 0, fmt.Errorf("database insert of attachment failed: %w", err)
+	}
+
+	log.Printf("Successfully inserted attachment %d for transaction %d", insertedID, a.TransactionID)
+	return insertedID, nil
+}
+
+// GetAttachments returns the attachments of a transaction owned by the user, oldest first.
+func (s *SQLStore) GetAttachments(userID, transactionID int64) ([]transaction.Attachment, error) {
+	querySQL := `SELECT ` + attachmentColumns + ` FROM attachments WHERE transaction_id = $1 AND user_id = $2 ORDER BY id`
+
+	rows, err := s.db.Query(querySQL, transactionID, userID)
+	if err != nil {
+		log.Printf("Error querying attachments of transaction %d: %v", transactionID, err)
+		return nil, fmt.Errorf("database query for attachments failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for attachments: %v", err)
+		}
+	}(rows)
+
+	attachments := []transaction.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			log.Printf("Error scanning attachment row: %v", err)
+			return nil, fmt.Errorf("failed to scan attachment row: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating attachment rows: %v", err)
+		return nil, fmt.Errorf("error during attachment row iteration: %w", err)
+	}
+	return attachments, nil
+}
+
+// GetAttachment returns one attachment of a transaction owned by the user, or ErrNotFound.
+func (s *SQLStore) GetAttachment(userID, transactionID, id int64) (transaction.Attachment, error) {
+	querySQL := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1 AND transaction_id = $2 AND user_id = $3`
+
+	a, err := scanAttachment(s.db.QueryRow(querySQL, id, transactionID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return transaction.Attachment{}, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error querying attachment %d: %v", id, err)
+		return transaction.Attachment{}, fmt.Errorf("database query for attachment failed: %w", err)
+	}
+	return a, nil
+}
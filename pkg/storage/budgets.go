@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/budget"
+)
+
+// ErrBudgetExists is returned when a budget would duplicate the category and currency of another one.
+var ErrBudgetExists = errors.New("a budget for this category and currency already exists")
+
+// budgetColumns lists the budget columns read by scanBudget, in order.
+const budgetColumns = `id, user_id, category, currency, amount_minor, created_at`
+
+// scanBudget reads a row selected with budgetColumns.
+func scanBudget(row rowScanner) (budget.Budget, error) {
+	var b budget.Budget
+	err := row.Scan(&b.ID, &b.UserID, &b.Category, &b.Currency, &b.Amount, &b.CreatedAt)
+	return b, err
+}
+
+// SetBudget creates the user's budget for the category and currency, or replaces its amount
+// if it already exists, and returns its ID.
+func (s *SQLStore) SetBudget(b budget.Budget) (int64, error) {
+	upsertSQL := `
+        INSERT INTO budgets (user_id, category, currency, amount_minor)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_id, category, currency) DO UPDATE SET amount_minor = excluded.amount_minor
+        RETURNING id;
+    `
+	var id int64
+	if err := s.db.QueryRow(upsertSQL, b.UserID, b.Category, b.Currency, b.Amount).Scan(&id); err != nil {
+		log.Printf("Error saving budget for %s: %v", b.Category, err)
+		return 0, fmt.Errorf("database upsert of budget failed: %w", err)
+	}
+
+	log.Printf("Successfully saved budget with ID: %d", id)
+	return id, nil
+}
+
+// UpdateBudget overwrites a budget owned by b.UserID. It returns ErrNotFound if the budget does not exist
+// and ErrBudgetExists if the user already has another budget for the new category and currency.
+func (s *SQLStore) UpdateBudget(b budget.Budget) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	if err = updateBudget(tx, b); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit budget update: %w", err)
+	}
+
+	log.Printf("Successfully updated budget with ID: %d", b.ID)
+	return nil
+}
+
+// updateBudget checks for a conflicting budget and updates the budget within a database transaction.
+func updateBudget(tx *sql.Tx, b budget.Budget) error {
+	var otherID int64
+	err := tx.QueryRow(`
+        SELECT id FROM budgets WHERE user_id = $1 AND category = $2 AND currency = $3 AND id <> $4
+    `, b.UserID, b.Category, b.Currency, b.ID).Scan(&otherID)
+	if err == nil {
+		return ErrBudgetExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error checking budget %d for duplicates: %v", b.ID, err)
+		return fmt.Errorf("database query for budgets failed: %w", err)
+	}
+
+	result, err := tx.Exec(`
+        UPDATE budgets SET category = $1, currency = $2, amount_minor = $3 WHERE id = $4 AND user_id = $5
+    `, b.Category, b.Currency, b.Amount, b.ID, b.UserID)
+	if err != nil {
+		log.Printf("Error updating budget %d: %v", b.ID, err)
+		return fmt.Errorf("database update of budget failed: %w", err)
+	}
+	return expectAffected(result)
+}
+
+// DeleteBudget removes a budget owned by the user, or returns ErrNotFound.
+func (s *SQLStore) DeleteBudget(userID, id int64) error {
+	result, err := s.db.Exec(`DELETE FROM budgets WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Printf("Error deleting budget %d: %v", id, err)
+		return fmt.Errorf("database delete of budget failed: %w", err)
+	}
+	if err = expectAffected(result); err != nil {
+		return err
+	}
+
+	log.Printf("Successfully deleted budget with ID: %d", id)
+	return nil
+}
+
+// GetBudget returns a budget owned by the user, or ErrNotFound.
+func (s *SQLStore) GetBudget(userID, id int64) (budget.Budget, error) {
+	querySQL := `SELECT ` + budgetColumns + ` FROM budgets WHERE id = $1 AND user_id = $2`
+
+	b, err := scanBudget(s.db.QueryRow(querySQL, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return budget.Budget{}, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error querying budget %d: %v", id, err)
+		return budget.Budget{}, fmt.Errorf("database query for budget failed: %w", err)
+	}
+	return b, nil
+}
+
+// GetBudgets returns the user's budgets ordered by category and currency.
+func (s *SQLStore) GetBudgets(userID int64) ([]budget.Budget, error) {
+	querySQL := `SELECT ` + budgetColumns + ` FROM budgets WHERE user_id = $1 ORDER BY category, currency`
+
+	rows, err := s.db.Query(querySQL, userID)
+	if err != nil {
+		log.Printf("Error querying budgets: %v", err)
+		return nil, fmt.Errorf("database query for budgets failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for budgets: %v", err)
+		}
+	}(rows)
+
+	budgets := []budget.Budget{}
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			log.Printf("Error scanning budget row: %v", err)
+			return nil, fmt.Errorf("failed to scan budget row: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating budget rows: %v", err)
+		return nil, fmt.Errorf("error during budget row iteration: %w", err)
+	}
+	return budgets, nil
+}
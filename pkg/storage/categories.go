@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/category"
+	"strings"
+)
+
+var (
+	// ErrCategoryExists is returned when a category would duplicate the name of another one of the user's categories.
+	ErrCategoryExists = errors.New("a category with this name already exists")
+
+	// ErrInvalidParent is returned when a category cannot be placed under the requested parent.
+	ErrInvalidParent = errors.New("invalid parent category")
+)
+
+// categoryColumns lists the category columns read by scanCategory, in order.
+const categoryColumns = `id, user_id, name, type, parent_id, created_at`
+
+// scanCategory reads a row selected with categoryColumns.
+func scanCategory(row rowScanner) (category.Category, error) {
+	var c category.Category
+	var parentID sql.NullInt64 // NULL for top-level categories
+	err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.Type, &parentID, &c.CreatedAt)
+	c.ParentID = parentID.Int64
+	return c, err
+}
+
+// SeedCategories creates the seed categories for a user who has no categories yet, parents before their subcategories.
+func (s *SQLStore) SeedCategories(userID int64, seeds []category.Seed) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	seeded, err := seedCategories(tx, userID, seeds)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Printf("Error seeding categories of user %d: %v", userID, err)
+		return fmt.Errorf("database insert of seed categories failed: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit seed categories: %w", err)
+	}
+
+	if seeded > 0 {
+		log.Printf("Successfully seeded %d categories for user %d", seeded, userID)
+	}
+	return nil
+}
+
+// seedCategories inserts the seeds within a database transaction unless the user has categories,
+// and returns the number of categories created.
+func seedCategories(tx *sql.Tx, userID int64, seeds []category.Seed) (int, error) {
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM categories WHERE user_id = $1`, userID).Scan(&existing); err != nil {
+		return 0, err
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+	ids := make(map[string]int64, len(seeds))
+	for _, seed := range seeds {
+		parentID := ids[strings.ToLower(seed.Parent)]
+		var id int64
+		err := tx.QueryRow(`INSERT INTO categories (user_id, name, type, parent_id) VALUES ($1, $2, $3, $4) RETURNING id`,
+			userID, seed.Name, string(seed.Type), nullID(parentID)).Scan(&id)
+		if err != nil {
+			return 0, err
+		}
+		ids[strings.ToLower(seed.Name)] = id
+	}
+	return len(seeds), nil
+}
+
+// InsertCategory saves a new category and returns its ID. It returns ErrCategoryExists if the user already
+// has a category with the name and ErrInvalidParent if the parent cannot have the category as a subcategory.
+func (s *SQLStore) InsertCategory(c category.Category) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	var id int64
+	err = checkCategory(tx, c)
+	if err == nil {
+		err = tx.QueryRow(`
+            INSERT INTO categories (user_id, name, type, parent_id)
+            VALUES ($1, $2, $3, $4)
+            RETURNING id;
+        `, c.UserID, c.Name, string(c.Type), nullID(c.ParentID)).Scan(&id)
+		if err != nil {
+			log.Printf("Error inserting category %s: %v", c.Name, err)
+			err = fmt.Errorf("database insert of category failed: %w", err)
+		}
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit category insert: %w", err)
+	}
+
+	log.Printf("Successfully inserted category with ID: %d", id)
+	return id, nil
+}
+
+// UpdateCategory overwrites a category owned by c.UserID. A new name is also given to the user's transactions,
+// budgets, recurring rules and frequent expenses in the category. It returns ErrNotFound if the category does not
+// exist, ErrCategoryExists if the new name is taken and ErrInvalidParent if the new parent is not allowed.
+func (s *SQLStore) UpdateCategory(c category.Category) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	if err = updateCategory(tx, c); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit category update: %w", err)
+	}
+
+	log.Printf("Successfully updated category with ID: %d", c.ID)
+	return nil
+}
+
+// updateCategory checks and updates the category and renames what refers to it within a database transaction.
+func updateCategory(tx *sql.Tx, c category.Category) error {
+	var oldName string
+	err := tx.QueryRow(`SELECT name FROM categories WHERE id = $1 AND user_id = $2`, c.ID, c.UserID).Scan(&oldName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error querying category %d: %v", c.ID, err)
+		return fmt.Errorf("database query for category failed: %w", err)
+	}
+	if err = checkCategory(tx, c); err != nil {
+		return err
+	}
+
+	statements := []string{`UPDATE categories SET name = $1, type = $2, parent_id = $3 WHERE id = $4 AND user_id = $5`}
+	args := [][]interface{}{{c.Name, string(c.Type), nullID(c.ParentID), c.ID, c.UserID}}
+	if c.Name != oldName {
+		for _, table := range []string{"transactions", "budgets", "recurring_rules", "frequent_expenses"} {
+			statements = append(statements, `UPDATE `+table+` SET category = $1 WHERE user_id = $2 AND category = $3`)
+			args = append(args, []interface{}{c.Name, c.UserID, oldName})
+		}
+	}
+	for i, statement := range statements {
+		if _, err = tx.Exec(statement, args[i]...); err != nil {
+			log.Printf("Error updating category %d: %v", c.ID, err)
+			return fmt.Errorf("database update of category failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkCategory returns ErrCategoryExists if another of the user's categories has the name of c, and
+// ErrInvalidParent unless the parent is a top-level category of the same type and c has no subcategories,
+// or when c is a parent whose subcategories would no longer have its type.
+func checkCategory(tx *sql.Tx, c category.Category) error {
+	var otherID int64
+	err := tx.QueryRow(`SELECT id FROM categories WHERE user_id = $1 AND LOWER(name) = LOWER($2) AND id <> $3`, c.UserID, c.Name, c.ID).Scan(&otherID)
+	if err == nil {
+		return ErrCategoryExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error checking category %s for duplicates: %v", c.Name, err)
+		return fmt.Errorf("database query for categories failed: %w", err)
+	}
+
+	var children, otherTypes int
+	err = tx.QueryRow(`SELECT COUNT(*), COUNT(CASE WHEN type <> $3 THEN 1 END) FROM categories WHERE parent_id = $1 AND user_id = $2`,
+		c.ID, c.UserID, string(c.Type)).Scan(&children, &otherTypes)
+	if err != nil {
+		log.Printf("Error counting subcategories of category %d: %v", c.ID, err)
+		return fmt.Errorf("database query for categories failed: %w", err)
+	}
+	if otherTypes > 0 {
+		return fmt.Errorf("%w: its subcategories must have the same type", ErrInvalidParent)
+	}
+	if c.ParentID == 0 {
+		return nil
+	}
+	if children > 0 {
+		return fmt.Errorf("%w: a category with subcategories cannot become a subcategory", ErrInvalidParent)
+	}
+
+	var parent category.Category
+	parent, err = scanCategory(tx.QueryRow(`SELECT `+categoryColumns+` FROM categories WHERE id = $1 AND user_id = $2`, c.ParentID, c.UserID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: category %d not found", ErrInvalidParent, c.ParentID)
+	}
+	if err != nil {
+		log.Printf("Error querying parent category %d: %v", c.ParentID, err)
+		return fmt.Errorf("database query for category failed: %w", err)
+	}
+	return checkParent(c, parent)
+}
+
+// checkParent returns ErrInvalidParent unless the parent is a top-level category of the type of c.
+func checkParent(c, parent category.Category) error {
+	if parent.ParentID != 0 {
+		return fmt.Errorf("%w: %s is a subcategory itself", ErrInvalidParent, parent.Name)
+	}
+	if parent.Type != c.Type {
+		return fmt.Errorf("%w: %s is an %s category", ErrInvalidParent, parent.Name, parent.Type)
+	}
+	return nil
+}
+
+// DeleteCategory removes a category owned by the user, or returns ErrNotFound. Its subcategories become
+// top-level categories, and its transactions keep the category name.
+func (s *SQLStore) DeleteCategory(userID, id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM categories WHERE id = $1 AND user_id = $2`, id, userID)
+	if err == nil {
+		err = expectAffected(result)
+	}
+	if err == nil {
+		_, err = tx.Exec(`UPDATE categories SET parent_id = NULL WHERE parent_id = $1`, id)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, ErrNotFound) {
+			return err
+		}
+		log.Printf("Error deleting category %d: %v", id, err)
+		return fmt.Errorf("database delete of category failed: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit category delete: %w", err)
+	}
+
+	log.Printf("Successfully deleted category with ID: %d", id)
+	return nil
+}
+
+// GetCategory returns a category owned by the user, or ErrNotFound.
+func (s *SQLStore) GetCategory(userID, id int64) (category.Category, error) {
+	querySQL := `SELECT ` + categoryColumns + ` FROM categories WHERE id = $1 AND user_id = $2`
+
+	c, err := scanCategory(s.db.QueryRow(querySQL, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return category.Category{}, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error querying category %d: %v", id, err)
+		return category.Category{}, fmt.Errorf("database query for category failed: %w", err)
+	}
+	return c, nil
+}
+
+// GetCategories returns the user's categories ordered by name.
+func (s *SQLStore) GetCategories(userID int64) ([]category.Category, error) {
+	querySQL := `SELECT ` + categoryColumns + ` FROM categories WHERE user_id = $1 ORDER BY name`
+
+	rows, err := s.db.Query(querySQL, userID)
+	if err != nil {
+		log.Printf("Error querying categories: %v", err)
+		return nil, fmt.Errorf("database query for categories failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for categories: %v", err)
+		}
+	}(rows)
+
+	categories := []category.Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			log.Printf("Error scanning category row: %v", err)
+			return nil, fmt.Errorf("failed to scan category row: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating category rows: %v", err)
+		return nil, fmt.Errorf("error during category row iteration: %w", err)
+	}
+	return categories, nil
+}
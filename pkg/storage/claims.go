@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/transaction"
+	"time"
+)
+
+// UpdateClaim moves the claim of a transaction owned by the user to the status at the time, see
+// transaction.MoveClaim, and returns the updated transaction. It returns ErrNotFound if there is no
+// such transaction and transaction.ErrClaimTransition if the lifecycle does not allow the change.
+func (s *SQLStore) UpdateClaim(userID, id int64, status transaction.ClaimStatus, reference string, at time.Time) (transaction.Transaction, error) {
+	t, err := s.GetTransaction(userID, id)
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	previous := t.CurrentClaimStatus()
+	if err = t.MoveClaim(status, reference, at); err != nil {
+		return transaction.Transaction{}, err
+	}
+
+	// The status is compared rather than locked, so of two concurrent changes only the first one wins.
+	updateSQL := `
+        UPDATE transactions
+        SET claim_status = $1, claim_reference = $2, claim_submitted_at = $3, claim_resolved_at = $4
+        WHERE id = $5 AND user_id = $6 AND claim_status = $7;
+    `
+	result, err := s.db.Exec(updateSQL,
+		string(t.ClaimStatus), nullString(t.ClaimReference), nullTime(t.ClaimSubmittedAt), nullTime(t.ClaimResolvedAt),
+		id, userID, string(previous),
+	)
+	if err != nil {
+		log.Printf("Error updating claim of transaction %d: %v", id, err)
+		return transaction.Transaction{}, fmt.Errorf("database update of claim failed: %w", err)
+	}
+	if err = expectAffected(result); errors.Is(err, ErrNotFound) {
+		return transaction.Transaction{}, fmt.Errorf("%w: the claim was changed at the same time", transaction.ErrClaimTransition)
+	} else if err != nil {
+		return transaction.Transaction{}, err
+	}
+
+	log.Printf("Successfully moved claim of transaction %d to %s", id, t.ClaimStatus)
+	return t, nil
+}
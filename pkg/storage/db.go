@@ -2,19 +2,49 @@ package storage
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
 	_ "github.com/lib/pq"
 	"log"
 	"main/pkg/config"
 )
 
-// db holds the database connection pool. It's a package-level variable.
-var db *sql.DB
+// SQLStore is a TransactionStore backed by a database/sql connection pool.
+type SQLStore struct {
+	db     *sql.DB
+	driver string // config.DriverPostgres or config.DriverSQLite
+}
 
-// InitDB initializes the database connection pool using environment variables.
-// It should be called once when your application starts.
-func InitDB(dbConfig config.DatabaseConfig) error {
+// NewStore opens the SQL store selected by the driver in the database config
+// and brings its schema up to date.
+func NewStore(dbConfig config.DatabaseConfig) (*SQLStore, error) {
+	s, err := Open(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.MigrateUp(); err != nil {
+		if closeErr := s.Close(); closeErr != nil {
+			log.Printf("Error closing database connection: %v", closeErr)
+		}
+		return nil, fmt.Errorf("failed to migrate database schema: %w", err)
+	}
+	return s, nil
+}
+
+// Open connects to the SQL store selected by the driver in the database config
+// without touching its schema. Use NewStore unless you are managing migrations.
+func Open(dbConfig config.DatabaseConfig) (*SQLStore, error) {
+	switch dbConfig.Driver {
+	case "", config.DriverPostgres:
+		return openPostgres(dbConfig)
+	case config.DriverSQLite:
+		return openSQLite(dbConfig.Path)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", dbConfig.Driver)
+	}
+}
+
+// openPostgres initializes the Postgres connection pool from the database config.
+func openPostgres(dbConfig config.DatabaseConfig) (*SQLStore, error) {
 	dbHost := dbConfig.Host
 	dbPort := dbConfig.Port
 	dbUser := dbConfig.User
@@ -26,76 +56,35 @@ func InitDB(dbConfig config.DatabaseConfig) error {
 	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		dbHost, dbPort, dbUser, dbPassword, dbName, dbSSLMode)
 
-	var err error
 	// Open the database connection pool. Note: sql.Open doesn't establish any connections yet.
-	db, err = sql.Open("postgres", connStr)
+	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		log.Printf("Error opening database connection: %v", err)
-		return fmt.Errorf("failed to open database connection: %w", err)
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
 
 	// Ping the database to verify the connection details are correct and the DB is reachable.
 	err = db.Ping()
 	if err != nil {
 		// Close the pool if ping fails, as it's unusable.
-		err = db.Close()
-
-		if err != nil {
-			panic(err)
+		if closeErr := db.Close(); closeErr != nil {
+			log.Printf("Error closing database connection: %v", closeErr)
 		}
-		return fmt.Errorf("failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	log.Println("Successfully connected to the database!")
-
-	// Optionally, ensure the necessary table exists
-	return createTableIfNotExists()
+	return &SQLStore{db: db, driver: config.DriverPostgres}, nil
 }
 
-// createTableIfNotExists creates the 'transactions' table if it doesn't already exist.
-func createTableIfNotExists() error {
-	// SQL statement to create the table. Adjust types/constraints as needed.
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS transactions (
-		id SERIAL PRIMARY KEY,
-		name TEXT,
-		amount NUMERIC(10, 2), -- Example: 10 total digits, 2 after decimal
-		currency VARCHAR(10),
-		date DATE,
-		is_claimable BOOLEAN,
-		paid_for_family BOOLEAN,
-	    category TEXT,
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	_, err := db.Exec(createTableSQL)
+// Close closes the database connection pool.
+// It should be called when the application is shutting down gracefully.
+func (s *SQLStore) Close() error {
+	err := s.db.Close()
 	if err != nil {
-		log.Printf("Error creating transactions table: %v", err)
-		return fmt.Errorf("failed to create transactions table: %w", err)
+		log.Printf("Error closing database connection: %v", err)
+		return err
 	}
-	log.Println("Transactions table checked/created successfully.")
+	log.Println("Database connection closed.")
 	return nil
 }
-
-// GetDB returns the initialized database connection pool.
-// Other functions in this package (or other packages, if exported) can use this
-// to interact with the database.
-func GetDB() (*sql.DB, error) {
-	if db == nil {
-		return nil, errors.New("database connection pool is not initialized, call InitDB first")
-	}
-	return db, nil
-}
-
-// CloseDB closes the database connection pool.
-// It should be called when the application is shutting down gracefully.
-func CloseDB() {
-	if db != nil {
-		err := db.Close()
-		if err != nil {
-			log.Printf("Error closing database connection: %v", err)
-		} else {
-			log.Println("Database connection closed.")
-		}
-	}
-}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/transaction"
+	"strings"
+)
+
+// duplicateRange returns the dates within windowDays days of the YYYY-MM-DD date.
+func duplicateRange(date string, windowDays int) (DateRange, error) {
+	d, err := transaction.ParseDate(date)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("invalid transaction date: %w", err)
+	}
+	return DateRange{From: d.AddDate(0, 0, -windowDays).Format("2006-01-02"), To: d.AddDate(0, 0, windowDays).Format("2006-01-02")}, nil
+}
+
+// FindDuplicate returns the ID of the most recently saved transaction of t.UserID that looks like t: the same type,
+// name (ignoring case and surrounding spaces), amount and currency, dated at most windowDays days from t.Date.
+// It returns 0 if there is none.
+func (s *SQLStore) FindDuplicate(t transaction.Transaction, windowDays int) (int64, error) {
+	dateRange, err := duplicateRange(t.Date, windowDays)
+	if err != nil {
+		return 0, err
+	}
+	querySQL := `
+        SELECT id FROM transactions
+        WHERE user_id = $1 AND type = $2 AND LOWER(TRIM(name)) = LOWER($3) AND amount_minor = $4 AND currency = $5
+          AND date >= $6 AND date <= $7
+        ORDER BY id DESC
+        LIMIT 1`
+
+	var id int64
+	err = s.db.QueryRow(querySQL, t.UserID, string(t.TransactionType()), strings.TrimSpace(t.Name), t.Amount, t.Currency,
+		dateRange.From, dateRange.To).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		log.Printf("Error looking for duplicates of transaction %s: %v", t.Name, err)
+		return 0, fmt.Errorf("database query for duplicate transactions failed: %w", err)
+	}
+	return id, nil
+}
+
+// FindDuplicate returns the ID of the most recently saved transaction that looks like t, or 0.
+func (s *MemoryStore) FindDuplicate(t transaction.Transaction, windowDays int) (int64, error) {
+	dateRange, err := duplicateRange(t.Date, windowDays)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var id int64
+	for _, existing := range s.transactions {
+		if existing.UserID == t.UserID && existing.TransactionType() == t.TransactionType() &&
+			strings.EqualFold(strings.TrimSpace(existing.Name), strings.TrimSpace(t.Name)) &&
+			existing.Amount == t.Amount && existing.Currency == t.Currency &&
+			dateRange.contains(existing.Date) && existing.ID > id {
+			id = existing.ID
+		}
+	}
+	return id, nil
+}
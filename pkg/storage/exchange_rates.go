@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"main/pkg/exchange"
+	"time"
+)
+
+// UpsertExchangeRates saves imported rates in a single database transaction,
+// replacing any rate already stored for the same day and currency pair.
+func (s *SQLStore) UpsertExchangeRates(rates []exchange.Rate) error {
+	upsertSQL := `
+        INSERT INTO exchange_rates (date, base_currency, quote_currency, rate)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (base_currency, quote_currency, date) DO UPDATE SET rate = excluded.rate;
+    `
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	stmt, err := tx.Prepare(upsertSQL)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to prepare exchange rate upsert: %w", err)
+	}
+	defer func(stmt *sql.Stmt) {
+		if err := stmt.Close(); err != nil {
+			log.Printf("Error closing exchange rate statement: %v", err)
+		}
+	}(stmt)
+
+	for _, rate := range rates {
+		if _, err = stmt.Exec(rate.Date, rate.Base, rate.Quote, exchange.FormatRate(rate.Value)); err != nil {
+			_ = tx.Rollback()
+			log.Printf("Error saving exchange rate %s/%s on %s: %v", rate.Base, rate.Quote, rate.Date, err)
+			return fmt.Errorf("database upsert of exchange rate failed: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit exchange rates: %w", err)
+	}
+
+	log.Printf("Successfully saved %d exchange rates.", len(rates))
+	return nil
+}
+
+// GetExchangeRates returns, for every currency pair, the most recent rate on or before the date.
+func (s *SQLStore) GetExchangeRates(date string) ([]exchange.Rate, error) {
+	querySQL := `
+		SELECT r.date, r.base_currency, r.quote_currency, r.rate
+		FROM exchange_rates r
+		JOIN (
+			SELECT base_currency, quote_currency, MAX(date) AS date
+			FROM exchange_rates
+			WHERE date <= $1
+			GROUP BY base_currency, quote_currency
+		) latest
+		ON r.base_currency = latest.base_currency
+			AND r.quote_currency = latest.quote_currency
+			AND r.date = latest.date;
+	`
+
+	rows, err := s.db.Query(querySQL, date)
+	if err != nil {
+		log.Printf("Error querying exchange rates: %v (SQL: %s)", err, querySQL)
+		return nil, fmt.Errorf("database query for exchange rates failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for exchange rates: %v", err)
+		}
+	}(rows)
+
+	var rates []exchange.Rate
+	for rows.Next() {
+		var rateDate time.Time
+		var rate exchange.Rate
+		var value string
+		if err := rows.Scan(&rateDate, &rate.Base, &rate.Quote, &value); err != nil {
+			log.Printf("Error scanning exchange rate row: %v", err)
+			return nil, fmt.Errorf("failed to scan exchange rate row: %w", err)
+		}
+		rate.Date = rateDate.Format("2006-01-02")
+		if rate.Value, err = exchange.ParseRate(value); err != nil {
+			return nil, fmt.Errorf("invalid stored exchange rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating exchange rate rows: %v", err)
+		return nil, fmt.Errorf("error during exchange rate row iteration: %w", err)
+	}
+	return rates, nil
+}
+
+// normalizeRate trims the trailing zeros Postgres pads NUMERIC rates with.
+func normalizeRate(rate string) string {
+	if rate == "" {
+		return ""
+	}
+	value, err := exchange.ParseRate(rate)
+	if err != nil {
+		return rate
+	}
+	return exchange.FormatRate(value)
+}
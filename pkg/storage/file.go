@@ -0,0 +1,458 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"main/pkg/category"
+	"main/pkg/frequent"
+	"main/pkg/recurring"
+	"main/pkg/transaction"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxLineSize is the longest line ReadJSONL accepts.
+const maxLineSize = 1 << 20
+
+// lockSuffix names the file next to the JSON lines file that writers lock, so that processes sharing
+// the file never lose each other's lines.
+const lockSuffix = ".lock"
+
+// FileStore is a Store that keeps transactions as JSON lines in a file, the format of the bot's file mode
+// (features.save_to_database: false). The file is indexed in memory by a MemoryStore, so it answers the same
+// queries as the database. New transactions are appended with a single write each; updates and deletes
+// rewrite the file. Lines appended by another process, such as the bot while the server reads the file,
+// are indexed before every read, and writers hold a lock on <file>.lock. Everything but transactions is
+// only kept in memory, so the bot and the API refuse the features that need anything else.
+type FileStore struct {
+	*MemoryStore
+	path string
+
+	fileMu sync.Mutex  // Serialises reading and writing the file, always taken before the file lock and MemoryStore.mu
+	info   os.FileInfo // The file as last indexed, nil before it exists
+	offset int64       // Bytes of the file already indexed
+}
+
+// NewFileStore opens the JSON lines file at path, SaveFilePath when empty, and indexes its transactions.
+// Transactions without an ID, written before the file was queryable, are numbered and the file rewritten.
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		path = SaveFilePath
+	}
+	s := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if err := s.write(func() error { return nil }); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ReadJSONL reads transactions written one JSON object per line. Blank lines are skipped.
+func ReadJSONL(r io.Reader) ([]transaction.Transaction, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	var ts []transaction.Transaction
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var t transaction.Transaction
+		if err := json.Unmarshal(scanner.Bytes(), &t); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ts = append(ts, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transactions: %w", err)
+	}
+	return ts, nil
+}
+
+// InsertTransaction appends the transaction to the file and returns its assigned ID.
+func (s *FileStore) InsertTransaction(t transaction.Transaction) (int64, error) {
+	ids, err := s.InsertTransactions([]transaction.Transaction{t})
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return 0, batchErr.Err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// InsertTransactions appends the transactions to the file in a single write and returns their assigned IDs.
+func (s *FileStore) InsertTransactions(ts []transaction.Transaction) ([]int64, error) {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	var ids []int64
+	err := s.write(func() error {
+		var err error
+		if ids, err = s.MemoryStore.InsertTransactions(ts); err != nil {
+			return err
+		}
+		saved := make([]transaction.Transaction, 0, len(ids))
+		for i, id := range ids {
+			t, err := s.MemoryStore.GetTransaction(ts[i].UserID, id)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, t)
+		}
+		if err = s.appendTransactions(saved); err != nil {
+			for i, id := range ids {
+				_ = s.MemoryStore.DeleteTransaction(ts[i].UserID, id)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetTransaction returns a single transaction owned by the user, or ErrNotFound.
+func (s *FileStore) GetTransaction(userID, id int64) (transaction.Transaction, error) {
+	if err := s.sync(); err != nil {
+		return transaction.Transaction{}, err
+	}
+	return s.MemoryStore.GetTransaction(userID, id)
+}
+
+// UpdateTransaction overwrites a transaction owned by t.UserID and rewrites the file.
+func (s *FileStore) UpdateTransaction(t transaction.Transaction) error {
+	return s.change(func() error { return s.MemoryStore.UpdateTransaction(t) })
+}
+
+// UpdateClaim moves the claim of a transaction owned by the user along the claim lifecycle and rewrites the file.
+func (s *FileStore) UpdateClaim(userID, id int64, status transaction.ClaimStatus, reference string, at time.Time) (transaction.Transaction, error) {
+	var t transaction.Transaction
+	err := s.change(func() error {
+		var err error
+		t, err = s.MemoryStore.UpdateClaim(userID, id, status, reference, at)
+		return err
+	})
+	return t, err
+}
+
+// DeleteTransaction removes a transaction owned by the user and rewrites the file.
+func (s *FileStore) DeleteTransaction(userID, id int64) error {
+	return s.change(func() error { return s.MemoryStore.DeleteTransaction(userID, id) })
+}
+
+// DeleteRecurringRule removes a rule owned by the user, unlinks its transactions and rewrites the file.
+func (s *FileStore) DeleteRecurringRule(userID, id int64) error {
+	return s.change(func() error { return s.MemoryStore.DeleteRecurringRule(userID, id) })
+}
+
+// DeleteAccount removes an account owned by the user, unlinks its transactions and rewrites the file.
+func (s *FileStore) DeleteAccount(userID, id int64) error {
+	return s.change(func() error { return s.MemoryStore.DeleteAccount(userID, id) })
+}
+
+// UpdateCategory overwrites a category owned by c.UserID, renames the transactions in it and rewrites the file.
+func (s *FileStore) UpdateCategory(c category.Category) error {
+	return s.change(func() error { return s.MemoryStore.UpdateCategory(c) })
+}
+
+// FindDuplicate returns the ID of the latest transaction t looks like, or 0 if there is none.
+func (s *FileStore) FindDuplicate(t transaction.Transaction, windowDays int) (int64, error) {
+	if err := s.sync(); err != nil {
+		return 0, err
+	}
+	return s.MemoryStore.FindDuplicate(t, windowDays)
+}
+
+// GetAllTransactions returns one page of the user's matching transactions.
+func (s *FileStore) GetAllTransactions(userID int64, filter TransactionFilter, req PageRequest) (TransactionPage, error) {
+	if err := s.sync(); err != nil {
+		return TransactionPage{}, err
+	}
+	return s.MemoryStore.GetAllTransactions(userID, filter, req)
+}
+
+// GetTotals returns the user's summed amounts per group and currency for the transactions dated within the range.
+func (s *FileStore) GetTotals(userID int64, groupBy GroupBy, dateRange DateRange) ([]Total, error) {
+	if err := s.sync(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.GetTotals(userID, groupBy, dateRange)
+}
+
+// GetAccountTotals returns the daily net change of an account per currency.
+func (s *FileStore) GetAccountTotals(userID, accountID int64) ([]Total, error) {
+	if err := s.sync(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.GetAccountTotals(userID, accountID)
+}
+
+// GetExpenseHistory returns the user's expenses dated on or after the date.
+func (s *FileStore) GetExpenseHistory(userID int64, since string) ([]frequent.Occurrence, error) {
+	if err := s.sync(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.GetExpenseHistory(userID, since)
+}
+
+// RecordRecurringRun saves the transactions of the rule's occurrences and appends them to the file.
+func (s *FileStore) RecordRecurringRun(rule recurring.Rule, due []transaction.Transaction, through string) ([]transaction.Transaction, error) {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	var recorded []transaction.Transaction
+	err := s.write(func() error {
+		var err error
+		if recorded, err = s.MemoryStore.RecordRecurringRun(rule, due, through); err != nil {
+			return err
+		}
+		if err = s.appendTransactions(recorded); err != nil {
+			for _, t := range recorded {
+				_ = s.MemoryStore.DeleteTransaction(t.UserID, t.ID)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
+
+// sync indexes the lines appended to the file since it was last read. If they had to be renumbered,
+// because another process appended a transaction with an ID already in use, the file is rewritten
+// so that every process sees the same IDs.
+func (s *FileStore) sync() error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	renumbered, err := s.refresh()
+	if err != nil || !renumbered {
+		return err
+	}
+	return s.write(s.rewrite)
+}
+
+// change applies a change to the index and rewrites the file. If the file cannot be rewritten,
+// the index is read from the file again. Callers must not hold fileMu.
+func (s *FileStore) change(apply func() error) error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	return s.write(func() error {
+		if err := apply(); err != nil {
+			return err
+		}
+		if err := s.rewrite(); err != nil {
+			s.info = nil
+			if _, reloadErr := s.refresh(); reloadErr != nil {
+				log.Printf("Error reloading %s: %v", s.path, reloadErr)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// write runs fn holding the lock shared with the other processes writing the file, after indexing the
+// lines they wrote so that new IDs come after theirs. If that had to renumber transactions, the file is
+// rewritten first. Callers must hold fileMu.
+func (s *FileStore) write(fn func() error) error {
+	unlock, err := lockFile(s.path + lockSuffix)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	renumbered, err := s.refresh()
+	if err != nil {
+		return err
+	}
+	if renumbered {
+		if err = s.rewrite(); err != nil {
+			return err
+		}
+		log.Printf("Renumbered the transactions of %s without an ID or with one already in use", s.path)
+	}
+	return fn()
+}
+
+// refresh indexes the complete lines the file gained since it was last read, or reads the whole file again
+// if it was replaced or truncated. It reports whether transactions had to be given new IDs because they
+// had none or a taken one. Callers must hold fileMu.
+func (s *FileStore) refresh() (bool, error) {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing file: %v", err)
+		}
+	}(file)
+
+	// The opened file is the one read even if it is replaced meanwhile.
+	info, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	if s.info != nil && os.SameFile(s.info, info) && info.Size() == s.offset {
+		return false, nil
+	}
+	reload := s.info == nil || !os.SameFile(s.info, info) || info.Size() < s.offset
+
+	offset := s.offset
+	if reload {
+		offset = 0
+	}
+	data, err := io.ReadAll(io.NewSectionReader(file, offset, info.Size()-offset))
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	// A line without its newline is still being written; it is indexed once complete.
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	ts, err := ReadJSONL(bytes.NewReader(data[:complete]))
+	if err != nil {
+		return false, fmt.Errorf("invalid transaction in %s: %w", s.path, err)
+	}
+
+	s.MemoryStore.mu.Lock()
+	defer s.MemoryStore.mu.Unlock()
+	if reload {
+		s.MemoryStore.transactions = nil
+	}
+	renumbered := s.MemoryStore.restoreTransactions(ts)
+	s.info, s.offset = info, offset+int64(complete)
+	return renumbered, nil
+}
+
+// restoreTransactions indexes transactions read from a file, keeping their IDs unless they have none or a
+// taken one. It reports whether any had to be given a new ID. Callers must hold the lock.
+func (s *MemoryStore) restoreTransactions(ts []transaction.Transaction) bool {
+	taken := make(map[int64]bool, len(s.transactions)+len(ts))
+	for _, t := range s.transactions {
+		taken[t.ID] = true
+	}
+	for _, t := range ts {
+		if t.ID >= s.nextID {
+			s.nextID = t.ID + 1
+		}
+	}
+
+	renumbered := false
+	for _, t := range ts {
+		if t.ID <= 0 || taken[t.ID] {
+			t.ID = s.nextID
+			s.nextID++
+			renumbered = true
+		}
+		taken[t.ID] = true
+		t.Tags = append([]string{}, t.Tags...)
+		t.ClaimStatus = t.CurrentClaimStatus()
+		t.Type = t.TransactionType()
+		s.transactions = append(s.transactions, t)
+	}
+	return renumbered
+}
+
+// appendTransactions writes the transactions to the end of the file with a single write.
+// Callers must hold fileMu and the file lock.
+func (s *FileStore) appendTransactions(ts []transaction.Transaction) error {
+	if len(ts) == 0 {
+		return nil
+	}
+	var lines bytes.Buffer
+	for _, t := range ts {
+		if err := encodeLine(&lines, t); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	_, err = file.Write(lines.Bytes())
+	var info os.FileInfo
+	if err == nil {
+		info, err = file.Stat()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append to %s: %w", s.path, err)
+	}
+
+	// The lines just written are already indexed; the file lock keeps other processes from appending meanwhile.
+	if s.info == nil || (os.SameFile(s.info, info) && info.Size() == s.offset+int64(lines.Len())) {
+		s.info, s.offset = info, info.Size()
+	}
+	return nil
+}
+
+// rewrite replaces the file with the indexed transactions, writing a temporary file first
+// so that the file is never left half written. Callers must hold fileMu and the file lock.
+func (s *FileStore) rewrite() error {
+	var lines bytes.Buffer
+	s.MemoryStore.mu.RLock()
+	for _, t := range s.MemoryStore.transactions {
+		if err := encodeLine(&lines, t); err != nil {
+			s.MemoryStore.mu.RUnlock()
+			return err
+		}
+	}
+	s.MemoryStore.mu.RUnlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to rewrite %s: %w", s.path, err)
+	}
+	_, err = tmp.Write(lines.Bytes())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to rewrite %s: %w", s.path, err)
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	s.info, s.offset = info, info.Size()
+	return nil
+}
+
+// encodeLine writes a transaction as one JSON line.
+func encodeLine(w *bytes.Buffer, t transaction.Transaction) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction %d: %w", t.ID, err)
+	}
+	w.Write(data)
+	w.WriteByte('\n')
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package storage
+
+// lockFile does not lock across processes on platforms without flock: writers are then only serialised
+// within the process, so the bot and the server must not share a responses file there.
+func lockFile(string) (func(), error) {
+	return func() {}, nil
+}
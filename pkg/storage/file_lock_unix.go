@@ -0,0 +1,35 @@
+//go:build unix
+
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on the file at path, creating it if needed, and waits for
+// other processes holding it. The returned function releases the lock.
+func lockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return func() {
+		// Closing the file releases the lock.
+		if err := file.Close(); err != nil {
+			log.Printf("Error unlocking %s: %v", path, err)
+		}
+	}, nil
+}
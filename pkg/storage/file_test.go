@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"fmt"
+	"main/pkg/account"
+	"main/pkg/transaction"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// jsonLine is a transaction line as the bot writes it, without an ID when id is 0.
+func jsonLine(id int64, name string) string {
+	idField := ""
+	if id != 0 {
+		idField = fmt.Sprintf(`"id":%d,`, id)
+	}
+	return fmt.Sprintf(`{%s"userId":1,"date":"2026-01-02","name":%q,"category":"Food","amount":12.5,"currency":"SGD"}`+"\n", idField, name)
+}
+
+func appendToFile(t *testing.T, path, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = file.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = file.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fileIDs returns the IDs of the transactions in the file, in file order.
+func fileIDs(t *testing.T, path string) []int64 {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	ts, err := ReadJSONL(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transactionIDs(ts)
+}
+
+// listed returns the user's transactions by ID.
+func listed(t *testing.T, store TransactionStore, userID int64) map[int64]transaction.Transaction {
+	t.Helper()
+	page, err := store.GetAllTransactions(userID, TransactionFilter{}, PageRequest{Sort: DefaultSort, Limit: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[int64]transaction.Transaction, len(page.Transactions))
+	for _, tr := range page.Transactions {
+		byID[tr.ID] = tr
+	}
+	return byID
+}
+
+func sortedIDs(byID map[int64]transaction.Transaction) []int64 {
+	ids := make([]int64, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func lunchTransaction(name string) transaction.Transaction {
+	return transaction.Transaction{UserID: 1, Date: "2026-01-02", Name: name, Category: "Food", Amount: 1250, Currency: "SGD"}
+}
+
+func TestFileStoreNumbersLinesWithoutID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.txt")
+	appendToFile(t, path, jsonLine(0, "Lunch")+"\n"+jsonLine(5, "Coffee")+jsonLine(0, "Dinner"))
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fileIDs(t, path), []int64{6, 5, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("file IDs = %v, want %v", got, want)
+	}
+	if got := listed(t, store, 1); got[6].Name != "Lunch" || got[5].Name != "Coffee" || got[7].Name != "Dinner" {
+		t.Errorf("indexed %v", got)
+	}
+}
+
+func TestFileStoreIndexesAppendedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.txt")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.InsertTransaction(lunchTransaction("Lunch")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process appends a line, and is still writing the next one.
+	partial := jsonLine(3, "Dinner")
+	appendToFile(t, path, jsonLine(2, "Coffee")+partial[:20])
+	if got, want := sortedIDs(listed(t, store, 1)), []int64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IDs = %v, want %v", got, want)
+	}
+
+	appendToFile(t, path, partial[20:])
+	if got, want := sortedIDs(listed(t, store, 1)), []int64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IDs = %v, want %v", got, want)
+	}
+	id, err := store.InsertTransaction(lunchTransaction("Tea"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 {
+		t.Errorf("new ID = %d, want 4", id)
+	}
+}
+
+func TestFileStoreRenumbersTakenIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.txt")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.InsertTransaction(lunchTransaction("Lunch")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process appended a transaction with the same ID.
+	appendToFile(t, path, jsonLine(1, "Coffee"))
+	got := listed(t, store, 1)
+	if ids, want := sortedIDs(got), []int64{1, 2}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("IDs = %v, want %v", ids, want)
+	}
+	if got[1].Name != "Lunch" || got[2].Name != "Coffee" {
+		t.Errorf("indexed %v", got)
+	}
+	// The file is rewritten, so that every process sees the new ID.
+	if ids, want := fileIDs(t, path), []int64{1, 2}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("file IDs = %v, want %v", ids, want)
+	}
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again := listed(t, reopened, 1); !reflect.DeepEqual(sortedIDs(again), sortedIDs(got)) || again[2].Name != "Coffee" {
+		t.Errorf("reopened %v, want %v", again, got)
+	}
+}
+
+func TestFileStoreRewritesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.txt")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountID, err := store.InsertAccount(account.Account{UserID: 1, Name: "Card", Type: account.Card, Currency: "SGD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paid := lunchTransaction("Lunch")
+	paid.AccountID = accountID
+	lunchID, err := store.InsertTransaction(paid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coffeeID, err := store.InsertTransaction(lunchTransaction("Coffee"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lunch, err := store.GetTransaction(1, lunchID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lunch.Name = "Brunch"
+	if err = store.UpdateTransaction(lunch); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.DeleteTransaction(1, coffeeID); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.DeleteAccount(1, accountID); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := fileIDs(t, path), []int64{lunchID}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("file IDs = %v, want %v", got, want)
+	}
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := listed(t, reopened, 1)[lunchID]
+	if saved.Name != "Brunch" || saved.AccountID != 0 {
+		t.Errorf("saved %+v, want Brunch without an account", saved)
+	}
+}
+
+func TestFileStoreReloadsReplacedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.txt")
+	server, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Lunch", "Coffee"} {
+		if _, err = server.InsertTransaction(lunchTransaction(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The bot deletes a transaction, replacing the file under the server.
+	if err = bot.DeleteTransaction(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sortedIDs(listed(t, server, 1)), []int64{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("server IDs = %v, want %v", got, want)
+	}
+}
+
+func TestFileStoreConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.txt")
+	// Each store opens the file lock separately, like another process.
+	stores := make([]*FileStore, 2)
+	for i := range stores {
+		var err error
+		if stores[i], err = NewFileStore(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const writes = 100
+	var wg sync.WaitGroup
+	for i := 0; i < writes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := stores[i%len(stores)].InsertTransaction(lunchTransaction(fmt.Sprintf("Lunch %d", i))); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	ids := fileIDs(t, path)
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("ID %d is in the file twice: %v", id, ids)
+		}
+		seen[id] = true
+	}
+	if len(ids) != writes {
+		t.Errorf("file holds %d transactions, want %d", len(ids), writes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != writes {
+		t.Errorf("file has %d lines, want %d", lines, writes)
+	}
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"fmt"
+	"main/pkg/money"
+	"main/pkg/transaction"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// TransactionFilter selects the transactions returned by GetAllTransactions.
+// Zero values mean "no restriction".
+type TransactionFilter struct {
+	DateRange                        // Inclusive YYYY-MM-DD bounds on the transaction date
+	Types         []transaction.Type // Any of these types
+	AccountID     int64              // Paid from, into or transferred to this account
+	Categories    []string           // Any of these categories
+	Currency      string             // ISO 4217 code, matched case-insensitively
+	MinAmount     *big.Rat           // Inclusive, a decimal in each transaction's own currency
+	MaxAmount     *big.Rat           // Inclusive, a decimal in each transaction's own currency
+	IsClaimable   *bool
+	ClaimStatuses []transaction.ClaimStatus // Any of these claim statuses
+	PaidForFamily *bool
+	Query         string   // Case-insensitive substring of the name
+	Tags          []string // Normalised tags, see transaction.NormalizeTags
+	TagMatch      TagMatch // How Tags is matched, TagMatchAny when empty
+}
+
+// conditions appends the SQL conditions and arguments for the filter, numbering placeholders from argID.
+// It returns the next free placeholder number.
+func (f TransactionFilter) conditions(conditions []string, args []interface{}, argID int) ([]string, []interface{}, int) {
+	conditions, args, argID = f.DateRange.conditions(conditions, args, argID)
+
+	if len(f.Types) > 0 {
+		placeholders := make([]string, len(f.Types))
+		for i, t := range f.Types {
+			placeholders[i] = fmt.Sprintf("$%d", argID)
+			args = append(args, string(t))
+			argID++
+		}
+		conditions = append(conditions, "type IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.AccountID != 0 {
+		conditions = append(conditions, fmt.Sprintf("(account_id = $%d OR to_account_id = $%d)", argID, argID))
+		args = append(args, f.AccountID)
+		argID++
+	}
+	if len(f.Categories) > 0 {
+		placeholders := make([]string, len(f.Categories))
+		for i, category := range f.Categories {
+			placeholders[i] = fmt.Sprintf("$%d", argID)
+			args = append(args, category)
+			argID++
+		}
+		conditions = append(conditions, "category IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.Currency != "" {
+		conditions = append(conditions, fmt.Sprintf("UPPER(currency) = $%d", argID))
+		args = append(args, strings.ToUpper(f.Currency))
+		argID++
+	}
+	if f.MinAmount != nil {
+		var condition string
+		condition, args, argID = f.amountCondition(">=", f.MinAmount, true, args, argID)
+		conditions = append(conditions, condition)
+	}
+	if f.MaxAmount != nil {
+		var condition string
+		condition, args, argID = f.amountCondition("<=", f.MaxAmount, false, args, argID)
+		conditions = append(conditions, condition)
+	}
+	if f.IsClaimable != nil {
+		conditions = append(conditions, fmt.Sprintf("is_claimable = $%d", argID))
+		args = append(args, *f.IsClaimable)
+		argID++
+	}
+	if len(f.ClaimStatuses) > 0 {
+		placeholders := make([]string, len(f.ClaimStatuses))
+		for i, status := range f.ClaimStatuses {
+			placeholders[i] = fmt.Sprintf("$%d", argID)
+			args = append(args, string(status))
+			argID++
+		}
+		conditions = append(conditions, "claim_status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.PaidForFamily != nil {
+		conditions = append(conditions, fmt.Sprintf("paid_for_family = $%d", argID))
+		args = append(args, *f.PaidForFamily)
+		argID++
+	}
+	if f.Query != "" {
+		conditions = append(conditions, fmt.Sprintf(`LOWER(name) LIKE $%d ESCAPE '\'`, argID))
+		args = append(args, "%"+escapeLike(strings.ToLower(f.Query))+"%")
+		argID++
+	}
+	if len(f.Tags) > 0 {
+		var condition string
+		condition, args, argID = tagCondition(f.Tags, f.TagMatch, args, argID)
+		conditions = append(conditions, condition)
+	}
+	return conditions, args, argID
+}
+
+// amountCondition compares amount_minor with a decimal bound. Amounts are stored in minor units,
+// so the bound is scaled by the exponent of each row's currency: with a currency filter there is
+// a single exponent, otherwise a CASE picks the scaled bound per currency group.
+func (f TransactionFilter) amountCondition(op string, bound *big.Rat, roundUp bool, args []interface{}, argID int) (string, []interface{}, int) {
+	if f.Currency != "" {
+		args = append(args, money.ToMinor(bound, money.Exponent(f.Currency), roundUp))
+		return fmt.Sprintf("amount_minor %s $%d", op, argID), args, argID + 1
+	}
+
+	groups := money.Currencies()
+	exps := make([]int, 0, len(groups))
+	for exp := range groups {
+		exps = append(exps, exp)
+	}
+	sort.Ints(exps)
+
+	var condition strings.Builder
+	condition.WriteString("amount_minor " + op + " CASE")
+	for _, exp := range exps {
+		// The currency codes come from the money package, never from the request.
+		condition.WriteString(fmt.Sprintf(" WHEN UPPER(currency) IN ('%s') THEN $%d", strings.Join(groups[exp], "', '"), argID))
+		args = append(args, money.ToMinor(bound, exp, roundUp))
+		argID++
+	}
+	condition.WriteString(fmt.Sprintf(" ELSE $%d END", argID))
+	args = append(args, money.ToMinor(bound, money.Exponent(""), roundUp))
+	return condition.String(), args, argID + 1
+}
+
+// matches reports whether a transaction passes the filter, mirroring conditions for the memory store.
+func (f TransactionFilter) matches(t transaction.Transaction) bool {
+	if !f.DateRange.contains(t.Date) {
+		return false
+	}
+	if len(f.Types) > 0 && !containsType(f.Types, t.TransactionType()) {
+		return false
+	}
+	if f.AccountID != 0 && t.AccountID != f.AccountID && t.ToAccountID != f.AccountID {
+		return false
+	}
+	if len(f.Categories) > 0 && !containsString(f.Categories, t.Category) {
+		return false
+	}
+	if f.Currency != "" && !strings.EqualFold(f.Currency, t.Currency) {
+		return false
+	}
+	exp := money.Exponent(t.Currency)
+	if f.MinAmount != nil && t.Amount < money.ToMinor(f.MinAmount, exp, true) {
+		return false
+	}
+	if f.MaxAmount != nil && t.Amount > money.ToMinor(f.MaxAmount, exp, false) {
+		return false
+	}
+	if f.IsClaimable != nil && t.IsClaimable != *f.IsClaimable {
+		return false
+	}
+	if len(f.ClaimStatuses) > 0 && !containsClaimStatus(f.ClaimStatuses, t.CurrentClaimStatus()) {
+		return false
+	}
+	if f.PaidForFamily != nil && t.PaidForFamily != *f.PaidForFamily {
+		return false
+	}
+	if f.Query != "" && !strings.Contains(strings.ToLower(t.Name), strings.ToLower(f.Query)) {
+		return false
+	}
+	if len(f.Tags) > 0 && !matchesTags(t, f.Tags, f.TagMatch) {
+		return false
+	}
+	return true
+}
+
+// escapeLike escapes the LIKE wildcards so the query is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// containsType reports whether the slice contains the type.
+func containsType(types []transaction.Type, t transaction.Type) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// containsClaimStatus reports whether the slice contains the status.
+func containsClaimStatus(statuses []transaction.ClaimStatus, status transaction.ClaimStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether the slice contains the value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/config"
+	"main/pkg/frequent"
+	"main/pkg/transaction"
+)
+
+// ErrFrequentExpenseExists is returned when a frequent expense would duplicate the name of another one of the user's.
+var ErrFrequentExpenseExists = errors.New("a frequent expense with this name already exists")
+
+// frequentExpenseColumns lists the frequent expense columns read by scanFrequentExpense, in order.
+const frequentExpenseColumns = `id, user_id, name, category, currency, is_claimable, paid_for_family, account_id, created_at`
+
+// scanFrequentExpense reads a row selected with frequentExpenseColumns.
+func scanFrequentExpense(row rowScanner) (frequent.Expense, error) {
+	var e frequent.Expense
+	var accountID sql.NullInt64 // NULL without an account
+	err := row.Scan(&e.ID, &e.UserID, &e.Name, &e.Category, &e.Currency, &e.IsClaimable, &e.PaidForFamily, &accountID, &e.CreatedAt)
+	e.AccountID = accountID.Int64
+	return e, err
+}
+
+// SeedFrequentExpenses creates the configured frequent expenses for a user who has none yet.
+// Accounts are looked up by name among the user's accounts; unknown ones are left out.
+func (s *SQLStore) SeedFrequentExpenses(userID int64, seeds []config.FrequentExpense) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	seeded, err := seedFrequentExpenses(tx, userID, seeds)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Printf("Error seeding frequent expenses of user %d: %v", userID, err)
+		return fmt.Errorf("database insert of seed frequent expenses failed: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit seed frequent expenses: %w", err)
+	}
+
+	if seeded > 0 {
+		log.Printf("Successfully seeded %d frequent expenses for user %d", seeded, userID)
+	}
+	return nil
+}
+
+// seedFrequentExpenses inserts the seeds within a database transaction unless the user has frequent expenses,
+// and returns the number of frequent expenses created. Invalid seeds and repeated names are skipped.
+func seedFrequentExpenses(tx *sql.Tx, userID int64, seeds []config.FrequentExpense) (int, error) {
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM frequent_expenses WHERE user_id = $1`, userID).Scan(&existing); err != nil {
+		return 0, err
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+	var seeded []frequent.Expense
+	for i, e := range frequent.FromConfig(seeds) {
+		if _, repeated := frequent.Find(seeded, e.Name); repeated || e.Validate() != nil {
+			continue
+		}
+		if seeds[i].Account != "" {
+			err := tx.QueryRow(`SELECT id FROM accounts WHERE user_id = $1 AND LOWER(name) = LOWER($2)`, userID, seeds[i].Account).Scan(&e.AccountID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return 0, err
+			}
+		}
+		_, err := tx.Exec(`
+            INSERT INTO frequent_expenses (user_id, name, category, currency, is_claimable, paid_for_family, account_id)
+            VALUES ($1, $2, $3, $4, $5, $6, $7);
+        `, userID, e.Name, e.Category, e.Currency, e.IsClaimable, e.PaidForFamily, nullID(e.AccountID))
+		if err != nil {
+			return 0, err
+		}
+		seeded = append(seeded, e)
+	}
+	return len(seeded), nil
+}
+
+// InsertFrequentExpense saves a new frequent expense and returns its ID. It returns ErrFrequentExpenseExists
+// if the user already has one with the name and ErrAccountNotFound if the user has no such account.
+func (s *SQLStore) InsertFrequentExpense(e frequent.Expense) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	var id int64
+	err = checkFrequentExpense(tx, e)
+	if err == nil {
+		err = tx.QueryRow(`
+            INSERT INTO frequent_expenses (user_id, name, category, currency, is_claimable, paid_for_family, account_id)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)
+            RETURNING id;
+        `, e.UserID, e.Name, e.Category, e.Currency, e.IsClaimable, e.PaidForFamily, nullID(e.AccountID)).Scan(&id)
+		if err != nil {
+			log.Printf("Error inserting frequent expense %s: %v", e.Name, err)
+			err = fmt.Errorf("database insert of frequent expense failed: %w", err)
+		}
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit frequent expense insert: %w", err)
+	}
+
+	log.Printf("Successfully inserted frequent expense with ID: %d", id)
+	return id, nil
+}
+
+// UpdateFrequentExpense overwrites a frequent expense owned by e.UserID. It returns ErrNotFound if there is
+// no such frequent expense, ErrFrequentExpenseExists if the new name is taken and ErrAccountNotFound.
+func (s *SQLStore) UpdateFrequentExpense(e frequent.Expense) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	err = checkFrequentExpense(tx, e)
+	if err == nil {
+		var result sql.Result
+		result, err = tx.Exec(`
+            UPDATE frequent_expenses
+            SET name = $1, category = $2, currency = $3, is_claimable = $4, paid_for_family = $5, account_id = $6
+            WHERE id = $7 AND user_id = $8;
+        `, e.Name, e.Category, e.Currency, e.IsClaimable, e.PaidForFamily, nullID(e.AccountID), e.ID, e.UserID)
+		if err == nil {
+			err = expectAffected(result)
+		} else {
+			log.Printf("Error updating frequent expense %d: %v", e.ID, err)
+			err = fmt.Errorf("database update of frequent expense failed: %w", err)
+		}
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit frequent expense update: %w", err)
+	}
+
+	log.Printf("Successfully updated frequent expense with ID: %d", e.ID)
+	return nil
+}
+
+// checkFrequentExpense returns ErrFrequentExpenseExists if another of the user's frequent expenses has the
+// name of e, ignoring case, and ErrAccountNotFound unless its account is owned by the user.
+func checkFrequentExpense(tx *sql.Tx, e frequent.Expense) error {
+	var otherID int64
+	err := tx.QueryRow(`SELECT id FROM frequent_expenses WHERE user_id = $1 AND LOWER(name) = LOWER($2) AND id <> $3`,
+		e.UserID, e.Name, e.ID).Scan(&otherID)
+	if err == nil {
+		return ErrFrequentExpenseExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error checking frequent expense %s for duplicates: %v", e.Name, err)
+		return fmt.Errorf("database query for frequent expenses failed: %w", err)
+	}
+	return checkAccounts(tx, transaction.Transaction{UserID: e.UserID, AccountID: e.AccountID})
+}
+
+// DeleteFrequentExpense removes a frequent expense owned by the user, or returns ErrNotFound.
+func (s *SQLStore) DeleteFrequentExpense(userID, id int64) error {
+	result, err := s.db.Exec(`DELETE FROM frequent_expenses WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Printf("Error deleting frequent expense %d: %v", id, err)
+		return fmt.Errorf("database delete of frequent expense failed: %w", err)
+	}
+	if err = expectAffected(result); err != nil {
+		return err
+	}
+
+	log.Printf("Successfully deleted frequent expense with ID: %d", id)
+	return nil
+}
+
+// GetFrequentExpense returns a frequent expense owned by the user, or ErrNotFound.
+func (s *SQLStore) GetFrequentExpense(userID, id int64) (frequent.Expense, error) {
+	querySQL := `SELECT ` + frequentExpenseColumns + ` FROM frequent_expenses WHERE id = $1 AND user_id = $2`
+
+	e, err := scanFrequentExpense(s.db.QueryRow(querySQL, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return frequent.Expense{}, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error querying frequent expense %d: %v", id, err)
+		return frequent.Expense{}, fmt.Errorf("database query for frequent expense failed: %w", err)
+	}
+	return e, nil
+}
+
+// GetFrequentExpenses returns the user's frequent expenses ordered by name.
+func (s *SQLStore) GetFrequentExpenses(userID int64) ([]frequent.Expense, error) {
+	querySQL := `SELECT ` + frequentExpenseColumns + ` FROM frequent_expenses WHERE user_id = $1 ORDER BY name`
+
+	rows, err := s.db.Query(querySQL, userID)
+	if err != nil {
+		log.Printf("Error querying frequent expenses: %v", err)
+		return nil, fmt.Errorf("database query for frequent expenses failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for frequent expenses: %v", err)
+		}
+	}(rows)
+
+	expenses := []frequent.Expense{}
+	for rows.Next() {
+		e, err := scanFrequentExpense(rows)
+		if err != nil {
+			log.Printf("Error scanning frequent expense row: %v", err)
+			return nil, fmt.Errorf("failed to scan frequent expense row: %w", err)
+		}
+		expenses = append(expenses, e)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating frequent expense rows: %v", err)
+		return nil, fmt.Errorf("error during frequent expense row iteration: %w", err)
+	}
+	return expenses, nil
+}
+
+// GetExpenseHistory returns the name, category, currency and amount of the user's expenses dated on or after
+// the date (YYYY-MM-DD), for suggesting frequent expenses.
+func (s *SQLStore) GetExpenseHistory(userID int64, since string) ([]frequent.Occurrence, error) {
+	querySQL := `
+        SELECT COALESCE(name, ''), COALESCE(category, ''), COALESCE(currency, ''), amount_minor
+        FROM transactions
+        WHERE user_id = $1 AND type = $2 AND date >= $3`
+
+	rows, err := s.db.Query(querySQL, userID, string(transaction.TypeExpense), since)
+	if err != nil {
+		log.Printf("Error querying expense history: %v", err)
+		return nil, fmt.Errorf("database query for expense history failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for expense history: %v", err)
+		}
+	}(rows)
+
+	var history []frequent.Occurrence
+	for rows.Next() {
+		var o frequent.Occurrence
+		if err := rows.Scan(&o.Name, &o.Category, &o.Currency, &o.Amount); err != nil {
+			log.Printf("Error scanning expense history row: %v", err)
+			return nil, fmt.Errorf("failed to scan expense history row: %w", err)
+		}
+		history = append(history, o)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating expense history rows: %v", err)
+		return nil, fmt.Errorf("error during expense history row iteration: %w", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrIdempotencyKeyUsed is returned when an idempotency key was already used within its lifetime.
+var ErrIdempotencyKeyUsed = errors.New("idempotency key already used")
+
+// IdempotencyRecord is a request made with an idempotency key and, once it finished, its response.
+type IdempotencyRecord struct {
+	UserID      int64
+	Key         string
+	RequestHash string // Tells a repeat of the request from another request with the same key
+	StatusCode  int    // 0 while the request is being processed
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// ReserveIdempotencyKey records that the request of rec is being processed and forgets the keys created before
+// the date. If the user's key was created after it, the record of that request is returned with ErrIdempotencyKeyUsed.
+func (s *SQLStore) ReserveIdempotencyKey(rec IdempotencyRecord, since time.Time) (IdempotencyRecord, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return IdempotencyRecord{}, fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	existing, err := reserveIdempotencyKey(tx, rec, since)
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, ErrIdempotencyKeyUsed) {
+			return existing, err
+		}
+		// A concurrent request may have reserved the key first.
+		if existing, getErr := s.getIdempotencyRecord(rec.UserID, rec.Key); getErr == nil {
+			return existing, ErrIdempotencyKeyUsed
+		}
+		log.Printf("Error reserving idempotency key: %v", err)
+		return IdempotencyRecord{}, fmt.Errorf("database insert of idempotency key failed: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return IdempotencyRecord{}, fmt.Errorf("failed to commit idempotency key: %w", err)
+	}
+	return rec, nil
+}
+
+// reserveIdempotencyKey inserts the record within a database transaction unless the key is in use.
+// Times are stored in UTC, so that SQLite compares them as text in the right order.
+func reserveIdempotencyKey(tx *sql.Tx, rec IdempotencyRecord, since time.Time) (IdempotencyRecord, error) {
+	if _, err := tx.Exec(`DELETE FROM idempotency_keys WHERE created_at < $1`, since.UTC()); err != nil {
+		return IdempotencyRecord{}, err
+	}
+	existing, err := scanIdempotencyRecord(tx.QueryRow(`SELECT `+idempotencyColumns+` FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`,
+		rec.UserID, rec.Key))
+	if err == nil {
+		return existing, ErrIdempotencyKeyUsed
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return IdempotencyRecord{}, err
+	}
+	_, err = tx.Exec(`INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at) VALUES ($1, $2, $3, $4)`,
+		rec.UserID, rec.Key, rec.RequestHash, rec.CreatedAt.UTC())
+	return IdempotencyRecord{}, err
+}
+
+// CompleteIdempotencyKey stores the response to the request reserved with the user's key.
+func (s *SQLStore) CompleteIdempotencyKey(rec IdempotencyRecord) error {
+	_, err := s.db.Exec(`UPDATE idempotency_keys SET status_code = $1, response_body = $2 WHERE user_id = $3 AND idempotency_key = $4`,
+		rec.StatusCode, string(rec.Body), rec.UserID, rec.Key)
+	if err != nil {
+		log.Printf("Error storing the response of idempotency key: %v", err)
+		return fmt.Errorf("database update of idempotency key failed: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets the user's key, so that the request can be made again.
+func (s *SQLStore) ReleaseIdempotencyKey(userID int64, key string) error {
+	if _, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`, userID, key); err != nil {
+		log.Printf("Error releasing idempotency key: %v", err)
+		return fmt.Errorf("database delete of idempotency key failed: %w", err)
+	}
+	return nil
+}
+
+// idempotencyColumns lists the idempotency key columns read by scanIdempotencyRecord, in order.
+const idempotencyColumns = `user_id, idempotency_key, request_hash, status_code, response_body, created_at`
+
+// scanIdempotencyRecord reads a row selected with idempotencyColumns.
+func scanIdempotencyRecord(row rowScanner) (IdempotencyRecord, error) {
+	var rec IdempotencyRecord
+	var body string
+	err := row.Scan(&rec.UserID, &rec.Key, &rec.RequestHash, &rec.StatusCode, &body, &rec.CreatedAt)
+	rec.Body = []byte(body)
+	return rec, err
+}
+
+// getIdempotencyRecord returns the record of the user's key.
+func (s *SQLStore) getIdempotencyRecord(userID int64, key string) (IdempotencyRecord, error) {
+	return scanIdempotencyRecord(s.db.QueryRow(`SELECT `+idempotencyColumns+` FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`,
+		userID, key))
+}
+
+// ReserveIdempotencyKey records that the request of rec is being processed, or returns the record of the key
+// with ErrIdempotencyKeyUsed if it was created after the date.
+func (s *MemoryStore) ReserveIdempotencyKey(rec IdempotencyRecord, since time.Time) (IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.idempotencyKeys[:0]
+	for _, existing := range s.idempotencyKeys {
+		if !existing.CreatedAt.Before(since) {
+			kept = append(kept, existing)
+		}
+	}
+	s.idempotencyKeys = kept
+	if i := s.idempotencyIndex(rec.UserID, rec.Key); i >= 0 {
+		return s.idempotencyKeys[i], ErrIdempotencyKeyUsed
+	}
+	rec.StatusCode, rec.Body = 0, nil
+	s.idempotencyKeys = append(s.idempotencyKeys, rec)
+	return rec, nil
+}
+
+// CompleteIdempotencyKey stores the response to the request reserved with the user's key.
+func (s *MemoryStore) CompleteIdempotencyKey(rec IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.idempotencyIndex(rec.UserID, rec.Key); i >= 0 {
+		s.idempotencyKeys[i].StatusCode = rec.StatusCode
+		s.idempotencyKeys[i].Body = append([]byte{}, rec.Body...)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets the user's key.
+func (s *MemoryStore) ReleaseIdempotencyKey(userID int64, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.idempotencyIndex(userID, key); i >= 0 {
+		s.idempotencyKeys = append(s.idempotencyKeys[:i], s.idempotencyKeys[i+1:]...)
+	}
+	return nil
+}
+
+// idempotencyIndex returns the position of the user's key, or -1. Callers must hold the lock.
+func (s *MemoryStore) idempotencyIndex(userID int64, key string) int {
+	for i, rec := range s.idempotencyKeys {
+		if rec.UserID == userID && rec.Key == key {
+			return i
+		}
+	}
+	return -1
+}
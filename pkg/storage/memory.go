@@ -0,0 +1,1056 @@
+package storage
+
+import (
+	"fmt"
+	"main/pkg/account"
+	"main/pkg/budget"
+	"main/pkg/category"
+	"main/pkg/config"
+	"main/pkg/exchange"
+	"main/pkg/frequent"
+	"main/pkg/recurring"
+	"main/pkg/transaction"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a TransactionStore that keeps everything in process memory.
+// It is meant for tests and demos; nothing is persisted across restarts.
+type MemoryStore struct {
+	mu               sync.RWMutex
+	transactions     []transaction.Transaction
+	nextID           int64
+	rates            []exchange.Rate
+	attachments      []transaction.Attachment
+	nextAttachID     int64
+	rules            []recurring.Rule
+	nextRuleID       int64
+	budgets          []budget.Budget
+	nextBudgetID     int64
+	accounts         []account.Account
+	nextAccountID    int64
+	categories       []category.Category
+	nextCategoryID   int64
+	frequentExpenses []frequent.Expense
+	nextFrequentID   int64
+	idempotencyKeys  []IdempotencyRecord
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1, nextAttachID: 1, nextRuleID: 1, nextBudgetID: 1, nextAccountID: 1, nextCategoryID: 1, nextFrequentID: 1}
+}
+
+// InsertTransaction stores a copy of the transaction and returns its assigned ID.
+func (s *MemoryStore) InsertTransaction(t transaction.Transaction) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAccounts(t); err != nil {
+		return 0, err
+	}
+	return s.insertTransaction(t), nil
+}
+
+// InsertTransactions stores copies of the transactions and returns their assigned IDs, in order.
+// If an account of one of them does not exist, none are stored and a *BatchError tells which.
+func (s *MemoryStore) InsertTransactions(ts []transaction.Transaction) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range ts {
+		if err := s.checkAccounts(t); err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+	}
+	ids := make([]int64, 0, len(ts))
+	for _, t := range ts {
+		ids = append(ids, s.insertTransaction(t))
+	}
+	return ids, nil
+}
+
+// insertTransaction stores a copy of a checked transaction and returns its ID. Callers must hold the lock.
+func (s *MemoryStore) insertTransaction(t transaction.Transaction) int64 {
+	t.ID = s.nextID
+	s.nextID++
+	t.Tags = append([]string{}, t.Tags...) // Never share the caller's slice
+	t.ClaimStatus = t.CurrentClaimStatus()
+	t.Type = t.TransactionType()
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	s.transactions = append(s.transactions, t)
+	return t.ID
+}
+
+// GetTransaction returns a single transaction owned by the user, or ErrNotFound.
+func (s *MemoryStore) GetTransaction(userID, id int64) (transaction.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.indexOf(userID, id)
+	if i < 0 {
+		return transaction.Transaction{}, ErrNotFound
+	}
+	return s.transactions[i], nil
+}
+
+// UpdateTransaction overwrites a transaction owned by t.UserID, or returns ErrNotFound.
+// The creation time and the recurring rule are kept from the stored transaction.
+func (s *MemoryStore) UpdateTransaction(t transaction.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(t.UserID, t.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if err := s.checkAccounts(t); err != nil {
+		return err
+	}
+	stored := s.transactions[i]
+	t.CreatedAt, t.RecurringRuleID = stored.CreatedAt, stored.RecurringRuleID
+	t.Type = t.TransactionType()
+	t.Tags = append([]string{}, t.Tags...) // Never share the caller's slice
+	// Like the SQL store, the claim only changes through UpdateClaim.
+	t.ClaimStatus, t.ClaimReference, t.ClaimSubmittedAt, t.ClaimResolvedAt = "", "", nil, nil
+	if t.IsClaimable {
+		t.ClaimStatus, t.ClaimReference = stored.CurrentClaimStatus(), stored.ClaimReference
+		t.ClaimSubmittedAt, t.ClaimResolvedAt = stored.ClaimSubmittedAt, stored.ClaimResolvedAt
+	}
+	s.transactions[i] = t
+	return nil
+}
+
+// UpdateClaim moves the claim of a transaction owned by the user along the claim lifecycle.
+func (s *MemoryStore) UpdateClaim(userID, id int64, status transaction.ClaimStatus, reference string, at time.Time) (transaction.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(userID, id)
+	if i < 0 {
+		return transaction.Transaction{}, ErrNotFound
+	}
+	t := s.transactions[i]
+	if err := t.MoveClaim(status, reference, at); err != nil {
+		return transaction.Transaction{}, err
+	}
+	s.transactions[i] = t
+	return t, nil
+}
+
+// DeleteTransaction removes a transaction owned by the user, or returns ErrNotFound.
+func (s *MemoryStore) DeleteTransaction(userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(userID, id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.transactions = append(s.transactions[:i], s.transactions[i+1:]...)
+
+	// Like the ON DELETE CASCADE of the SQL schema.
+	kept := s.attachments[:0]
+	for _, a := range s.attachments {
+		if a.TransactionID != id {
+			kept = append(kept, a)
+		}
+	}
+	s.attachments = kept
+	return nil
+}
+
+// indexOf returns the position of the user's transaction, or -1. Callers must hold the lock.
+func (s *MemoryStore) indexOf(userID, id int64) int {
+	for i, t := range s.transactions {
+		if t.ID == id && t.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetAllTransactions returns one page of the user's matching transactions, paged like the SQL store.
+func (s *MemoryStore) GetAllTransactions(userID int64, filter TransactionFilter, req PageRequest) (TransactionPage, error) {
+	if _, err := req.Sort.column(); err != nil {
+		return TransactionPage{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []transaction.Transaction
+	for _, t := range s.transactions {
+		if t.UserID == userID && filter.matches(t) {
+			matched = append(matched, t)
+		}
+	}
+	total := len(matched)
+
+	// Backward pages are collected in reverse order and flipped by finishPage.
+	backward, hasBefore, offset := false, false, 0
+	descending := req.Sort.Descending
+	var after *transaction.Transaction
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor, req.Sort)
+		if err != nil {
+			return TransactionPage{}, err
+		}
+		backward, hasBefore = c.Backward, true
+		if backward {
+			descending = !descending
+		}
+		position := c.position()
+		after = &position
+	} else if req.Page > 1 {
+		offset = (req.Page - 1) * req.Limit
+		hasBefore = true
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		c := compareTransactions(req.Sort, matched[i], matched[j])
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	var rows []transaction.Transaction
+	for _, t := range matched {
+		if after != nil {
+			c := compareTransactions(req.Sort, t, *after)
+			if (descending && c >= 0) || (!descending && c <= 0) {
+				continue
+			}
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		rows = append(rows, t)
+		if len(rows) > req.Limit {
+			break
+		}
+	}
+
+	page := finishPage(rows, req, backward, hasBefore)
+	if req.IncludeTotal {
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// GetTotals returns the user's summed amounts per group and currency for expenses dated within the range,
+// or for transactions of every type when grouping by type.
+func (s *MemoryStore) GetTotals(userID int64, groupBy GroupBy, dateRange DateRange) ([]Total, error) {
+	if _, err := groupBy.column(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type key struct{ group, currency, baseCurrency string }
+	sums := make(map[key]*Total)
+	var order []key
+	for _, t := range s.transactions {
+		if t.UserID != userID || !dateRange.contains(t.Date) {
+			continue
+		}
+		if groupBy != GroupByType && t.TransactionType() != transaction.TypeExpense {
+			continue
+		}
+		for _, group := range groupBy.values(t) {
+			k := key{group: group, currency: t.Currency, baseCurrency: t.BaseCurrency}
+			if _, seen := sums[k]; !seen {
+				sums[k] = &Total{Group: k.group, Currency: k.currency, BaseCurrency: k.baseCurrency}
+				order = append(order, k)
+			}
+			sums[k].Amount += t.Amount
+			sums[k].BaseAmount += t.BaseAmount
+		}
+	}
+
+	totals := make([]Total, 0, len(order))
+	for _, k := range order {
+		totals = append(totals, *sums[k])
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Amount > totals[j].Amount })
+	return totals, nil
+}
+
+// UpsertExchangeRates saves rates, replacing existing rates for the same day and currency pair.
+func (s *MemoryStore) UpsertExchangeRates(rates []exchange.Rate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+next:
+	for _, rate := range rates {
+		for i, existing := range s.rates {
+			if existing.Date == rate.Date && existing.Base == rate.Base && existing.Quote == rate.Quote {
+				s.rates[i] = rate
+				continue next
+			}
+		}
+		s.rates = append(s.rates, rate)
+	}
+	return nil
+}
+
+// GetExchangeRates returns, for every currency pair, the most recent rate on or before the date.
+func (s *MemoryStore) GetExchangeRates(date string) ([]exchange.Rate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type pair struct{ base, quote string }
+	latest := make(map[pair]int)
+	var order []pair
+	for i, rate := range s.rates {
+		if rate.Date > date {
+			continue
+		}
+		p := pair{rate.Base, rate.Quote}
+		j, seen := latest[p]
+		if !seen {
+			order = append(order, p)
+		}
+		if !seen || rate.Date > s.rates[j].Date {
+			latest[p] = i
+		}
+	}
+
+	rates := make([]exchange.Rate, 0, len(order))
+	for _, p := range order {
+		rates = append(rates, s.rates[latest[p]])
+	}
+	return rates, nil
+}
+
+// InsertAttachment links an attachment to a transaction owned by a.UserID and returns its ID.
+func (s *MemoryStore) InsertAttachment(a transaction.Attachment) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOf(a.UserID, a.TransactionID) < 0 {
+		return 0, ErrNotFound
+	}
+	a.ID = s.nextAttachID
+	s.nextAttachID++
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	s.attachments = append(s.attachments, a)
+	return a.ID, nil
+}
+
+// GetAttachments returns the attachments of a transaction owned by the user, oldest first.
+func (s *MemoryStore) GetAttachments(userID, transactionID int64) ([]transaction.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attachments := []transaction.Attachment{}
+	for _, a := range s.attachments {
+		if a.UserID == userID && a.TransactionID == transactionID {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments, nil
+}
+
+// GetAttachment returns one attachment of a transaction owned by the user, or ErrNotFound.
+func (s *MemoryStore) GetAttachment(userID, transactionID, id int64) (transaction.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, a := range s.attachments {
+		if a.ID == id && a.UserID == userID && a.TransactionID == transactionID {
+			return a, nil
+		}
+	}
+	return transaction.Attachment{}, ErrNotFound
+}
+
+// InsertRecurringRule stores a copy of the rule and returns its assigned ID.
+func (s *MemoryStore) InsertRecurringRule(r recurring.Rule) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = s.nextRuleID
+	s.nextRuleID++
+	r.Tags = append([]string{}, r.Tags...)
+	r.LastRunDate = ""
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	s.rules = append(s.rules, r)
+	return r.ID, nil
+}
+
+// UpdateRecurringRule overwrites a rule owned by r.UserID, or returns ErrNotFound.
+// The last run date and creation time are kept from the stored rule.
+func (s *MemoryStore) UpdateRecurringRule(r recurring.Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.ruleIndex(r.UserID, r.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.Tags = append([]string{}, r.Tags...)
+	r.LastRunDate, r.CreatedAt = s.rules[i].LastRunDate, s.rules[i].CreatedAt
+	s.rules[i] = r
+	return nil
+}
+
+// DeleteRecurringRule removes a rule owned by the user and unlinks its transactions, or returns ErrNotFound.
+func (s *MemoryStore) DeleteRecurringRule(userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.ruleIndex(userID, id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.rules = append(s.rules[:i], s.rules[i+1:]...)
+	for j := range s.transactions {
+		if s.transactions[j].RecurringRuleID == id {
+			s.transactions[j].RecurringRuleID = 0
+		}
+	}
+	return nil
+}
+
+// GetRecurringRule returns a rule owned by the user, or ErrNotFound.
+func (s *MemoryStore) GetRecurringRule(userID, id int64) (recurring.Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.ruleIndex(userID, id)
+	if i < 0 {
+		return recurring.Rule{}, ErrNotFound
+	}
+	return s.rules[i], nil
+}
+
+// GetRecurringRules returns the user's rules, oldest first.
+func (s *MemoryStore) GetRecurringRules(userID int64) ([]recurring.Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := []recurring.Rule{}
+	for _, r := range s.rules {
+		if r.UserID == userID {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// GetDueRecurringRules returns the rules of every user that have started by the date
+// and have not been run up to it or to their end date yet.
+func (s *MemoryStore) GetDueRecurringRules(date string) ([]recurring.Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := []recurring.Rule{}
+	for _, r := range s.rules {
+		if r.StartDate <= date && (r.LastRunDate == "" || (r.LastRunDate < date && (r.EndDate == "" || r.LastRunDate < r.EndDate))) {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// RecordRecurringRun stores the transactions of occurrences the rule has not recorded yet
+// and marks it as run up to the date through. It returns the stored transactions.
+func (s *MemoryStore) RecordRecurringRun(rule recurring.Rule, due []transaction.Transaction, through string) ([]transaction.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.ruleIndex(rule.UserID, rule.ID)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	recorded := []transaction.Transaction{}
+next:
+	for _, t := range due {
+		for _, existing := range s.transactions {
+			if existing.RecurringRuleID == rule.ID && existing.Date == t.Date {
+				continue next
+			}
+		}
+		t.ID, t.UserID, t.RecurringRuleID = s.nextID, rule.UserID, rule.ID
+		s.nextID++
+		t.Tags = append([]string{}, t.Tags...)
+		t.ClaimStatus = t.CurrentClaimStatus()
+		t.Type = t.TransactionType()
+		t.CreatedAt = time.Now()
+		s.transactions = append(s.transactions, t)
+		recorded = append(recorded, t)
+	}
+	s.rules[i].LastRunDate = through
+	return recorded, nil
+}
+
+// ruleIndex returns the position of the user's recurring rule, or -1. Callers must hold the lock.
+func (s *MemoryStore) ruleIndex(userID, id int64) int {
+	for i, r := range s.rules {
+		if r.ID == id && r.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetBudget creates the user's budget for the category and currency, or replaces its amount, and returns its ID.
+func (s *MemoryStore) SetBudget(b budget.Budget) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.budgets {
+		if existing.UserID == b.UserID && existing.Category == b.Category && existing.Currency == b.Currency {
+			s.budgets[i].Amount = b.Amount
+			return existing.ID, nil
+		}
+	}
+	b.ID = s.nextBudgetID
+	s.nextBudgetID++
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = time.Now()
+	}
+	s.budgets = append(s.budgets, b)
+	return b.ID, nil
+}
+
+// UpdateBudget overwrites a budget owned by b.UserID, or returns ErrNotFound or ErrBudgetExists.
+func (s *MemoryStore) UpdateBudget(b budget.Budget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := -1
+	for j, existing := range s.budgets {
+		if existing.UserID != b.UserID {
+			continue
+		}
+		if existing.ID == b.ID {
+			i = j
+		} else if existing.Category == b.Category && existing.Currency == b.Currency {
+			return ErrBudgetExists
+		}
+	}
+	if i < 0 {
+		return ErrNotFound
+	}
+	b.CreatedAt = s.budgets[i].CreatedAt
+	s.budgets[i] = b
+	return nil
+}
+
+// DeleteBudget removes a budget owned by the user, or returns ErrNotFound.
+func (s *MemoryStore) DeleteBudget(userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, b := range s.budgets {
+		if b.ID == id && b.UserID == userID {
+			s.budgets = append(s.budgets[:i], s.budgets[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// GetBudget returns a budget owned by the user, or ErrNotFound.
+func (s *MemoryStore) GetBudget(userID, id int64) (budget.Budget, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, b := range s.budgets {
+		if b.ID == id && b.UserID == userID {
+			return b, nil
+		}
+	}
+	return budget.Budget{}, ErrNotFound
+}
+
+// GetBudgets returns the user's budgets ordered by category and currency.
+func (s *MemoryStore) GetBudgets(userID int64) ([]budget.Budget, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	budgets := []budget.Budget{}
+	for _, b := range s.budgets {
+		if b.UserID == userID {
+			budgets = append(budgets, b)
+		}
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		if budgets[i].Category != budgets[j].Category {
+			return budgets[i].Category < budgets[j].Category
+		}
+		return budgets[i].Currency < budgets[j].Currency
+	})
+	return budgets, nil
+}
+
+// InsertAccount stores a copy of the account and returns its assigned ID, or ErrAccountExists.
+func (s *MemoryStore) InsertAccount(a account.Account) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accountNameTaken(a) {
+		return 0, ErrAccountExists
+	}
+	a.ID = s.nextAccountID
+	s.nextAccountID++
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	s.accounts = append(s.accounts, a)
+	return a.ID, nil
+}
+
+// UpdateAccount overwrites an account owned by a.UserID, or returns ErrNotFound or ErrAccountExists.
+func (s *MemoryStore) UpdateAccount(a account.Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.accountIndex(a.UserID, a.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if s.accountNameTaken(a) {
+		return ErrAccountExists
+	}
+	a.CreatedAt = s.accounts[i].CreatedAt
+	s.accounts[i] = a
+	return nil
+}
+
+// DeleteAccount removes an account owned by the user and unlinks its transactions and frequent expenses,
+// or returns ErrNotFound.
+func (s *MemoryStore) DeleteAccount(userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.accountIndex(userID, id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.accounts = append(s.accounts[:i], s.accounts[i+1:]...)
+	for j := range s.transactions {
+		if s.transactions[j].AccountID == id {
+			s.transactions[j].AccountID = 0
+		}
+		if s.transactions[j].ToAccountID == id {
+			s.transactions[j].ToAccountID = 0
+		}
+	}
+	for j := range s.frequentExpenses {
+		if s.frequentExpenses[j].AccountID == id {
+			s.frequentExpenses[j].AccountID = 0
+		}
+	}
+	return nil
+}
+
+// GetAccount returns an account owned by the user, or ErrNotFound.
+func (s *MemoryStore) GetAccount(userID, id int64) (account.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.accountIndex(userID, id)
+	if i < 0 {
+		return account.Account{}, ErrNotFound
+	}
+	return s.accounts[i], nil
+}
+
+// GetAccounts returns the user's accounts ordered by name.
+func (s *MemoryStore) GetAccounts(userID int64) ([]account.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	accounts := []account.Account{}
+	for _, a := range s.accounts {
+		if a.UserID == userID {
+			accounts = append(accounts, a)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Name < accounts[j].Name })
+	return accounts, nil
+}
+
+// GetAccountTotals returns the daily net change of an account owned by the user per currency, oldest first.
+func (s *MemoryStore) GetAccountTotals(userID, accountID int64) ([]Total, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type key struct{ date, currency, baseCurrency string }
+	sums := make(map[key]*Total)
+	var order []key
+	for _, t := range s.transactions {
+		if t.UserID != userID || (t.AccountID != accountID && t.ToAccountID != accountID) {
+			continue
+		}
+		sign := int64(-1)
+		if t.ToAccountID == accountID || t.TransactionType() == transaction.TypeIncome {
+			sign = 1
+		}
+		k := key{date: t.Date, currency: t.Currency, baseCurrency: t.BaseCurrency}
+		if _, seen := sums[k]; !seen {
+			sums[k] = &Total{Group: k.date, Currency: k.currency, BaseCurrency: k.baseCurrency}
+			order = append(order, k)
+		}
+		sums[k].Amount += sign * t.Amount
+		sums[k].BaseAmount += sign * t.BaseAmount
+	}
+
+	totals := make([]Total, 0, len(order))
+	for _, k := range order {
+		totals = append(totals, *sums[k])
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Group < totals[j].Group })
+	return totals, nil
+}
+
+// accountIndex returns the position of the user's account, or -1. Callers must hold the lock.
+func (s *MemoryStore) accountIndex(userID, id int64) int {
+	for i, a := range s.accounts {
+		if a.ID == id && a.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// accountNameTaken reports whether another of the user's accounts has the name of a. Callers must hold the lock.
+func (s *MemoryStore) accountNameTaken(a account.Account) bool {
+	for _, existing := range s.accounts {
+		if existing.UserID == a.UserID && existing.Name == a.Name && existing.ID != a.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAccounts returns ErrAccountNotFound unless the accounts of the transaction are owned by its user.
+// Callers must hold the lock.
+func (s *MemoryStore) checkAccounts(t transaction.Transaction) error {
+	for _, id := range []int64{t.AccountID, t.ToAccountID} {
+		if id != 0 && s.accountIndex(t.UserID, id) < 0 {
+			return fmt.Errorf("%w: %d", ErrAccountNotFound, id)
+		}
+	}
+	return nil
+}
+
+// SeedCategories creates the seed categories for a user who has no categories yet.
+func (s *MemoryStore) SeedCategories(userID int64, seeds []category.Seed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.categories {
+		if c.UserID == userID {
+			return nil
+		}
+	}
+	ids := make(map[string]int64, len(seeds))
+	for _, seed := range seeds {
+		c := category.Category{ID: s.nextCategoryID, UserID: userID, Name: seed.Name, Type: seed.Type,
+			ParentID: ids[strings.ToLower(seed.Parent)], CreatedAt: time.Now()}
+		s.nextCategoryID++
+		s.categories = append(s.categories, c)
+		ids[strings.ToLower(seed.Name)] = c.ID
+	}
+	return nil
+}
+
+// InsertCategory stores a copy of the category and returns its assigned ID, or ErrCategoryExists or ErrInvalidParent.
+func (s *MemoryStore) InsertCategory(c category.Category) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkCategory(c); err != nil {
+		return 0, err
+	}
+	c.ID = s.nextCategoryID
+	s.nextCategoryID++
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	s.categories = append(s.categories, c)
+	return c.ID, nil
+}
+
+// UpdateCategory overwrites a category owned by c.UserID and renames what refers to it,
+// or returns ErrNotFound, ErrCategoryExists or ErrInvalidParent.
+func (s *MemoryStore) UpdateCategory(c category.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.categoryIndex(c.UserID, c.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if err := s.checkCategory(c); err != nil {
+		return err
+	}
+	if oldName := s.categories[i].Name; oldName != c.Name {
+		for j, t := range s.transactions {
+			if t.UserID == c.UserID && t.Category == oldName {
+				s.transactions[j].Category = c.Name
+			}
+		}
+		for j, b := range s.budgets {
+			if b.UserID == c.UserID && b.Category == oldName {
+				s.budgets[j].Category = c.Name
+			}
+		}
+		for j, r := range s.rules {
+			if r.UserID == c.UserID && r.Category == oldName {
+				s.rules[j].Category = c.Name
+			}
+		}
+		for j, e := range s.frequentExpenses {
+			if e.UserID == c.UserID && e.Category == oldName {
+				s.frequentExpenses[j].Category = c.Name
+			}
+		}
+	}
+	c.CreatedAt = s.categories[i].CreatedAt
+	s.categories[i] = c
+	return nil
+}
+
+// DeleteCategory removes a category owned by the user and makes its subcategories top-level, or returns ErrNotFound.
+func (s *MemoryStore) DeleteCategory(userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.categoryIndex(userID, id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.categories = append(s.categories[:i], s.categories[i+1:]...)
+	for j := range s.categories {
+		if s.categories[j].ParentID == id {
+			s.categories[j].ParentID = 0
+		}
+	}
+	return nil
+}
+
+// GetCategory returns a category owned by the user, or ErrNotFound.
+func (s *MemoryStore) GetCategory(userID, id int64) (category.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.categoryIndex(userID, id)
+	if i < 0 {
+		return category.Category{}, ErrNotFound
+	}
+	return s.categories[i], nil
+}
+
+// GetCategories returns the user's categories ordered by name.
+func (s *MemoryStore) GetCategories(userID int64) ([]category.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := []category.Category{}
+	for _, c := range s.categories {
+		if c.UserID == userID {
+			categories = append(categories, c)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+// categoryIndex returns the position of the user's category, or -1. Callers must hold the lock.
+func (s *MemoryStore) categoryIndex(userID, id int64) int {
+	for i, c := range s.categories {
+		if c.ID == id && c.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkCategory mirrors the checks of the SQL store's checkCategory. Callers must hold the lock.
+func (s *MemoryStore) checkCategory(c category.Category) error {
+	children := 0
+	for _, existing := range s.categories {
+		if existing.UserID != c.UserID {
+			continue
+		}
+		if existing.ID != c.ID && strings.EqualFold(existing.Name, c.Name) {
+			return ErrCategoryExists
+		}
+		if c.ID != 0 && existing.ParentID == c.ID {
+			if existing.Type != c.Type {
+				return fmt.Errorf("%w: its subcategories must have the same type", ErrInvalidParent)
+			}
+			children++
+		}
+	}
+	if c.ParentID == 0 {
+		return nil
+	}
+	if children > 0 {
+		return fmt.Errorf("%w: a category with subcategories cannot become a subcategory", ErrInvalidParent)
+	}
+	i := s.categoryIndex(c.UserID, c.ParentID)
+	if i < 0 {
+		return fmt.Errorf("%w: category %d not found", ErrInvalidParent, c.ParentID)
+	}
+	return checkParent(c, s.categories[i])
+}
+
+// SeedFrequentExpenses creates the configured frequent expenses for a user who has none yet.
+func (s *MemoryStore) SeedFrequentExpenses(userID int64, seeds []config.FrequentExpense) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.frequentExpenses {
+		if e.UserID == userID {
+			return nil
+		}
+	}
+	var seeded []frequent.Expense
+	for i, e := range frequent.FromConfig(seeds) {
+		if _, repeated := frequent.Find(seeded, e.Name); repeated || e.Validate() != nil {
+			continue
+		}
+		for _, a := range s.accounts {
+			if a.UserID == userID && seeds[i].Account != "" && strings.EqualFold(a.Name, seeds[i].Account) {
+				e.AccountID = a.ID
+			}
+		}
+		e.ID, e.UserID, e.CreatedAt = s.nextFrequentID, userID, time.Now()
+		s.nextFrequentID++
+		s.frequentExpenses = append(s.frequentExpenses, e)
+		seeded = append(seeded, e)
+	}
+	return nil
+}
+
+// InsertFrequentExpense stores a copy of the frequent expense and returns its assigned ID,
+// or ErrFrequentExpenseExists or ErrAccountNotFound.
+func (s *MemoryStore) InsertFrequentExpense(e frequent.Expense) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkFrequentExpense(e); err != nil {
+		return 0, err
+	}
+	e.ID = s.nextFrequentID
+	s.nextFrequentID++
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	s.frequentExpenses = append(s.frequentExpenses, e)
+	return e.ID, nil
+}
+
+// UpdateFrequentExpense overwrites a frequent expense owned by e.UserID,
+// or returns ErrNotFound, ErrFrequentExpenseExists or ErrAccountNotFound.
+func (s *MemoryStore) UpdateFrequentExpense(e frequent.Expense) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.frequentExpenseIndex(e.UserID, e.ID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if err := s.checkFrequentExpense(e); err != nil {
+		return err
+	}
+	e.CreatedAt = s.frequentExpenses[i].CreatedAt
+	s.frequentExpenses[i] = e
+	return nil
+}
+
+// DeleteFrequentExpense removes a frequent expense owned by the user, or returns ErrNotFound.
+func (s *MemoryStore) DeleteFrequentExpense(userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.frequentExpenseIndex(userID, id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.frequentExpenses = append(s.frequentExpenses[:i], s.frequentExpenses[i+1:]...)
+	return nil
+}
+
+// GetFrequentExpense returns a frequent expense owned by the user, or ErrNotFound.
+func (s *MemoryStore) GetFrequentExpense(userID, id int64) (frequent.Expense, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.frequentExpenseIndex(userID, id)
+	if i < 0 {
+		return frequent.Expense{}, ErrNotFound
+	}
+	return s.frequentExpenses[i], nil
+}
+
+// GetFrequentExpenses returns the user's frequent expenses ordered by name.
+func (s *MemoryStore) GetFrequentExpenses(userID int64) ([]frequent.Expense, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expenses := []frequent.Expense{}
+	for _, e := range s.frequentExpenses {
+		if e.UserID == userID {
+			expenses = append(expenses, e)
+		}
+	}
+	sort.Slice(expenses, func(i, j int) bool { return expenses[i].Name < expenses[j].Name })
+	return expenses, nil
+}
+
+// GetExpenseHistory returns the user's expenses dated on or after the date (YYYY-MM-DD).
+func (s *MemoryStore) GetExpenseHistory(userID int64, since string) ([]frequent.Occurrence, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var history []frequent.Occurrence
+	for _, t := range s.transactions {
+		if t.UserID == userID && t.TransactionType() == transaction.TypeExpense && t.Date >= since {
+			history = append(history, frequent.Occurrence{Name: t.Name, Category: t.Category, Currency: t.Currency, Amount: t.Amount})
+		}
+	}
+	return history, nil
+}
+
+// frequentExpenseIndex returns the position of the user's frequent expense, or -1. Callers must hold the lock.
+func (s *MemoryStore) frequentExpenseIndex(userID, id int64) int {
+	for i, e := range s.frequentExpenses {
+		if e.ID == id && e.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkFrequentExpense mirrors the checks of the SQL store's checkFrequentExpense. Callers must hold the lock.
+func (s *MemoryStore) checkFrequentExpense(e frequent.Expense) error {
+	for _, existing := range s.frequentExpenses {
+		if existing.UserID == e.UserID && existing.ID != e.ID && strings.EqualFold(existing.Name, e.Name) {
+			return ErrFrequentExpenseExists
+		}
+	}
+	return s.checkAccounts(transaction.Transaction{UserID: e.UserID, AccountID: e.AccountID})
+}
+
+// Close is a no-op for the in-memory store.
+func (s *MemoryStore) Close() error {
+	return nil
+}
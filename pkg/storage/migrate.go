@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"main/pkg/config"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles holds the numbered schema migrations, one directory per driver.
+// Files are named NNNN_description.up.sql and NNNN_description.down.sql.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer binary.
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+// migrationLockKey identifies the Postgres advisory lock that serialises migrations ("expenses" in ASCII).
+const migrationLockKey = 0x6578_7065_6e73_6573
+
+// migration is a single versioned schema change.
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// loadMigrations reads the embedded migrations for a driver, sorted by version.
+func loadMigrations(driver string) ([]migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations for driver %q: %w", driver, err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		versionStr, rest, found := strings.Cut(fileName, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name %q", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration version in %q", fileName)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", fileName, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: strings.TrimSuffix(rest, "."+direction+".sql")}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be contiguous, expected %d but found %d", i+1, m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates the schema_migrations bookkeeping table.
+func (s *SQLStore) ensureMigrationsTable() error {
+	err := s.inMigrationTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error creating schema_migrations table: %v", err)
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the highest applied migration version and the latest version
+// known to this binary.
+func (s *SQLStore) SchemaVersion() (current int, latest int, err error) {
+	migrations, err := loadMigrations(s.driver)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = s.ensureMigrationsTable(); err != nil {
+		return 0, 0, err
+	}
+
+	var version sql.NullInt64
+	err = s.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), len(migrations), nil
+}
+
+// MigrateUp applies every pending migration in order.
+// It refuses to run against a schema that is newer than this binary.
+func (s *SQLStore) MigrateUp() error {
+	current, latest, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > latest {
+		return fmt.Errorf("%w (database version %d, latest known %d)", ErrSchemaTooNew, current, latest)
+	}
+
+	migrations, err := loadMigrations(s.driver)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations[current:] {
+		found, err := s.applyMigration(m.Version-1, m.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			return err
+		})
+		switch {
+		case err != nil:
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		case found == m.Version-1:
+			log.Printf("Applied migration %d (%s).", m.Version, m.Name)
+		case found >= m.Version:
+			log.Printf("Migration %d (%s) was applied by another process.", m.Version, m.Name)
+		default:
+			return fmt.Errorf("migration %d (%s) failed: schema version changed to %d meanwhile", m.Version, m.Name, found)
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts the given number of most recently applied migrations.
+func (s *SQLStore) MigrateDown(steps int) error {
+	current, latest, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > latest {
+		return fmt.Errorf("%w (database version %d, latest known %d)", ErrSchemaTooNew, current, latest)
+	}
+
+	migrations, err := loadMigrations(s.driver)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < steps && current > 0; i++ {
+		m := migrations[current-1]
+		if m.Down == "" {
+			return fmt.Errorf("migration %d (%s) has no down script", m.Version, m.Name)
+		}
+		found, err := s.applyMigration(m.Version, m.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("reverting migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if found != m.Version {
+			return fmt.Errorf("reverting migration %d (%s) failed: schema version changed to %d meanwhile", m.Version, m.Name, found)
+		}
+		log.Printf("Reverted migration %d (%s).", m.Version, m.Name)
+		current--
+	}
+	return nil
+}
+
+// applyMigration runs a migration script and its bookkeeping in a single database transaction, if the schema
+// is still at version from once other runs are locked out. It returns the version the schema was found at,
+// which differs from from when another run migrated it meanwhile and nothing was applied.
+func (s *SQLStore) applyMigration(from int, script string, record func(tx *sql.Tx) error) (int, error) {
+	var found int
+	err := s.inMigrationTx(func(tx *sql.Tx) error {
+		var version sql.NullInt64
+		if err := tx.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+		found = int(version.Int64)
+		if found != from {
+			return nil
+		}
+		if _, err := tx.Exec(script); err != nil {
+			return err
+		}
+		if err := record(tx); err != nil {
+			return fmt.Errorf("failed to record migration: %w", err)
+		}
+		return nil
+	})
+	return found, err
+}
+
+// inMigrationTx runs fn in a database transaction that concurrent migration runs, such as the bot and the
+// server starting together, wait for. Postgres takes an advisory lock held until the transaction ends;
+// SQLite transactions begin by taking the write lock.
+func (s *SQLStore) inMigrationTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	if s.driver != config.DriverSQLite {
+		if _, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+	}
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,12 @@
+-- Baseline schema. IF NOT EXISTS keeps databases created before migrations were introduced working.
+CREATE TABLE IF NOT EXISTS transactions (
+    id SERIAL PRIMARY KEY,
+    name TEXT,
+    amount NUMERIC(10, 2),
+    currency VARCHAR(10),
+    date DATE,
+    is_claimable BOOLEAN,
+    paid_for_family BOOLEAN,
+    category TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,13 @@
+-- Rates are exact decimals: one unit of base_currency is worth rate units of quote_currency.
+CREATE TABLE exchange_rates (
+    date DATE NOT NULL,
+    base_currency VARCHAR(10) NOT NULL,
+    quote_currency VARCHAR(10) NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL,
+    PRIMARY KEY (base_currency, quote_currency, date)
+);
+
+-- The conversion of each transaction into the reporting currency, using the rate in effect on its date.
+ALTER TABLE transactions ADD COLUMN base_currency VARCHAR(10);
+ALTER TABLE transactions ADD COLUMN exchange_rate NUMERIC(20, 10);
+ALTER TABLE transactions ADD COLUMN base_amount_minor BIGINT;
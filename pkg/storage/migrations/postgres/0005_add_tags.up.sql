@@ -0,0 +1,14 @@
+-- Tags are free-form labels such as "japan-trip-2025", owned by a user and shared by any number of transactions.
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE transaction_tags (
+    transaction_id INTEGER NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (transaction_id, tag_id)
+);
+CREATE INDEX idx_transaction_tags_tag ON transaction_tags (tag_id);
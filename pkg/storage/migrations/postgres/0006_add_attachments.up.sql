@@ -0,0 +1,12 @@
+-- Files such as receipt photos linked to a transaction. The content is kept in the blob store under blob_key.
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    file_name TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    blob_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_attachments_transaction ON attachments (transaction_id);
@@ -0,0 +1,25 @@
+-- Recurring rules record a transaction on every occurrence of their schedule, see package recurring.
+-- last_run_date is the day up to which occurrences have been recorded.
+CREATE TABLE recurring_rules (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    is_claimable BOOLEAN NOT NULL DEFAULT FALSE,
+    paid_for_family BOOLEAN NOT NULL DEFAULT FALSE,
+    tags TEXT NOT NULL DEFAULT '', -- Normalised tags, comma-separated
+    frequency VARCHAR(10) NOT NULL,
+    interval_count INTEGER NOT NULL DEFAULT 1,
+    cron VARCHAR(100) NOT NULL DEFAULT '',
+    start_date DATE NOT NULL,
+    end_date DATE,
+    last_run_date DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_recurring_rules_user ON recurring_rules (user_id);
+
+-- A rule records at most one transaction per day, so catching up after downtime never duplicates.
+ALTER TABLE transactions ADD COLUMN recurring_rule_id INTEGER REFERENCES recurring_rules (id) ON DELETE SET NULL;
+CREATE UNIQUE INDEX idx_transactions_recurring_run ON transactions (recurring_rule_id, date);
@@ -0,0 +1,11 @@
+-- Monthly spending limits per category. A budget in the reporting base currency covers spending in
+-- every currency, a budget in another currency only spending in that currency; see package budget.
+CREATE TABLE budgets (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    category TEXT NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, category, currency)
+);
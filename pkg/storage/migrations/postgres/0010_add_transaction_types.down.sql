@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_type;
+ALTER TABLE transactions DROP COLUMN type;
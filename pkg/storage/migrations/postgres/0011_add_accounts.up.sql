@@ -0,0 +1,17 @@
+-- Accounts are the cards, cash, bank accounts and e-wallets that transactions are paid from or into.
+CREATE TABLE accounts (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    opening_balance_minor BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+-- account_id is the account paid from (or into, for income); transfers also move money into to_account_id.
+ALTER TABLE transactions ADD COLUMN account_id INTEGER REFERENCES accounts (id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN to_account_id INTEGER REFERENCES accounts (id) ON DELETE SET NULL;
+CREATE INDEX idx_transactions_account ON transactions (account_id, date);
+CREATE INDEX idx_transactions_to_account ON transactions (to_account_id, date);
@@ -0,0 +1,13 @@
+-- Categories replace the static list of config.yaml, which now only seeds the categories of new users.
+-- Transactions, budgets and recurring rules keep referring to their category by name.
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    type VARCHAR(10) NOT NULL DEFAULT 'expense',
+    parent_id INTEGER REFERENCES categories (id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX idx_categories_parent ON categories (parent_id);
@@ -0,0 +1 @@
+DROP TABLE frequent_expenses;
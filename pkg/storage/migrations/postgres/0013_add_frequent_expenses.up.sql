@@ -0,0 +1,13 @@
+-- Frequent expenses replace the static list of config.yaml, which now only seeds the frequent expenses of new users.
+CREATE TABLE frequent_expenses (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    currency VARCHAR(10) NOT NULL DEFAULT '',
+    is_claimable BOOLEAN NOT NULL DEFAULT FALSE,
+    paid_for_family BOOLEAN NOT NULL DEFAULT FALSE,
+    account_id INTEGER REFERENCES accounts (id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
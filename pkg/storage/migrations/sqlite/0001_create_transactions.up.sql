@@ -0,0 +1,13 @@
+-- Baseline schema. IF NOT EXISTS keeps databases created before migrations were introduced working.
+-- SQLite has no SERIAL type, and its driver only decodes columns declared as TIMESTAMP into time.Time.
+CREATE TABLE IF NOT EXISTS transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    amount NUMERIC(10, 2),
+    currency VARCHAR(10),
+    date DATE,
+    is_claimable BOOLEAN,
+    paid_for_family BOOLEAN,
+    category TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
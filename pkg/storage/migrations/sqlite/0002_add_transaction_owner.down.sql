@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_user_date;
+ALTER TABLE transactions DROP COLUMN user_id;
@@ -0,0 +1,4 @@
+-- Owner of the transaction: the Telegram chat ID it was recorded from.
+-- Rows created before this migration stay NULL until claimed with `migrate assign-owner`.
+ALTER TABLE transactions ADD COLUMN user_id BIGINT;
+CREATE INDEX idx_transactions_user_date ON transactions (user_id, date);
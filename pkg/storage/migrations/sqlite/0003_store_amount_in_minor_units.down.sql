@@ -0,0 +1,13 @@
+-- Amounts with more than 2 decimals (e.g. KWD) are rounded by the NUMERIC(10, 2) column.
+ALTER TABLE transactions ADD COLUMN amount NUMERIC(10, 2);
+
+UPDATE transactions
+SET amount = CAST(amount_minor AS REAL) / CASE
+        WHEN UPPER(currency) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN UPPER(currency) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        WHEN UPPER(currency) IN ('CLF', 'UYW') THEN 10000
+        ELSE 100
+    END
+WHERE amount_minor IS NOT NULL;
+
+ALTER TABLE transactions DROP COLUMN amount_minor;
@@ -0,0 +1,15 @@
+-- Amounts are stored as integer minor units of their currency (cents, yen, fils, ...) instead of
+-- NUMERIC(10, 2), so sums are exact and currencies with 0 or 3 decimals are represented correctly.
+-- The exponents mirror money.Exponent.
+ALTER TABLE transactions ADD COLUMN amount_minor INTEGER;
+
+UPDATE transactions
+SET amount_minor = CAST(ROUND(amount * CASE
+        WHEN UPPER(currency) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 1
+        WHEN UPPER(currency) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+        WHEN UPPER(currency) IN ('CLF', 'UYW') THEN 10000
+        ELSE 100
+    END) AS INTEGER)
+WHERE amount IS NOT NULL;
+
+ALTER TABLE transactions DROP COLUMN amount;
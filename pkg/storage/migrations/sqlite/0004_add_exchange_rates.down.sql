@@ -0,0 +1,5 @@
+ALTER TABLE transactions DROP COLUMN base_amount_minor;
+ALTER TABLE transactions DROP COLUMN exchange_rate;
+ALTER TABLE transactions DROP COLUMN base_currency;
+
+DROP TABLE exchange_rates;
@@ -0,0 +1,14 @@
+-- Rates are exact decimal strings: one unit of base_currency is worth rate units of quote_currency.
+-- TEXT rather than NUMERIC, which SQLite would turn into a binary float.
+CREATE TABLE exchange_rates (
+    date DATE NOT NULL,
+    base_currency VARCHAR(10) NOT NULL,
+    quote_currency VARCHAR(10) NOT NULL,
+    rate TEXT NOT NULL,
+    PRIMARY KEY (base_currency, quote_currency, date)
+);
+
+-- The conversion of each transaction into the reporting currency, using the rate in effect on its date.
+ALTER TABLE transactions ADD COLUMN base_currency VARCHAR(10);
+ALTER TABLE transactions ADD COLUMN exchange_rate TEXT;
+ALTER TABLE transactions ADD COLUMN base_amount_minor INTEGER;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_recurring_run;
+ALTER TABLE transactions DROP COLUMN recurring_rule_id;
+
+DROP TABLE recurring_rules;
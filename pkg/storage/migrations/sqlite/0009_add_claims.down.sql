@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_claim_status;
+ALTER TABLE transactions DROP COLUMN claim_resolved_at;
+ALTER TABLE transactions DROP COLUMN claim_submitted_at;
+ALTER TABLE transactions DROP COLUMN claim_reference;
+ALTER TABLE transactions DROP COLUMN claim_status;
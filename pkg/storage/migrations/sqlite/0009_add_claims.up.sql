@@ -0,0 +1,9 @@
+-- The reimbursement of claimable transactions: claimable, then submitted, then reimbursed or rejected.
+-- claim_status is NULL for transactions that are not claimable.
+ALTER TABLE transactions ADD COLUMN claim_status VARCHAR(20);
+ALTER TABLE transactions ADD COLUMN claim_reference TEXT;
+ALTER TABLE transactions ADD COLUMN claim_submitted_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN claim_resolved_at TIMESTAMP; -- When it was reimbursed or rejected
+
+UPDATE transactions SET claim_status = 'claimable' WHERE is_claimable;
+CREATE INDEX idx_transactions_claim_status ON transactions (user_id, claim_status);
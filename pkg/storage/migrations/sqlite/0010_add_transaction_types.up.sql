@@ -0,0 +1,4 @@
+-- Transactions are expenses, income or transfers between the user's own accounts.
+-- Everything recorded before is an expense.
+ALTER TABLE transactions ADD COLUMN type VARCHAR(10) NOT NULL DEFAULT 'expense';
+CREATE INDEX idx_transactions_type ON transactions (user_id, type, date);
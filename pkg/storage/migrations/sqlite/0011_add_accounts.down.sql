@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transactions_to_account;
+DROP INDEX IF EXISTS idx_transactions_account;
+ALTER TABLE transactions DROP COLUMN to_account_id;
+ALTER TABLE transactions DROP COLUMN account_id;
+
+DROP TABLE accounts;
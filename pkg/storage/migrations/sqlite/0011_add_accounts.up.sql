@@ -0,0 +1,18 @@
+-- Accounts are the cards, cash, bank accounts and e-wallets that transactions are paid from or into.
+CREATE TABLE accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    opening_balance_minor BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+-- account_id is the account paid from (or into, for income); transfers also move money into to_account_id.
+-- SQLite cannot drop a column with a foreign key, so the store unlinks transactions when an account is deleted.
+ALTER TABLE transactions ADD COLUMN account_id INTEGER;
+ALTER TABLE transactions ADD COLUMN to_account_id INTEGER;
+CREATE INDEX idx_transactions_account ON transactions (account_id, date);
+CREATE INDEX idx_transactions_to_account ON transactions (to_account_id, date);
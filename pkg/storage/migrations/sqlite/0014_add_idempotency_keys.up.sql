@@ -0,0 +1,12 @@
+-- The outcome of POST requests made with an Idempotency-Key, replayed when the request is repeated.
+-- status_code is 0 while the first request is still being processed.
+CREATE TABLE idempotency_keys (
+    user_id BIGINT NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    response_body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+CREATE INDEX idx_idempotency_keys_created ON idempotency_keys (created_at);
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/pkg/transaction"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed or were issued for another sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortField is a transaction attribute listings can be ordered by.
+type SortField string
+
+const (
+	SortByDate     SortField = "date"
+	SortByAmount   SortField = "amount" // Minor units, regardless of the currency
+	SortByName     SortField = "name"
+	SortByCategory SortField = "category"
+)
+
+// Sort is the order of a transaction listing. Ties are always broken by date, creation time and ID,
+// in the same direction, so that every transaction has a unique position.
+type Sort struct {
+	Field      SortField
+	Descending bool
+}
+
+// DefaultSort lists the most recent transactions first.
+var DefaultSort = Sort{Field: SortByDate, Descending: true}
+
+// ParseSort parses "field" or "field:asc" / "field:desc", e.g. "amount:desc".
+// Without a direction dates sort descending and everything else ascending. The empty string is DefaultSort.
+func ParseSort(s string) (Sort, error) {
+	if s == "" {
+		return DefaultSort, nil
+	}
+	field, direction, hasDirection := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	sort := Sort{Field: SortField(field)}
+	if _, err := sort.column(); err != nil {
+		return Sort{}, err
+	}
+	switch {
+	case !hasDirection:
+		sort.Descending = sort.Field == SortByDate
+	case direction == "asc":
+	case direction == "desc":
+		sort.Descending = true
+	default:
+		return Sort{}, fmt.Errorf("unsupported sort direction %q", direction)
+	}
+	return sort, nil
+}
+
+// String renders the sort the way ParseSort accepts it.
+func (s Sort) String() string {
+	if s.Descending {
+		return string(s.Field) + ":desc"
+	}
+	return string(s.Field) + ":asc"
+}
+
+// column returns the SQL column of the sort field, "" for dates which are part of every key.
+// Only whitelisted columns are ever interpolated into queries.
+func (s Sort) column() (string, error) {
+	switch s.Field {
+	case SortByDate:
+		return "", nil
+	case SortByAmount:
+		return "amount_minor", nil
+	case SortByName, SortByCategory:
+		return string(s.Field), nil
+	default:
+		return "", fmt.Errorf("unsupported sort field %q", string(s.Field))
+	}
+}
+
+// PageRequest selects one page of a transaction listing.
+type PageRequest struct {
+	Sort  Sort
+	Limit int
+	// Cursor continues a listing from a NextCursor or PrevCursor of a previous page.
+	Cursor string
+	// Page is a 1-based page number for clients that do not use cursors. Ignored when Cursor is set.
+	Page int
+	// IncludeTotal also counts all matching transactions, which costs an extra query.
+	IncludeTotal bool
+}
+
+// TransactionPage is one page of a transaction listing.
+type TransactionPage struct {
+	Transactions []transaction.Transaction
+	// NextCursor and PrevCursor continue the listing after the last or before the first
+	// transaction of the page. They are empty at the ends of the listing.
+	NextCursor string
+	PrevCursor string
+	// Total is the number of matching transactions, only set when requested.
+	Total *int
+}
+
+// cursor is the position of a transaction in a sorted listing. It is handed out base64 encoded,
+// clients must treat it as opaque.
+type cursor struct {
+	Sort      string    `json:"s"`
+	Backward  bool      `json:"b,omitempty"` // Continue towards the start of the listing
+	Amount    int64     `json:"a,omitempty"` // Sort key for SortByAmount
+	Text      string    `json:"t,omitempty"` // Sort key for SortByName and SortByCategory
+	Date      string    `json:"d"`
+	CreatedAt time.Time `json:"c"`
+	ID        int64     `json:"i"`
+}
+
+// newCursor returns the encoded position of the transaction.
+func newCursor(sort Sort, t transaction.Transaction, backward bool) string {
+	// SQL drivers return dates as RFC 3339 timestamps, the key compares them as YYYY-MM-DD.
+	date := t.Date
+	if len(date) > len("2006-01-02") {
+		date = date[:len("2006-01-02")]
+	}
+	c := cursor{Sort: sort.String(), Backward: backward, Date: date, CreatedAt: t.CreatedAt, ID: t.ID}
+	switch sort.Field {
+	case SortByAmount:
+		c.Amount = t.Amount
+	case SortByName:
+		c.Text = t.Name
+	case SortByCategory:
+		c.Text = t.Category
+	}
+	data, _ := json.Marshal(c) // Cannot fail, the struct only has plain fields
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses an encoded cursor and checks that it belongs to the sort order.
+func decodeCursor(encoded string, sort Sort) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	if c.Sort != sort.String() {
+		return c, fmt.Errorf("%w: it was issued for sort %q", ErrInvalidCursor, c.Sort)
+	}
+	return c, nil
+}
+
+// key returns the cursor's sort key value for SQL queries.
+func (c cursor) key(sort Sort) interface{} {
+	if sort.Field == SortByAmount {
+		return c.Amount
+	}
+	return c.Text
+}
+
+// compareTransactions orders two transactions by the sort key, then date, creation time and ID,
+// all ascending. It returns -1, 0 or 1.
+func compareTransactions(sort Sort, a, b transaction.Transaction) int {
+	switch sort.Field {
+	case SortByAmount:
+		if c := compareInt64(a.Amount, b.Amount); c != 0 {
+			return c
+		}
+	case SortByName:
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+	case SortByCategory:
+		if c := strings.Compare(a.Category, b.Category); c != 0 {
+			return c
+		}
+	}
+	if c := strings.Compare(a.Date, b.Date); c != 0 {
+		return c
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		if a.CreatedAt.Before(b.CreatedAt) {
+			return -1
+		}
+		return 1
+	}
+	return compareInt64(a.ID, b.ID)
+}
+
+// compareInt64 returns -1, 0 or 1.
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// position is the transaction a cursor points at, with only the fields compareTransactions uses.
+func (c cursor) position() transaction.Transaction {
+	return transaction.Transaction{
+		ID: c.ID, Name: c.Text, Category: c.Text, Amount: c.Amount, Date: c.Date, CreatedAt: c.CreatedAt,
+	}
+}
+
+// finishPage trims the extra row fetched to detect more results, restores the listing order of a
+// backward page and sets the cursors. rows are in fetch order, hasBefore tells whether rows exist
+// before the first one in fetch order.
+func finishPage(rows []transaction.Transaction, req PageRequest, backward, hasBefore bool) TransactionPage {
+	hasMore := len(rows) > req.Limit
+	if hasMore {
+		rows = rows[:req.Limit]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := TransactionPage{Transactions: rows}
+	if rows == nil {
+		page.Transactions = []transaction.Transaction{}
+	}
+	// In listing order: a forward page continues after its last row, a backward page before its first.
+	hasNext, hasPrev := hasMore, hasBefore
+	if backward {
+		hasNext, hasPrev = hasBefore, hasMore
+	}
+	if len(rows) > 0 {
+		if hasNext {
+			page.NextCursor = newCursor(req.Sort, rows[len(rows)-1], false)
+		}
+		if hasPrev {
+			page.PrevCursor = newCursor(req.Sort, rows[0], true)
+		}
+	}
+	return page
+}
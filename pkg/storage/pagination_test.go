@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"main/pkg/transaction"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestSQLStore opens a migrated SQLite database in a temporary directory.
+func newTestSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+	s, err := openSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err = s.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// pagingFixture returns transactions with many ties on every sort field, so that pages break inside them.
+func pagingFixture() []transaction.Transaction {
+	dates := []string{"2026-01-03", "2026-01-01", "2026-01-02"}
+	names := []string{"Lunch", "coffee", "Bus", "Lunch"}
+	categories := []string{"Food", "Transport", "Food"}
+	amounts := []int64{500, 1250, 500, 99, 1250}
+	createdAt := time.Date(2026, time.January, 4, 12, 0, 0, 0, time.UTC)
+
+	var ts []transaction.Transaction
+	for i := 0; i < 23; i++ {
+		userID := int64(1)
+		if i%7 == 6 {
+			userID = 2
+		}
+		ts = append(ts, transaction.Transaction{
+			UserID:    userID,
+			Date:      dates[i%len(dates)],
+			Name:      names[i%len(names)],
+			Category:  categories[i%len(categories)],
+			Amount:    amounts[i%len(amounts)],
+			Currency:  "SGD",
+			CreatedAt: createdAt,
+		})
+	}
+	return ts
+}
+
+// walkPages follows the next cursors from the first page, then the previous cursors back from the last one,
+// and returns the IDs of every page. It fails if walking back does not return the same pages.
+func walkPages(t *testing.T, store TransactionStore, filter TransactionFilter, sort Sort, limit int) [][]int64 {
+	t.Helper()
+	req := PageRequest{Sort: sort, Limit: limit}
+	var pages [][]int64
+	var page TransactionPage
+	for {
+		var err error
+		if page, err = store.GetAllTransactions(1, filter, req); err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, transactionIDs(page.Transactions))
+		if page.NextCursor == "" {
+			break
+		}
+		if len(pages) > 100 {
+			t.Fatal("cursors do not reach the end of the listing")
+		}
+		req.Cursor = page.NextCursor
+	}
+
+	for i := len(pages) - 2; i >= 0; i-- {
+		if page.PrevCursor == "" {
+			t.Fatalf("page %d has no previous cursor", i+2)
+		}
+		req.Cursor = page.PrevCursor
+		var err error
+		if page, err = store.GetAllTransactions(1, filter, req); err != nil {
+			t.Fatal(err)
+		}
+		if got := transactionIDs(page.Transactions); !reflect.DeepEqual(got, pages[i]) {
+			t.Fatalf("walking back, page %d = %v, want %v", i+1, got, pages[i])
+		}
+	}
+	if page.PrevCursor != "" {
+		t.Fatal("the first page has a previous cursor")
+	}
+	return pages
+}
+
+func transactionIDs(ts []transaction.Transaction) []int64 {
+	ids := make([]int64, len(ts))
+	for i, t := range ts {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func TestKeysetPagingMatchesBetweenStores(t *testing.T) {
+	memory := NewMemoryStore()
+	sqlite := newTestSQLStore(t)
+	for _, tr := range pagingFixture() {
+		memoryID, err := memory.InsertTransaction(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqliteID, err := sqlite.InsertTransaction(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if memoryID != sqliteID {
+			t.Fatalf("memory ID %d, SQLite ID %d", memoryID, sqliteID)
+		}
+	}
+
+	filters := map[string]TransactionFilter{
+		"all":  {},
+		"food": {Categories: []string{"Food"}},
+	}
+	for _, field := range []SortField{SortByDate, SortByAmount, SortByName, SortByCategory} {
+		for _, descending := range []bool{false, true} {
+			for name, filter := range filters {
+				sort := Sort{Field: field, Descending: descending}
+				t.Run(fmt.Sprintf("%s/%s", sort, name), func(t *testing.T) {
+					want := walkPages(t, memory, filter, sort, 4)
+					got := walkPages(t, sqlite, filter, sort, 4)
+					if !reflect.DeepEqual(got, want) {
+						t.Fatalf("SQLite pages %v, memory pages %v", got, want)
+					}
+
+					seen := make(map[int64]bool)
+					for _, page := range want {
+						for _, id := range page {
+							if seen[id] {
+								t.Fatalf("transaction %d is listed twice in %v", id, want)
+							}
+							seen[id] = true
+						}
+					}
+					total, err := memory.GetAllTransactions(1, filter, PageRequest{Sort: sort, Limit: 1, IncludeTotal: true})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(seen) != *total.Total {
+						t.Fatalf("listed %d transactions, want %d", len(seen), *total.Total)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestPageNumbersMatchCursors(t *testing.T) {
+	sqlite := newTestSQLStore(t)
+	for _, tr := range pagingFixture() {
+		if _, err := sqlite.InsertTransaction(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sort := Sort{Field: SortByAmount, Descending: true}
+	pages := walkPages(t, sqlite, TransactionFilter{}, sort, 5)
+	for i, want := range pages {
+		page, err := sqlite.GetAllTransactions(1, TransactionFilter{}, PageRequest{Sort: sort, Limit: 5, Page: i + 1, IncludeTotal: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := transactionIDs(page.Transactions); !reflect.DeepEqual(got, want) {
+			t.Errorf("page %d = %v, want %v", i+1, got, want)
+		}
+		if page.Total == nil || *page.Total != 20 {
+			t.Errorf("page %d total = %v, want 20", i+1, page.Total)
+		}
+	}
+}
+
+func TestCursorOfAnotherSortIsRejected(t *testing.T) {
+	memory := NewMemoryStore()
+	for _, tr := range pagingFixture() {
+		if _, err := memory.InsertTransaction(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page, err := memory.GetAllTransactions(1, TransactionFilter{}, PageRequest{Sort: DefaultSort, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := PageRequest{Sort: Sort{Field: SortByName}, Limit: 2, Cursor: page.NextCursor}
+	for name, store := range map[string]TransactionStore{"memory": memory, "sqlite": newTestSQLStore(t)} {
+		if _, err = store.GetAllTransactions(1, TransactionFilter{}, req); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s returned %v for a cursor of another sort, want ErrInvalidCursor", name, err)
+		}
+	}
+}
@@ -2,88 +2,167 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"main/pkg/config"
 	"main/pkg/transaction"
 	"strings"
+	"time"
 )
 
-// GetAllTransactionsFromDB retrieves transactions from the database,
-// with optional filtering by category, is_claimable, paid_for_family,
-// and supports pagination.
-// It returns the slice of transactions for the current page,
-// the total number of items matching the filters (before pagination), and an error.
-func GetAllTransactionsFromDB(
-	categoryFilter string,
-	isClaimableFilter *bool,
-	paidForFamilyFilter *bool,
-	page int, // Current page number (1-based)
-	limit int, // Number of items per page
-) ([]transaction.Transaction, int, error) { // Returns: transactions, totalItems, error
-	currentDB, err := GetDB() // Assuming GetDB() returns your *sql.DB or *sqlx.DB
+// transactionColumns lists the transaction columns read by scanTransaction, in order.
+const transactionColumns = `id, user_id, name, amount_minor, currency, date, is_claimable, paid_for_family, category, created_at,
+	base_currency, exchange_rate, base_amount_minor, recurring_rule_id,
+	claim_status, claim_reference, claim_submitted_at, claim_resolved_at, type, account_id, to_account_id`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTransaction reads a row selected with transactionColumns.
+func scanTransaction(row rowScanner) (transaction.Transaction, error) {
+	var t transaction.Transaction
+	// The conversion columns are NULL for transactions saved without an exchange rate.
+	var baseCurrency, exchangeRate sql.NullString
+	var baseAmount, recurringRuleID sql.NullInt64
+	// The account columns are NULL for transactions without an account.
+	var accountID, toAccountID sql.NullInt64
+	// The claim columns are NULL for transactions that are not claimable.
+	var claimStatus, claimReference sql.NullString
+	var claimSubmittedAt, claimResolvedAt sql.NullTime
+	err := row.Scan(
+		&t.ID, &t.UserID, &t.Name, &t.Amount, &t.Currency, &t.Date,
+		&t.IsClaimable, &t.PaidForFamily, &t.Category, &t.CreatedAt,
+		&baseCurrency, &exchangeRate, &baseAmount, &recurringRuleID,
+		&claimStatus, &claimReference, &claimSubmittedAt, &claimResolvedAt, &t.Type,
+		&accountID, &toAccountID,
+	)
 	if err != nil {
-		log.Printf("Error getting DB connection: %v", err)
-		return nil, 0, fmt.Errorf("failed to get DB connection: %w", err)
+		return t, err
 	}
+	t.BaseCurrency = baseCurrency.String
+	t.ExchangeRate = normalizeRate(exchangeRate.String)
+	t.BaseAmount = baseAmount.Int64
+	t.RecurringRuleID = recurringRuleID.Int64
+	t.ClaimStatus = transaction.ClaimStatus(claimStatus.String)
+	t.ClaimReference = claimReference.String
+	t.ClaimSubmittedAt = timePointer(claimSubmittedAt)
+	t.ClaimResolvedAt = timePointer(claimResolvedAt)
+	t.AccountID = accountID.Int64
+	t.ToAccountID = toAccountID.Int64
+	return t, nil
+}
 
-	var conditions []string
-	var args []interface{}
-	argID := 1 // For SQL query placeholder numbering ($1, $2, etc.)
+// GetTransaction retrieves a single transaction owned by the user.
+// It returns ErrNotFound if the transaction does not exist or belongs to someone else.
+func (s *SQLStore) GetTransaction(userID, id int64) (transaction.Transaction, error) {
+	selectSQL := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 AND user_id = $2`
 
-	// Build WHERE clause and arguments for filtering
-	if categoryFilter != "" {
-		conditions = append(conditions, fmt.Sprintf("category = $%d", argID))
-		args = append(args, categoryFilter)
-		argID++
-	}
-	if isClaimableFilter != nil {
-		conditions = append(conditions, fmt.Sprintf("is_claimable = $%d", argID))
-		args = append(args, *isClaimableFilter)
-		argID++
+	t, err := scanTransaction(s.db.QueryRow(selectSQL, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return transaction.Transaction{}, ErrNotFound
 	}
-	if paidForFamilyFilter != nil {
-		conditions = append(conditions, fmt.Sprintf("paid_for_family = $%d", argID))
-		args = append(args, *paidForFamilyFilter)
-		argID++
+	if err != nil {
+		log.Printf("Error querying transaction %d: %v", id, err)
+		return transaction.Transaction{}, fmt.Errorf("database query for transaction failed: %w", err)
 	}
 
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	transactions := []transaction.Transaction{t}
+	if err = s.loadTags(transactions); err != nil {
+		return transaction.Transaction{}, err
 	}
+	return transactions[0], nil
+}
 
-	// --- Query 1: Get the total count of items matching the filters ---
-	countSQL := `SELECT COUNT(*) FROM transactions` + whereClause
-	var totalItems int
-	err = currentDB.QueryRow(countSQL, args...).Scan(&totalItems) // Use the same filter args
+// GetAllTransactions retrieves one page of the user's transactions that match the filter.
+// Pages are selected by keyset on (sort key, date, created_at, id) when a cursor is given, so they
+// neither skip nor repeat transactions when new ones are added; page numbers fall back to OFFSET.
+// The total number of matching transactions is only counted when requested.
+func (s *SQLStore) GetAllTransactions(userID int64, filter TransactionFilter, req PageRequest) (TransactionPage, error) {
+	sortColumn, err := req.Sort.column()
 	if err != nil {
-		log.Printf("Error querying total transaction count: %v (SQL: %s, Args: %v)", err, countSQL, args)
-		return nil, 0, fmt.Errorf("database query for total count failed: %w", err)
+		return TransactionPage{}, err
 	}
 
-	// If no items match the filter, return early
-	if totalItems == 0 {
-		return []transaction.Transaction{}, 0, nil
+	// Every query is scoped to the owner of the transactions.
+	// argID is the next SQL query placeholder number ($1, $2, etc.)
+	conditions, args, argID := filter.conditions([]string{"user_id = $1"}, []interface{}{userID}, 2)
+
+	// --- Optional query: Get the total count of items matching the filters ---
+	var total *int
+	if req.IncludeTotal {
+		countSQL := `SELECT COUNT(*) FROM transactions WHERE ` + strings.Join(conditions, " AND ")
+		var totalItems int
+		if err := s.db.QueryRow(countSQL, args...).Scan(&totalItems); err != nil {
+			log.Printf("Error querying total transaction count: %v (SQL: %s, Args: %v)", err, countSQL, args)
+			return TransactionPage{}, fmt.Errorf("database query for total count failed: %w", err)
+		}
+		total = &totalItems
 	}
 
-	// --- Query 2: Get the paginated list of transactions ---
-	selectSQL := `
-        SELECT id, name, amount, currency, date, is_claimable, paid_for_family, category, created_at
-        FROM transactions
-    ` + whereClause + ` ORDER BY date DESC, created_at DESC` // Keep existing order
+	keyColumns := []string{"date", "created_at", "id"}
+	if sortColumn != "" {
+		keyColumns = append([]string{sortColumn}, keyColumns...)
+	}
+
+	// Backward pages are fetched in reverse order and flipped afterwards.
+	backward, hasBefore, offset := false, false, 0
+	descending := req.Sort.Descending
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor, req.Sort)
+		if err != nil {
+			return TransactionPage{}, err
+		}
+		backward, hasBefore = c.Backward, true
+		if backward {
+			descending = !descending
+		}
+
+		operator := ">"
+		if descending {
+			operator = "<"
+		}
+		keyArgs := []interface{}{c.Date, s.timeArg(c.CreatedAt), c.ID}
+		if sortColumn != "" {
+			keyArgs = append([]interface{}{c.key(req.Sort)}, keyArgs...)
+		}
+		placeholders := make([]string, len(keyArgs))
+		for i, arg := range keyArgs {
+			placeholders[i] = fmt.Sprintf("$%d", argID)
+			args = append(args, arg)
+			argID++
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s) %s (%s)",
+			strings.Join(keyColumns, ", "), operator, strings.Join(placeholders, ", ")))
+	} else if req.Page > 1 {
+		offset = (req.Page - 1) * req.Limit
+		hasBefore = true
+	}
 
-	// Add LIMIT and OFFSET for pagination
-	// The placeholders for LIMIT and OFFSET will be after the filter args
-	selectSQL += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argID, argID+1)
+	direction := " ASC"
+	if descending {
+		direction = " DESC"
+	}
+	orderBy := make([]string, len(keyColumns))
+	for i, column := range keyColumns {
+		orderBy[i] = column + direction
+	}
 
-	offset := (page - 1) * limit
-	// Append limit and offset to the arguments list for the main query
-	queryArgs := append(args, limit, offset)
+	// One row more than requested tells whether there is a next page.
+	selectSQL := `
+        SELECT ` + transactionColumns + `
+        FROM transactions
+        WHERE ` + strings.Join(conditions, " AND ") + `
+        ORDER BY ` + strings.Join(orderBy, ", ") +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", argID, argID+1)
+	queryArgs := append(args, req.Limit+1, offset)
 
-	rows, err := currentDB.Query(selectSQL, queryArgs...)
+	rows, err := s.db.Query(selectSQL, queryArgs...)
 	if err != nil {
 		log.Printf("Error querying paginated transactions: %v (SQL: %s, Args: %v)", err, selectSQL, queryArgs)
-		return nil, 0, fmt.Errorf("database query for paginated transactions failed: %w", err)
+		return TransactionPage{}, fmt.Errorf("database query for paginated transactions failed: %w", err)
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
@@ -94,186 +173,109 @@ func GetAllTransactionsFromDB(
 
 	var transactions []transaction.Transaction
 	for rows.Next() {
-		var t transaction.Transaction
-		// Ensure the Scan arguments match the columns in your SELECT statement
-		err := rows.Scan(
-			&t.ID, &t.Name, &t.Amount, &t.Currency, &t.Date,
-			&t.IsClaimable, &t.PaidForFamily, &t.Category, &t.CreatedAt,
-		)
+		t, err := scanTransaction(rows)
 		if err != nil {
 			log.Printf("Error scanning transaction row: %v", err)
-			// Decide if you want to return immediately or try to process other rows
-			return nil, 0, fmt.Errorf("failed to scan transaction row: %w", err)
+			return TransactionPage{}, fmt.Errorf("failed to scan transaction row: %w", err)
 		}
 		transactions = append(transactions, t)
 	}
 
 	if err = rows.Err(); err != nil {
 		log.Printf("Error iterating transaction rows: %v", err)
-		return nil, 0, fmt.Errorf("error during row iteration: %w", err)
+		return TransactionPage{}, fmt.Errorf("error during row iteration: %w", err)
 	}
 
-	log.Printf("Successfully retrieved %d transactions (page %d, limit %d, total matching %d).",
-		len(transactions), page, limit, totalItems)
-	return transactions, totalItems, nil
-}
-
-// GetTransactionCountByCategory retrieves the total number of transactions for each category.
-func GetTransactionCountByCategory() (map[string]float32, error) {
-	querySQL := `
-		SELECT
-			category,
-			SUM(amount) AS total_cost
-		FROM
-			transactions
-		GROUP BY
-			category
-		ORDER BY
-			total_cost DESC;
-    `
-	categoryCounts := make(map[string]float32)
-
-	currentDB, err := GetDB()
-	if err != nil {
-		log.Printf("Error getting DB connection for category count: %v", err)
-		return nil, fmt.Errorf("failed to get DB connection: %w", err)
+	if err = s.loadTags(transactions); err != nil {
+		return TransactionPage{}, err
 	}
 
-	rows, err := currentDB.Query(querySQL)
-	if err != nil {
-		log.Printf("Error querying transaction counts by category: %v (SQL: %s)", err, querySQL)
-		return nil, fmt.Errorf("database query for category counts failed: %w", err)
-	}
-	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
-			log.Printf("Error closing rows for category count: %v", err)
-		}
-	}(rows)
-
-	for rows.Next() {
-		var category string
-		var count float32
-		if err := rows.Scan(&category, &count); err != nil {
-			log.Printf("Error scanning category count row: %v", err)
-			return nil, fmt.Errorf("failed to scan category count row: %w", err)
-		}
-		categoryCounts[category] = count
-	}
-
-	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating category count rows: %v", err)
-		return nil, fmt.Errorf("error during category count row iteration: %w", err)
-	}
-
-	log.Printf("Successfully retrieved transaction counts for %d categories.", len(categoryCounts))
-	return categoryCounts, nil
+	page := finishPage(transactions, req, backward, hasBefore)
+	page.Total = total
+	log.Printf("Successfully retrieved %d transactions (sort %s, limit %d).", len(page.Transactions), req.Sort, req.Limit)
+	return page, nil
 }
 
-// GetTotalAmountByPaidForFamily retrieves the total sum of amounts grouped by the 'paid_for_family' status.
-func GetTotalAmountByPaidForFamily() (map[bool]float32, error) {
-	querySQL := `
-		SELECT
-			paid_for_family,
-			SUM(amount) AS total_amount
-		FROM
-			transactions
-		GROUP BY
-			paid_for_family
-		ORDER BY
-			paid_for_family;
-	`
-	amountByPaidForFamily := make(map[bool]float32)
-
-	currentDB, err := GetDB()
-	if err != nil {
-		log.Printf("Error getting DB connection for 'paid_for_family' totals: %v", err)
-		return nil, fmt.Errorf("failed to get DB connection: %w", err)
+// timeArg converts a timestamp for comparison with a TIMESTAMP column. SQLite stores
+// CURRENT_TIMESTAMP as "YYYY-MM-DD HH:MM:SS" text in UTC and compares it as text.
+func (s *SQLStore) timeArg(t time.Time) interface{} {
+	if s.driver == config.DriverSQLite {
+		return t.UTC().Format("2006-01-02 15:04:05")
 	}
+	return t
+}
 
-	rows, err := currentDB.Query(querySQL)
+// GetTotals retrieves the user's summed amounts per group and currency for expenses dated within the range,
+// or for transactions of every type when grouping by type. Amounts in different currencies are never added together.
+func (s *SQLStore) GetTotals(userID int64, groupBy GroupBy, dateRange DateRange) ([]Total, error) {
+	groupColumn, err := groupBy.column()
 	if err != nil {
-		log.Printf("Error querying transaction totals by 'paid_for_family': %v (SQL: %s)", err, querySQL)
-		return nil, fmt.Errorf("database query for 'paid_for_family' totals failed: %w", err)
+		return nil, err
 	}
-	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
-			log.Printf("Error closing rows for 'paid_for_family' totals: %v", err)
-		}
-	}(rows)
-
-	for rows.Next() {
-		var paidForFamilyStatus bool
-		var totalAmount float32
-		// Handle potential NULL values for SUM(amount) if a group has no non-NULL amounts,
-		// though SUM usually returns 0 for empty groups or NULL if all inputs are NULL.
-		// Using sql.NullFloat64 might be more robust if SUM can return NULL.
-		// For simplicity, assuming SUM(amount) will return a float32 (or 0).
-		if err := rows.Scan(&paidForFamilyStatus, &totalAmount); err != nil {
-			log.Printf("Error scanning 'paid_for_family' total row: %v", err)
-			return nil, fmt.Errorf("failed to scan 'paid_for_family' total row: %w", err)
-		}
-		amountByPaidForFamily[paidForFamilyStatus] = totalAmount
+	conditions, args, _ := dateRange.conditions([]string{"t.user_id = $1"}, []interface{}{userID}, 2)
+	if groupBy != GroupByType {
+		conditions = append(conditions, "t.type = '"+string(transaction.TypeExpense)+"'")
 	}
 
-	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating 'paid_for_family' total rows: %v", err)
-		return nil, fmt.Errorf("error during 'paid_for_family' total row iteration: %w", err)
+	from := `transactions t`
+	if groupBy == GroupByTag {
+		from += `
+			JOIN transaction_tags tt ON tt.transaction_id = t.id
+			JOIN tags g ON g.id = tt.tag_id`
 	}
 
-	log.Printf("Successfully retrieved transaction totals by 'paid_for_family' status for %d groups.", len(amountByPaidForFamily))
-	return amountByPaidForFamily, nil
-}
-
-// GetTotalAmountByIsClaimable retrieves the total sum of amounts grouped by the 'is_claimable' status.
-func GetTotalAmountByIsClaimable() (map[bool]float32, error) {
 	querySQL := `
 		SELECT
-			is_claimable,
-			SUM(amount) AS total_amount
+			` + groupColumn + `,
+			t.currency,
+			SUM(t.amount_minor) AS total_amount,
+			t.base_currency,
+			SUM(t.base_amount_minor) AS total_base_amount
 		FROM
-			transactions
+			` + from + `
+		WHERE
+			` + strings.Join(conditions, " AND ") + `
 		GROUP BY
-			is_claimable
+			` + groupColumn + `, t.currency, t.base_currency
 		ORDER BY
-			is_claimable;
+			total_amount DESC;
 	`
-	amountByIsClaimable := make(map[bool]float32)
-
-	currentDB, err := GetDB()
-	if err != nil {
-		log.Printf("Error getting DB connection for 'is_claimable' totals: %v", err)
-		return nil, fmt.Errorf("failed to get DB connection: %w", err)
-	}
 
-	rows, err := currentDB.Query(querySQL)
+	rows, err := s.db.Query(querySQL, args...)
 	if err != nil {
-		log.Printf("Error querying transaction totals by 'is_claimable': %v (SQL: %s)", err, querySQL)
-		return nil, fmt.Errorf("database query for 'is_claimable' totals failed: %w", err)
+		log.Printf("Error querying transaction totals by '%s': %v (SQL: %s, Args: %v)", groupColumn, err, querySQL, args)
+		return nil, fmt.Errorf("database query for '%s' totals failed: %w", groupColumn, err)
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-			log.Printf("Error closing rows for 'is_claimable' totals: %v", err)
+			log.Printf("Error closing rows for '%s' totals: %v", groupColumn, err)
 		}
 	}(rows)
 
+	var totals []Total
 	for rows.Next() {
-		var isClaimableStatus bool
-		var totalAmount float32
-		if err := rows.Scan(&isClaimableStatus, &totalAmount); err != nil {
-			log.Printf("Error scanning 'is_claimable' total row: %v", err)
-			return nil, fmt.Errorf("failed to scan 'is_claimable' total row: %w", err)
+		var group interface{}
+		var currency, baseCurrency sql.NullString
+		var amount, baseAmount sql.NullInt64
+		if err := rows.Scan(&group, &currency, &amount, &baseCurrency, &baseAmount); err != nil {
+			log.Printf("Error scanning '%s' total row: %v", groupColumn, err)
+			return nil, fmt.Errorf("failed to scan '%s' total row: %w", groupColumn, err)
 		}
-		amountByIsClaimable[isClaimableStatus] = totalAmount
+		totals = append(totals, Total{
+			Group:        groupBy.format(group),
+			Currency:     currency.String,
+			Amount:       amount.Int64,
+			BaseCurrency: baseCurrency.String,
+			BaseAmount:   baseAmount.Int64,
+		})
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating 'is_claimable' total rows: %v", err)
-		return nil, fmt.Errorf("error during 'is_claimable' total row iteration: %w", err)
+		log.Printf("Error iterating '%s' total rows: %v", groupColumn, err)
+		return nil, fmt.Errorf("error during '%s' total row iteration: %w", groupColumn, err)
 	}
 
-	log.Printf("Successfully retrieved transaction totals by 'is_claimable' status for %d groups.", len(amountByIsClaimable))
-	return amountByIsClaimable, nil
+	log.Printf("Successfully retrieved %d transaction totals by '%s'.", len(totals), groupColumn)
+	return totals, nil
 }
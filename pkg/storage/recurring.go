@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"main/pkg/recurring"
+	"main/pkg/transaction"
+	"strings"
+	"time"
+)
+
+// recurringRuleColumns lists the recurring rule columns read by scanRecurringRule, in order.
+const recurringRuleColumns = `id, user_id, name, amount_minor, currency, category, is_claimable, paid_for_family, tags,
+	frequency, interval_count, cron, start_date, end_date, last_run_date, created_at`
+
+// scanRecurringRule reads a row selected with recurringRuleColumns.
+func scanRecurringRule(row rowScanner) (recurring.Rule, error) {
+	var r recurring.Rule
+	var tags, frequency string
+	var startDate time.Time
+	var endDate, lastRunDate sql.NullTime
+	err := row.Scan(
+		&r.ID, &r.UserID, &r.Name, &r.Amount, &r.Currency, &r.Category, &r.IsClaimable, &r.PaidForFamily, &tags,
+		&frequency, &r.Interval, &r.Cron, &startDate, &endDate, &lastRunDate, &r.CreatedAt,
+	)
+	if err != nil {
+		return r, err
+	}
+	r.Tags = []string{}
+	if tags != "" {
+		r.Tags = strings.Split(tags, ",")
+	}
+	r.Frequency = recurring.Frequency(frequency)
+	r.StartDate = startDate.Format("2006-01-02")
+	if endDate.Valid {
+		r.EndDate = endDate.Time.Format("2006-01-02")
+	}
+	if lastRunDate.Valid {
+		r.LastRunDate = lastRunDate.Time.Format("2006-01-02")
+	}
+	return r, nil
+}
+
+// InsertRecurringRule saves a new recurring rule and returns its ID.
+func (s *SQLStore) InsertRecurringRule(r recurring.Rule) (int64, error) {
+	insertSQL := `
+        INSERT INTO recurring_rules (user_id, name, amount_minor, currency, category, is_claimable, paid_for_family, tags,
+                                     frequency, interval_count, cron, start_date, end_date)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        RETURNING id;
+    `
+	var insertedID int64
+	err := s.db.QueryRow(insertSQL,
+		r.UserID, r.Name, r.Amount, r.Currency, r.Category, r.IsClaimable, r.PaidForFamily, strings.Join(r.Tags, ","),
+		string(r.Frequency), r.Interval, r.Cron, r.StartDate, nullString(r.EndDate),
+	).Scan(&insertedID)
+	if err != nil {
+		log.Printf("Error inserting recurring rule: %v", err)
+		return 0, fmt.Errorf("database insert of recurring rule failed: %w", err)
+	}
+
+	log.Printf("Successfully inserted recurring rule with ID: %d", insertedID)
+	return insertedID, nil
+}
+
+// UpdateRecurringRule overwrites the editable fields of a rule owned by r.UserID, or returns ErrNotFound.
+// Occurrences already recorded are kept, so moving the start date back does not record the days in between.
+func (s *SQLStore) UpdateRecurringRule(r recurring.Rule) error {
+	updateSQL := `
+        UPDATE recurring_rules
+        SET name = $1, amount_minor = $2, currency = $3, category = $4, is_claimable = $5, paid_for_family = $6, tags = $7,
+            frequency = $8, interval_count = $9, cron = $10, start_date = $11, end_date = $12
+        WHERE id = $13 AND user_id = $14;
+    `
+	result, err := s.db.Exec(updateSQL,
+		r.Name, r.Amount, r.Currency, r.Category, r.IsClaimable, r.PaidForFamily, strings.Join(r.Tags, ","),
+		string(r.Frequency), r.Interval, r.Cron, r.StartDate, nullString(r.EndDate), r.ID, r.UserID,
+	)
+	if err != nil {
+		log.Printf("Error updating recurring rule %d: %v", r.ID, err)
+		return fmt.Errorf("database update of recurring rule failed: %w", err)
+	}
+	return expectAffected(result)
+}
+
+// DeleteRecurringRule removes a rule owned by the user, or returns ErrNotFound.
+// The transactions it recorded are kept and no longer linked to it.
+func (s *SQLStore) DeleteRecurringRule(userID, id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM recurring_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err == nil {
+		err = expectAffected(result)
+	}
+	if err == nil {
+		_, err = tx.Exec(`UPDATE transactions SET recurring_rule_id = NULL WHERE recurring_rule_id = $1`, id)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, ErrNotFound) {
+			return err
+		}
+		log.Printf("Error deleting recurring rule %d: %v", id, err)
+		return fmt.Errorf("database delete of recurring rule failed: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recurring rule delete: %w", err)
+	}
+
+	log.Printf("Successfully deleted recurring rule with ID: %d", id)
+	return nil
+}
+
+// GetRecurringRule returns a rule owned by the user, or ErrNotFound.
+func (s *SQLStore) GetRecurringRule(userID, id int64) (recurring.Rule, error) {
+	querySQL := `SELECT ` + recurringRuleColumns + ` FROM recurring_rules WHERE id = $1 AND user_id = $2`
+
+	r, err := scanRecurringRule(s.db.QueryRow(querySQL, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return recurring.Rule{}, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Error querying recurring rule %d: %v", id, err)
+		return recurring.Rule{}, fmt.Errorf("database query for recurring rule failed: %w", err)
+	}
+	return r, nil
+}
+
+// GetRecurringRules returns the user's rules, oldest first.
+func (s *SQLStore) GetRecurringRules(userID int64) ([]recurring.Rule, error) {
+	return s.queryRecurringRules(`SELECT `+recurringRuleColumns+` FROM recurring_rules WHERE user_id = $1 ORDER BY id`, userID)
+}
+
+// GetDueRecurringRules returns the rules of every user that have started by the date
+// and have not been run up to it or to their end date yet.
+func (s *SQLStore) GetDueRecurringRules(date string) ([]recurring.Rule, error) {
+	querySQL := `
+        SELECT ` + recurringRuleColumns + `
+        FROM recurring_rules
+        WHERE start_date <= $1
+          AND (last_run_date IS NULL OR (last_run_date < $1 AND (end_date IS NULL OR last_run_date < end_date)))
+        ORDER BY id;
+    `
+	return s.queryRecurringRules(querySQL, date)
+}
+
+// queryRecurringRules runs a query selecting recurringRuleColumns.
+func (s *SQLStore) queryRecurringRules(querySQL string, args ...interface{}) ([]recurring.Rule, error) {
+	rows, err := s.db.Query(querySQL, args...)
+	if err != nil {
+		log.Printf("Error querying recurring rules: %v", err)
+		return nil, fmt.Errorf("database query for recurring rules failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for recurring rules: %v", err)
+		}
+	}(rows)
+
+	rules := []recurring.Rule{}
+	for rows.Next() {
+		r, err := scanRecurringRule(rows)
+		if err != nil {
+			log.Printf("Error scanning recurring rule row: %v", err)
+			return nil, fmt.Errorf("failed to scan recurring rule row: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating recurring rule rows: %v", err)
+		return nil, fmt.Errorf("error during recurring rule row iteration: %w", err)
+	}
+	return rules, nil
+}
+
+// RecordRecurringRun saves the transactions of a rule's occurrences and marks the rule as run up to
+// the date through, in one database transaction. Occurrences that were already recorded are skipped,
+// so a run that is repeated after a crash never duplicates them. It returns the saved transactions.
+func (s *SQLStore) RecordRecurringRun(rule recurring.Rule, due []transaction.Transaction, through string) ([]transaction.Transaction, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	recorded, err := recordRecurringRun(tx, rule, due, through)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit recurring run: %w", err)
+	}
+	return recorded, nil
+}
+
+// recordRecurringRun inserts the occurrences and moves the rule's last run date within a database transaction.
+func recordRecurringRun(tx *sql.Tx, rule recurring.Rule, due []transaction.Transaction, through string) ([]transaction.Transaction, error) {
+	recorded := []transaction.Transaction{}
+	for _, t := range due {
+		t.UserID, t.RecurringRuleID = rule.UserID, rule.ID
+		err := tx.QueryRow(
+			insertTransactionSQL+" ON CONFLICT (recurring_rule_id, date) DO NOTHING RETURNING id;",
+			transactionArgs(t)...,
+		).Scan(&t.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Recurring rule %d already recorded %s", rule.ID, t.Date)
+			continue
+		}
+		if err != nil {
+			log.Printf("Error inserting transaction of recurring rule %d: %v", rule.ID, err)
+			return nil, fmt.Errorf("database insert failed: %w", err)
+		}
+		if err = setTransactionTags(tx, t.UserID, t.ID, t.Tags); err != nil {
+			return nil, err
+		}
+		recorded = append(recorded, t)
+	}
+
+	result, err := tx.Exec(`UPDATE recurring_rules SET last_run_date = $1 WHERE id = $2`, through, rule.ID)
+	if err != nil {
+		log.Printf("Error updating last run of recurring rule %d: %v", rule.ID, err)
+		return nil, fmt.Errorf("database update of recurring rule failed: %w", err)
+	}
+	if err = expectAffected(result); err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"main/pkg/exchange"
+	"main/pkg/recurring"
+	"main/pkg/transaction"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRecurringRunsRecordEveryOccurrenceOnce(t *testing.T) {
+	file, err := NewFileStore(filepath.Join(t.TempDir(), "responses.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]Store{"memory": NewMemoryStore(), "sqlite": newTestSQLStore(t), "file": file}
+
+	fallback, err := exchange.NewStaticRates("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			scheduler := recurring.NewScheduler(store, exchange.NewRates(nil, fallback), nil, 0)
+			rule := recurring.Rule{
+				UserID: 1, Name: "Rent", Amount: 150000, Currency: "SGD", Category: "Housing",
+				Frequency: recurring.Monthly, Interval: 1, StartDate: "2026-01-31",
+			}
+			if rule.ID, err = store.InsertRecurringRule(rule); err != nil {
+				t.Fatal(err)
+			}
+
+			recorded, err := scheduler.Record(rule, "2026-01-31")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recorded) != 1 {
+				t.Errorf("first run recorded %d transactions, want 1", len(recorded))
+			}
+			// After downtime, a run with the rule as it was before the first run saved its last run date,
+			// like a repeat after a crash, records only the occurrences it missed.
+			if recorded, err = scheduler.Record(rule, "2026-03-31"); err != nil {
+				t.Fatal(err)
+			}
+			if len(recorded) != 2 {
+				t.Errorf("run after downtime recorded %d transactions, want 2", len(recorded))
+			}
+			if recorded, err = store.RecordRecurringRun(rule, []transaction.Transaction{rule.Transaction("2026-02-28")}, "2026-03-31"); err != nil {
+				t.Fatal(err)
+			}
+			if len(recorded) != 0 {
+				t.Errorf("repeated run recorded %v, want none", recorded)
+			}
+
+			var dates []string
+			for _, tr := range listed(t, store, 1) {
+				if tr.RecurringRuleID != rule.ID {
+					t.Errorf("transaction %d has recurring rule %d, want %d", tr.ID, tr.RecurringRuleID, rule.ID)
+				}
+				// The SQL drivers read DATE columns as midnight timestamps.
+				dates = append(dates, strings.TrimSuffix(tr.Date, "T00:00:00Z"))
+			}
+			sort.Strings(dates)
+			if want := []string{"2026-01-31", "2026-02-28", "2026-03-31"}; !reflect.DeepEqual(dates, want) {
+				t.Errorf("recorded %v, want %v", dates, want)
+			}
+			saved, err := store.GetRecurringRule(1, rule.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.LastRunDate != "2026-03-31" {
+				t.Errorf("last run date = %q, want 2026-03-31", saved.LastRunDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"main/pkg/config"
+	"net/url"
+
+	_ "modernc.org/sqlite" // Pure-Go driver, no cgo or database server required
+)
+
+// defaultSQLitePath is used when the sqlite driver is selected without a path.
+const defaultSQLitePath = "expenses.db"
+
+// openSQLite opens (creating if needed) an embedded SQLite database at the given path.
+// The queries are shared with the Postgres store; only the migrations differ.
+func openSQLite(path string) (*SQLStore, error) {
+	if path == "" {
+		path = defaultSQLitePath
+	}
+
+	// busy_timeout lets concurrent writers wait instead of failing with SQLITE_BUSY, and immediate
+	// transactions take the write lock when they begin rather than fail to upgrade a read lock.
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate", url.PathEscape(path))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		log.Printf("Error opening SQLite database: %v", err)
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	// SQLite allows a single writer, so serialise access through one connection.
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Printf("Error closing SQLite database: %v", closeErr)
+		}
+		return nil, fmt.Errorf("failed to open SQLite database %q: %w", path, err)
+	}
+
+	log.Printf("Successfully opened SQLite database at %s", path)
+
+	return &SQLStore{db: db, driver: config.DriverSQLite}, nil
+}
@@ -1,108 +1,241 @@
 package storage
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
+	"errors"
+	"main/pkg/account"
+	"main/pkg/budget"
+	"main/pkg/category"
+	"main/pkg/config"
+	"main/pkg/exchange"
+	"main/pkg/frequent"
+	"main/pkg/recurring"
 	"main/pkg/transaction" // Assuming Transaction is here
-	"os"
+	"time"
 )
 
-// SaveFilePath File to save responses
+// SaveFilePath is the default JSON lines file of the FileStore.
 const SaveFilePath = "responses.txt"
 
-// SaveResponseToFile saves the transaction to a file.
-func SaveResponseToFile(response transaction.Transaction) { // Assuming Transaction is an older version
-	file, err := os.OpenFile(SaveFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Printf("Error opening file: %v", err)
-		return
-	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			log.Printf("Error closing file: %v", err)
-		}
-	}(file)
-
-	data, err := json.Marshal(response)
-	if err != nil {
-		log.Printf("Error marshaling response: %v", err)
-		return
-	}
-
-	_, err = file.WriteString(fmt.Sprintf("%s\n", data))
-	if err != nil {
-		log.Printf("Error writing to file: %v", err)
-	}
+// ErrNotFound is returned when a transaction does not exist or is owned by another user.
+var ErrNotFound = errors.New("transaction not found")
+
+// TransactionStore is the persistence layer used by the bot and the HTTP handlers.
+// Every read is scoped to a single owner (the Telegram chat ID stored in Transaction.UserID).
+// Implementations must be safe for concurrent use.
+type TransactionStore interface {
+	// InsertTransaction saves a new transaction and returns its generated ID,
+	// or ErrAccountNotFound if the user has no account it refers to.
+	InsertTransaction(t transaction.Transaction) (int64, error)
+
+	// InsertTransactions saves new transactions all together and returns their generated IDs, in order.
+	// If one of them cannot be saved, none are and a *BatchError tells which.
+	InsertTransactions(ts []transaction.Transaction) ([]int64, error)
+
+	// FindDuplicate returns the ID of the most recently saved transaction of t.UserID with the type, name
+	// (ignoring case), amount and currency of t, dated at most windowDays days from t.Date, or 0 if there is none.
+	FindDuplicate(t transaction.Transaction, windowDays int) (int64, error)
+
+	// GetTransaction returns a single transaction owned by the user, or ErrNotFound.
+	GetTransaction(userID, id int64) (transaction.Transaction, error)
+
+	// UpdateTransaction overwrites a transaction owned by t.UserID, or returns ErrNotFound or ErrAccountNotFound.
+	// The claim status is kept, unless the transaction is no longer claimable.
+	UpdateTransaction(t transaction.Transaction) error
+
+	// DeleteTransaction removes a transaction owned by the user, or returns ErrNotFound.
+	DeleteTransaction(userID, id int64) error
+
+	// GetAllTransactions returns one page of the user's transactions matching the filter.
+	// It returns ErrInvalidCursor for cursors that do not belong to the requested sort order.
+	GetAllTransactions(userID int64, filter TransactionFilter, page PageRequest) (TransactionPage, error)
+
+	// UpdateClaim moves the claim of a transaction owned by the user along the claim lifecycle
+	// and returns the updated transaction. It returns ErrNotFound if there is no such transaction
+	// and transaction.ErrClaimTransition if the lifecycle does not allow the change.
+	UpdateClaim(userID, id int64, status transaction.ClaimStatus, reference string, at time.Time) (transaction.Transaction, error)
+
+	// GetTotals returns the user's summed amounts per group and currency for the expenses
+	// dated within the range. Income and transfers are only included when grouping by GroupByType.
+	GetTotals(userID int64, groupBy GroupBy, dateRange DateRange) ([]Total, error)
+
+	// Close releases any resources held by the store.
+	Close() error
 }
 
-// SaveTransactionToDB saves the transaction to the database.
-func SaveTransactionToDB(response transaction.Transaction) error {
-	insertSQL := `
-        INSERT INTO transactions (name, amount, currency, date, is_claimable, paid_for_family, category)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
-        RETURNING id;
-        `
-	var insertedID int64
-
-	currentDB, err := GetDB()
-	if err != nil {
-		log.Printf("Error getting DB connection for insert: %v", err)
-		return fmt.Errorf("failed to get DB connection: %w", err)
-	}
-
-	err = currentDB.QueryRow(
-		insertSQL,
-		response.Name,
-		response.Amount,
-		response.Currency,
-		response.Date,
-		response.IsClaimable,
-		response.PaidForFamily,
-		response.Category,
-	).Scan(&insertedID)
-
-	if err != nil {
-		log.Printf("Error inserting transaction into database: %v", err)
-		return fmt.Errorf("database insert failed: %w", err)
-	}
-
-	log.Printf("Successfully inserted transaction with ID: %d", insertedID)
-	return nil
+// ExchangeRateStore keeps the history of imported exchange rates.
+type ExchangeRateStore interface {
+	// UpsertExchangeRates saves rates, replacing existing rates for the same day and currency pair.
+	UpsertExchangeRates(rates []exchange.Rate) error
+
+	// GetExchangeRates returns, for every currency pair, the most recent rate on or before the date (YYYY-MM-DD).
+	GetExchangeRates(date string) ([]exchange.Rate, error)
 }
 
-// InsertTransaction inserts a new transaction into the database.
-func InsertTransaction(t transaction.Transaction) error {
-	// Note: This is an example. You should have proper validation
-	// and error handling in a real-world application.
-	insertSQL := `
-        INSERT INTO transactions (name, amount, currency, date, is_claimable, paid_for_family, category)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
-        RETURNING id;
-    `
-	var insertedID int
-
-	currentDB, err := GetDB()
-	if err != nil {
-		return fmt.Errorf("failed to get DB connection: %w", err)
-	}
-
-	err = currentDB.QueryRow(
-		insertSQL,
-		t.Name,
-		t.Amount,
-		t.Currency,
-		t.Date,
-		t.IsClaimable,
-		t.PaidForFamily,
-		t.Category,
-	).Scan(&insertedID)
-
-	if err != nil {
-		return fmt.Errorf("database insert failed: %w", err)
-	}
-
-	log.Printf("Successfully inserted transaction with ID: %d", insertedID)
-	return nil
+// AttachmentStore links files kept in a blob store to transactions.
+// Attachments are removed together with their transaction.
+type AttachmentStore interface {
+	// InsertAttachment links an attachment to a transaction owned by a.UserID and returns its ID,
+	// or ErrNotFound if the user has no such transaction.
+	InsertAttachment(a transaction.Attachment) (int64, error)
+
+	// GetAttachments returns the attachments of a transaction owned by the user, oldest first.
+	GetAttachments(userID, transactionID int64) ([]transaction.Attachment, error)
+
+	// GetAttachment returns one attachment of a transaction owned by the user, or ErrNotFound.
+	GetAttachment(userID, transactionID, id int64) (transaction.Attachment, error)
 }
+
+// RecurringStore keeps recurring rules and records the transactions of their occurrences.
+type RecurringStore interface {
+	// InsertRecurringRule saves a new rule and returns its generated ID.
+	InsertRecurringRule(r recurring.Rule) (int64, error)
+
+	// UpdateRecurringRule overwrites a rule owned by r.UserID, or returns ErrNotFound.
+	UpdateRecurringRule(r recurring.Rule) error
+
+	// DeleteRecurringRule removes a rule owned by the user, or returns ErrNotFound.
+	// The transactions it recorded are kept.
+	DeleteRecurringRule(userID, id int64) error
+
+	// GetRecurringRule returns a rule owned by the user, or ErrNotFound.
+	GetRecurringRule(userID, id int64) (recurring.Rule, error)
+
+	// GetRecurringRules returns the user's rules, oldest first.
+	GetRecurringRules(userID int64) ([]recurring.Rule, error)
+
+	// GetDueRecurringRules returns the rules of all users that may have occurrences up to the date (YYYY-MM-DD)
+	// that were not recorded yet.
+	GetDueRecurringRules(date string) ([]recurring.Rule, error)
+
+	// RecordRecurringRun saves the transactions of the rule's occurrences, skipping dates it already recorded,
+	// and marks the rule as run up to the date through. It returns the saved transactions.
+	RecordRecurringRun(rule recurring.Rule, due []transaction.Transaction, through string) ([]transaction.Transaction, error)
+}
+
+// BudgetStore keeps the users' monthly budgets.
+type BudgetStore interface {
+	// SetBudget creates the user's budget for b.Category and b.Currency, or replaces its amount, and returns its ID.
+	SetBudget(b budget.Budget) (int64, error)
+
+	// UpdateBudget overwrites a budget owned by b.UserID. It returns ErrNotFound if there is no such budget
+	// and ErrBudgetExists if it would duplicate another budget's category and currency.
+	UpdateBudget(b budget.Budget) error
+
+	// DeleteBudget removes a budget owned by the user, or returns ErrNotFound.
+	DeleteBudget(userID, id int64) error
+
+	// GetBudget returns a budget owned by the user, or ErrNotFound.
+	GetBudget(userID, id int64) (budget.Budget, error)
+
+	// GetBudgets returns the user's budgets ordered by category and currency.
+	GetBudgets(userID int64) ([]budget.Budget, error)
+}
+
+// AccountStore keeps the users' accounts and sums up the money moving in and out of them.
+type AccountStore interface {
+	// InsertAccount saves a new account and returns its generated ID,
+	// or ErrAccountExists if the user already has an account with the name.
+	InsertAccount(a account.Account) (int64, error)
+
+	// UpdateAccount overwrites an account owned by a.UserID. It returns ErrNotFound if there is no such account
+	// and ErrAccountExists if it would duplicate the name of another account.
+	UpdateAccount(a account.Account) error
+
+	// DeleteAccount removes an account owned by the user, or returns ErrNotFound.
+	// Its transactions and frequent expenses are kept and no longer linked to it.
+	DeleteAccount(userID, id int64) error
+
+	// GetAccount returns an account owned by the user, or ErrNotFound.
+	GetAccount(userID, id int64) (account.Account, error)
+
+	// GetAccounts returns the user's accounts ordered by name.
+	GetAccounts(userID int64) ([]account.Account, error)
+
+	// GetAccountTotals returns the daily net change of an account per currency, oldest first, with Group set
+	// to the date (YYYY-MM-DD). Income and transfers into the account are positive, expenses and transfers out negative.
+	GetAccountTotals(userID, accountID int64) ([]Total, error)
+}
+
+// CategoryStore keeps the users' expense and income categories.
+type CategoryStore interface {
+	// SeedCategories creates the seed categories, parents before their subcategories, for a user who has no categories.
+	SeedCategories(userID int64, seeds []category.Seed) error
+
+	// InsertCategory saves a new category and returns its generated ID. It returns ErrCategoryExists if the user
+	// already has a category with the name and ErrInvalidParent if the parent cannot have it as a subcategory.
+	InsertCategory(c category.Category) (int64, error)
+
+	// UpdateCategory overwrites a category owned by c.UserID, renaming the category of the user's transactions,
+	// budgets, recurring rules and frequent expenses along with it.
+	// It returns ErrNotFound, ErrCategoryExists or ErrInvalidParent.
+	UpdateCategory(c category.Category) error
+
+	// DeleteCategory removes a category owned by the user, or returns ErrNotFound.
+	// Its subcategories become top-level categories and its transactions keep the category name.
+	DeleteCategory(userID, id int64) error
+
+	// GetCategory returns a category owned by the user, or ErrNotFound.
+	GetCategory(userID, id int64) (category.Category, error)
+
+	// GetCategories returns the user's categories ordered by name.
+	GetCategories(userID int64) ([]category.Category, error)
+}
+
+// FrequentExpenseStore keeps the users' frequent expenses and the history they are suggested from.
+type FrequentExpenseStore interface {
+	// SeedFrequentExpenses creates the configured frequent expenses for a user who has none,
+	// linking the accounts they name when the user has them.
+	SeedFrequentExpenses(userID int64, seeds []config.FrequentExpense) error
+
+	// InsertFrequentExpense saves a new frequent expense and returns its generated ID. It returns
+	// ErrFrequentExpenseExists if the user already has one with the name and ErrAccountNotFound.
+	InsertFrequentExpense(e frequent.Expense) (int64, error)
+
+	// UpdateFrequentExpense overwrites a frequent expense owned by e.UserID.
+	// It returns ErrNotFound, ErrFrequentExpenseExists or ErrAccountNotFound.
+	UpdateFrequentExpense(e frequent.Expense) error
+
+	// DeleteFrequentExpense removes a frequent expense owned by the user, or returns ErrNotFound.
+	DeleteFrequentExpense(userID, id int64) error
+
+	// GetFrequentExpense returns a frequent expense owned by the user, or ErrNotFound.
+	GetFrequentExpense(userID, id int64) (frequent.Expense, error)
+
+	// GetFrequentExpenses returns the user's frequent expenses ordered by name.
+	GetFrequentExpenses(userID int64) ([]frequent.Expense, error)
+
+	// GetExpenseHistory returns the user's expenses dated on or after the date (YYYY-MM-DD), in no particular order.
+	GetExpenseHistory(userID int64, since string) ([]frequent.Occurrence, error)
+}
+
+// IdempotencyStore remembers the requests made with an idempotency key and their responses.
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey records that the request of rec is being processed and forgets the keys created
+	// before the date. If the user's key was created after it, it returns that record and ErrIdempotencyKeyUsed.
+	ReserveIdempotencyKey(rec IdempotencyRecord, since time.Time) (IdempotencyRecord, error)
+
+	// CompleteIdempotencyKey stores the status code and body of the response to the request reserved with the key.
+	CompleteIdempotencyKey(rec IdempotencyRecord) error
+
+	// ReleaseIdempotencyKey forgets the user's key, so that the request can be made again.
+	ReleaseIdempotencyKey(userID int64, key string) error
+}
+
+// Store is everything the bot, the HTTP handlers and the admin commands need from persistence.
+type Store interface {
+	TransactionStore
+	ExchangeRateStore
+	AttachmentStore
+	RecurringStore
+	BudgetStore
+	AccountStore
+	CategoryStore
+	FrequentExpenseStore
+	IdempotencyStore
+}
+
+var (
+	_ Store = (*SQLStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*FileStore)(nil)
+)
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"main/pkg/transaction"
+	"strings"
+)
+
+// TagMatch selects how TransactionFilter.Tags is matched.
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any" // At least one of the tags
+	TagMatchAll TagMatch = "all" // Every one of the tags
+)
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// setTransactionTags replaces the tags of a transaction, creating the user's tags that do not exist yet.
+// It is run in the same database transaction as the insert or update of the transaction.
+func setTransactionTags(tx execer, userID, transactionID int64, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM transaction_tags WHERE transaction_id = $1`, transactionID); err != nil {
+		log.Printf("Error clearing tags of transaction %d: %v", transactionID, err)
+		return fmt.Errorf("database delete of transaction tags failed: %w", err)
+	}
+
+	for _, tag := range tags {
+		if _, err := tx.Exec(`
+            INSERT INTO tags (user_id, name) VALUES ($1, $2)
+            ON CONFLICT (user_id, name) DO NOTHING;
+        `, userID, tag); err != nil {
+			log.Printf("Error saving tag %q: %v", tag, err)
+			return fmt.Errorf("database insert of tag failed: %w", err)
+		}
+		if _, err := tx.Exec(`
+            INSERT INTO transaction_tags (transaction_id, tag_id)
+            SELECT $1, id FROM tags WHERE user_id = $2 AND name = $3;
+        `, transactionID, userID, tag); err != nil {
+			log.Printf("Error tagging transaction %d with %q: %v", transactionID, tag, err)
+			return fmt.Errorf("database insert of transaction tag failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadTags fills in the tags of the transactions with a single query.
+func (s *SQLStore) loadTags(transactions []transaction.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*transaction.Transaction, len(transactions))
+	placeholders := make([]string, len(transactions))
+	args := make([]interface{}, len(transactions))
+	for i := range transactions {
+		transactions[i].Tags = []string{}
+		byID[transactions[i].ID] = &transactions[i]
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = transactions[i].ID
+	}
+
+	querySQL := `
+		SELECT tt.transaction_id, g.name
+		FROM transaction_tags tt
+		JOIN tags g ON g.id = tt.tag_id
+		WHERE tt.transaction_id IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY g.name;
+	`
+	rows, err := s.db.Query(querySQL, args...)
+	if err != nil {
+		log.Printf("Error querying transaction tags: %v", err)
+		return fmt.Errorf("database query for transaction tags failed: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows for transaction tags: %v", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var id int64
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			log.Printf("Error scanning transaction tag row: %v", err)
+			return fmt.Errorf("failed to scan transaction tag row: %w", err)
+		}
+		if t, ok := byID[id]; ok {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		log.Printf("Error iterating transaction tag rows: %v", err)
+		return fmt.Errorf("error during transaction tag row iteration: %w", err)
+	}
+	return nil
+}
+
+// tagCondition returns the SQL condition matching transactions tagged with any or all of the tags,
+// numbering placeholders from argID.
+func tagCondition(tags []string, match TagMatch, args []interface{}, argID int) (string, []interface{}, int) {
+	placeholders := make([]string, len(tags))
+	for i, tag := range tags {
+		placeholders[i] = fmt.Sprintf("$%d", argID)
+		args = append(args, tag)
+		argID++
+	}
+
+	subquery := `SELECT tt.transaction_id FROM transaction_tags tt JOIN tags g ON g.id = tt.tag_id
+		WHERE g.name IN (` + strings.Join(placeholders, ", ") + `)`
+	if match == TagMatchAll {
+		subquery += fmt.Sprintf(" GROUP BY tt.transaction_id HAVING COUNT(*) = %d", len(tags))
+	}
+	return "id IN (" + subquery + ")", args, argID
+}
+
+// matchesTags reports whether a transaction has any or all of the tags, mirroring tagCondition.
+func matchesTags(t transaction.Transaction, tags []string, match TagMatch) bool {
+	found := 0
+	for _, tag := range tags {
+		if containsString(t.Tags, tag) {
+			found++
+		}
+	}
+	if match == TagMatchAll {
+		return found == len(tags)
+	}
+	return found > 0
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"fmt"
+	"main/pkg/transaction"
+	"strconv"
+)
+
+// GroupBy selects the transaction attribute that GetTotals groups by.
+type GroupBy string
+
+const (
+	GroupByCategory      GroupBy = "category"
+	GroupByIsClaimable   GroupBy = "is_claimable"
+	GroupByPaidForFamily GroupBy = "paid_for_family"
+	// GroupByClaimStatus groups by transaction.ClaimStatus, "" for transactions that are not claimable.
+	GroupByClaimStatus GroupBy = "claim_status"
+	// GroupByType groups by transaction.Type and is the only grouping that includes income and transfers.
+	GroupByType GroupBy = "type"
+	// GroupByTag counts a transaction once for each of its tags and leaves out untagged transactions.
+	GroupByTag GroupBy = "tag"
+)
+
+// Total is the summed amount of one group of transactions in one currency.
+// Transactions converted into different base currencies (or not converted at all,
+// BaseCurrency "") are reported as separate totals.
+type Total struct {
+	Group        string // Group value, "true"/"false" for boolean groupings
+	Currency     string
+	Amount       int64 // In minor units of Currency
+	BaseCurrency string
+	BaseAmount   int64 // Sum of the recorded conversions, in minor units of BaseCurrency
+}
+
+// DateRange limits an aggregation to transactions dated From to To, inclusive.
+// Both are YYYY-MM-DD; an empty bound is open.
+type DateRange struct {
+	From string
+	To   string
+}
+
+// contains reports whether the YYYY-MM-DD date is within the range.
+func (r DateRange) contains(date string) bool {
+	return (r.From == "" || date >= r.From) && (r.To == "" || date <= r.To)
+}
+
+// conditions appends the SQL conditions and arguments for the range, numbering placeholders from argID.
+// It returns the next free placeholder number.
+func (r DateRange) conditions(conditions []string, args []interface{}, argID int) ([]string, []interface{}, int) {
+	if r.From != "" {
+		conditions = append(conditions, fmt.Sprintf("date >= $%d", argID))
+		args = append(args, r.From)
+		argID++
+	}
+	if r.To != "" {
+		conditions = append(conditions, fmt.Sprintf("date <= $%d", argID))
+		args = append(args, r.To)
+		argID++
+	}
+	return conditions, args, argID
+}
+
+// column returns the SQL column for the grouping, qualified for a query on transactions t
+// joined with their tags g. Only whitelisted columns are ever interpolated into queries.
+func (g GroupBy) column() (string, error) {
+	switch g {
+	case GroupByCategory, GroupByIsClaimable, GroupByPaidForFamily, GroupByClaimStatus, GroupByType:
+		return "t." + string(g), nil
+	case GroupByTag:
+		return "g.name", nil
+	default:
+		return "", fmt.Errorf("unsupported grouping %q", string(g))
+	}
+}
+
+// values returns the groups a transaction belongs to, formatted like format does for SQL rows.
+func (g GroupBy) values(t transaction.Transaction) []string {
+	switch g {
+	case GroupByIsClaimable:
+		return []string{strconv.FormatBool(t.IsClaimable)}
+	case GroupByPaidForFamily:
+		return []string{strconv.FormatBool(t.PaidForFamily)}
+	case GroupByClaimStatus:
+		return []string{string(t.CurrentClaimStatus())}
+	case GroupByType:
+		return []string{string(t.TransactionType())}
+	case GroupByTag:
+		return t.Tags
+	default:
+		return []string{t.Category}
+	}
+}
+
+// format normalises a scanned group value. Drivers disagree on booleans:
+// Postgres returns bool while SQLite returns 0 or 1.
+func (g GroupBy) format(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(value)
+	case int64:
+		if g == GroupByIsClaimable || g == GroupByPaidForFamily {
+			return strconv.FormatBool(value != 0)
+		}
+		return strconv.FormatInt(value, 10)
+	case []byte:
+		return string(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"main/pkg/transaction"
+	"time"
+)
+
+// InsertTransaction inserts a new transaction and its tags into the database and returns its ID.
+func (s *SQLStore) InsertTransaction(t transaction.Transaction) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	id, err := insertTransaction(tx, t)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction insert: %w", err)
+	}
+
+	log.Printf("Successfully inserted transaction with ID: %d", id)
+	return id, nil
+}
+
+// BatchError is returned when the transaction at Index of a batch could not be saved; none of the batch was.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("transaction %d of the batch: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// InsertTransactions inserts the transactions and their tags in one database transaction and returns their IDs,
+// in order. If one of them fails, none are saved and a *BatchError tells which.
+func (s *SQLStore) InsertTransactions(ts []transaction.Transaction) ([]int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	ids := make([]int64, 0, len(ts))
+	for i, t := range ts {
+		id, err := insertTransaction(tx, t)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, &BatchError{Index: i, Err: err}
+		}
+		ids = append(ids, id)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit batch insert: %w", err)
+	}
+
+	log.Printf("Successfully inserted a batch of %d transactions", len(ids))
+	return ids, nil
+}
+
+// insertTransactionSQL inserts a transaction from transactionArgs, it is completed with a RETURNING clause.
+const insertTransactionSQL = `
+        INSERT INTO transactions (user_id, name, amount_minor, currency, date, is_claimable, paid_for_family, category,
+                                  base_currency, exchange_rate, base_amount_minor, recurring_rule_id,
+                                  claim_status, claim_reference, claim_submitted_at, claim_resolved_at, type,
+                                  account_id, to_account_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`
+
+// transactionArgs returns the arguments of insertTransactionSQL.
+func transactionArgs(t transaction.Transaction) []interface{} {
+	return []interface{}{
+		t.UserID,
+		t.Name,
+		t.Amount,
+		t.Currency,
+		t.Date,
+		t.IsClaimable,
+		t.PaidForFamily,
+		t.Category,
+		nullString(t.BaseCurrency),
+		nullString(t.ExchangeRate),
+		nullBaseAmount(t),
+		sql.NullInt64{Int64: t.RecurringRuleID, Valid: t.RecurringRuleID != 0},
+		nullString(string(t.CurrentClaimStatus())),
+		nullString(t.ClaimReference),
+		nullTime(t.ClaimSubmittedAt),
+		nullTime(t.ClaimResolvedAt),
+		string(t.TransactionType()),
+		nullID(t.AccountID),
+		nullID(t.ToAccountID),
+	}
+}
+
+// insertTransaction inserts a transaction and its tags within a database transaction.
+// It returns ErrAccountNotFound if an account of the transaction is not owned by the user.
+func insertTransaction(tx *sql.Tx, t transaction.Transaction) (int64, error) {
+	if err := checkAccounts(tx, t); err != nil {
+		return 0, err
+	}
+	var insertedID int64
+	err := tx.QueryRow(insertTransactionSQL+" RETURNING id;", transactionArgs(t)...).Scan(&insertedID)
+	if err != nil {
+		log.Printf("Error inserting transaction into database: %v", err)
+		return 0, fmt.Errorf("database insert failed: %w", err)
+	}
+	if err = setTransactionTags(tx, t.UserID, insertedID, t.Tags); err != nil {
+		return 0, err
+	}
+	return insertedID, nil
+}
+
+// UpdateTransaction overwrites the editable fields and the tags of a transaction owned by t.UserID.
+// It returns ErrNotFound if the transaction does not exist or belongs to someone else.
+func (s *SQLStore) UpdateTransaction(t transaction.Transaction) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	if err = updateTransaction(tx, t); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction update: %w", err)
+	}
+
+	log.Printf("Successfully updated transaction with ID: %d", t.ID)
+	return nil
+}
+
+// updateTransaction updates a transaction and its tags within a database transaction.
+// The claim is kept, it only changes through UpdateClaim, unless the transaction is no longer claimable.
+func updateTransaction(tx *sql.Tx, t transaction.Transaction) error {
+	if err := checkAccounts(tx, t); err != nil {
+		return err
+	}
+	updateSQL := `
+        UPDATE transactions
+        SET name = $1, amount_minor = $2, currency = $3, date = $4, is_claimable = $5, paid_for_family = $6, category = $7,
+            base_currency = $8, exchange_rate = $9, base_amount_minor = $10,
+            claim_status = CASE WHEN $5 THEN COALESCE(claim_status, 'claimable') END,
+            claim_reference = CASE WHEN $5 THEN claim_reference END,
+            claim_submitted_at = CASE WHEN $5 THEN claim_submitted_at END,
+            claim_resolved_at = CASE WHEN $5 THEN claim_resolved_at END,
+            type = $13, account_id = $14, to_account_id = $15
+        WHERE id = $11 AND user_id = $12;
+    `
+	result, err := tx.Exec(
+		updateSQL,
+		t.Name,
+		t.Amount,
+		t.Currency,
+		t.Date,
+		t.IsClaimable,
+		t.PaidForFamily,
+		t.Category,
+		nullString(t.BaseCurrency),
+		nullString(t.ExchangeRate),
+		nullBaseAmount(t),
+		t.ID,
+		t.UserID,
+		string(t.TransactionType()),
+		nullID(t.AccountID),
+		nullID(t.ToAccountID),
+	)
+	if err != nil {
+		log.Printf("Error updating transaction %d: %v", t.ID, err)
+		return fmt.Errorf("database update failed: %w", err)
+	}
+	if err = expectAffected(result); err != nil {
+		return err
+	}
+	return setTransactionTags(tx, t.UserID, t.ID, t.Tags)
+}
+
+// DeleteTransaction removes a transaction owned by the user.
+// It returns ErrNotFound if the transaction does not exist or belongs to someone else.
+func (s *SQLStore) DeleteTransaction(userID, id int64) error {
+	result, err := s.db.Exec(`DELETE FROM transactions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Printf("Error deleting transaction %d: %v", id, err)
+		return fmt.Errorf("database delete failed: %w", err)
+	}
+	if err = expectAffected(result); err != nil {
+		return err
+	}
+
+	log.Printf("Successfully deleted transaction with ID: %d", id)
+	return nil
+}
+
+// nullString stores empty strings as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullTime stores nil times as NULL.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// timePointer is the inverse of nullTime.
+func timePointer(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// nullID stores unset references, ID 0, as NULL.
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+// nullBaseAmount stores the base amount as NULL for transactions that were not converted.
+func nullBaseAmount(t transaction.Transaction) sql.NullInt64 {
+	return sql.NullInt64{Int64: t.BaseAmount, Valid: t.BaseCurrency != ""}
+}
+
+// expectAffected turns an UPDATE or DELETE that matched no rows into ErrNotFound.
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AssignOwner gives every transaction without an owner to the given user.
+// It is used to claim rows recorded before transactions were scoped per user.
+func (s *SQLStore) AssignOwner(userID int64) (int64, error) {
+	result, err := s.db.Exec(`UPDATE transactions SET user_id = $1 WHERE user_id IS NULL`, userID)
+	if err != nil {
+		log.Printf("Error assigning owner to transactions: %v", err)
+		return 0, fmt.Errorf("database update failed: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,16 @@
+package transaction
+
+import "time"
+
+// Attachment is a file, such as a receipt photo, linked to a transaction.
+// The content lives in a blob store under BlobKey.
+type Attachment struct {
+	ID            int64     `db:"id" json:"id"`
+	TransactionID int64     `db:"transaction_id" json:"transactionId"`
+	UserID        int64     `db:"user_id" json:"-"`
+	FileName      string    `db:"file_name" json:"fileName"`
+	ContentType   string    `db:"content_type" json:"contentType"`
+	Size          int64     `db:"size_bytes" json:"size"`
+	BlobKey       string    `db:"blob_key" json:"-"`
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,136 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClaimStatus is the progress of the reimbursement of a claimable transaction.
+type ClaimStatus string
+
+const (
+	ClaimNone       ClaimStatus = "" // Not claimable
+	ClaimClaimable  ClaimStatus = "claimable"
+	ClaimSubmitted  ClaimStatus = "submitted"
+	ClaimReimbursed ClaimStatus = "reimbursed"
+	ClaimRejected   ClaimStatus = "rejected"
+)
+
+// maxClaimReferenceLength keeps references to the size of an expense report or ticket number.
+const maxClaimReferenceLength = 100
+
+// ErrClaimTransition is returned for a status change the claim lifecycle does not allow.
+var ErrClaimTransition = errors.New("invalid claim status change")
+
+// claimTransitions lists the statuses each status can move to. A claim can be paid back without
+// being submitted first, and a rejected claim can be submitted again; a reimbursed claim is final.
+var claimTransitions = map[ClaimStatus][]ClaimStatus{
+	ClaimClaimable: {ClaimSubmitted, ClaimReimbursed},
+	ClaimSubmitted: {ClaimReimbursed, ClaimRejected},
+	ClaimRejected:  {ClaimSubmitted},
+}
+
+// ClaimStatuses lists the statuses of claimable transactions in lifecycle order.
+var ClaimStatuses = []ClaimStatus{ClaimClaimable, ClaimSubmitted, ClaimReimbursed, ClaimRejected}
+
+// ParseClaimStatus parses a claim status, ignoring case.
+func ParseClaimStatus(s string) (ClaimStatus, error) {
+	status := ClaimStatus(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range ClaimStatuses {
+		if status == known {
+			return status, nil
+		}
+	}
+	return ClaimNone, fmt.Errorf("unknown claim status %q, use claimable, submitted, reimbursed or rejected", s)
+}
+
+// Outstanding reports whether the claim has not been paid back or rejected yet.
+func (s ClaimStatus) Outstanding() bool {
+	return s == ClaimClaimable || s == ClaimSubmitted
+}
+
+// CanBecome reports whether the lifecycle allows moving from s to next.
+func (s ClaimStatus) CanBecome(next ClaimStatus) bool {
+	for _, allowed := range claimTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentClaimStatus returns the claim status, ClaimClaimable for a claimable transaction
+// that has not been given a status yet.
+func (t Transaction) CurrentClaimStatus() ClaimStatus {
+	if !t.IsClaimable {
+		return ClaimNone
+	}
+	if t.ClaimStatus == ClaimNone {
+		return ClaimClaimable
+	}
+	return t.ClaimStatus
+}
+
+// MoveClaim changes the claim status, recording at as the submission time or the time it was
+// reimbursed or rejected. The reference replaces the current one unless it is empty.
+func (t *Transaction) MoveClaim(status ClaimStatus, reference string, at time.Time) error {
+	current := t.CurrentClaimStatus()
+	if current == ClaimNone {
+		return fmt.Errorf("%w: transaction %d is not claimable", ErrClaimTransition, t.ID)
+	}
+	if !current.CanBecome(status) {
+		return fmt.Errorf("%w from %s to %s", ErrClaimTransition, current, status)
+	}
+	reference = strings.TrimSpace(reference)
+	if err := ValidateClaimReference(reference); err != nil {
+		return err
+	}
+
+	at = at.UTC().Truncate(time.Second)
+	t.ClaimStatus = status
+	if status == ClaimSubmitted {
+		t.ClaimSubmittedAt, t.ClaimResolvedAt = &at, nil
+	} else {
+		t.ClaimResolvedAt = &at
+	}
+	if reference != "" {
+		t.ClaimReference = reference
+	}
+	return nil
+}
+
+// StartClaim gives a new transaction the claim it starts with: ClaimClaimable without a reference or
+// times when it is claimable, none otherwise. From there the claim only moves through MoveClaim.
+func (t *Transaction) StartClaim() {
+	t.ClaimStatus, t.ClaimReference, t.ClaimSubmittedAt, t.ClaimResolvedAt = ClaimNone, "", nil, nil
+	t.ClaimStatus = t.CurrentClaimStatus()
+}
+
+// normalizeClaim keeps the claim fields consistent with IsClaimable: a transaction that is
+// not claimable has no claim, and a claimable one starts as ClaimClaimable.
+func (t *Transaction) normalizeClaim() error {
+	if !t.IsClaimable {
+		t.ClaimStatus, t.ClaimReference, t.ClaimSubmittedAt, t.ClaimResolvedAt = ClaimNone, "", nil, nil
+		return nil
+	}
+	if t.ClaimStatus != ClaimNone {
+		status, err := ParseClaimStatus(string(t.ClaimStatus))
+		if err != nil {
+			return err
+		}
+		t.ClaimStatus = status
+	}
+	t.ClaimStatus = t.CurrentClaimStatus()
+	t.ClaimReference = strings.TrimSpace(t.ClaimReference)
+	return ValidateClaimReference(t.ClaimReference)
+}
+
+// ValidateClaimReference checks that a claim reference fits, e.g. an expense report or ticket number.
+func ValidateClaimReference(reference string) error {
+	if len(strings.TrimSpace(reference)) > maxClaimReferenceLength {
+		return fmt.Errorf("claim reference must be at most %d characters", maxClaimReferenceLength)
+	}
+	return nil
+}
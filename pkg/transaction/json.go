@@ -0,0 +1,58 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/pkg/money"
+)
+
+// transactionAlias has the fields of Transaction without its JSON methods.
+type transactionAlias Transaction
+
+// transactionJSON is the wire format of a Transaction. The amount is written as an exact decimal
+// in the currency's major unit (12.50 rather than 12.5000001), and the minor units are included
+// so clients never have to parse decimals.
+type transactionJSON struct {
+	*transactionAlias
+	Amount      json.Number `json:"amount"`
+	AmountMinor *int64      `json:"amountMinor,omitempty"`
+	BaseAmount  json.Number `json:"baseAmount,omitempty"` // Output only, in BaseCurrency
+}
+
+// MarshalJSON writes the amount losslessly as both a decimal and minor units.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	alias := transactionAlias(t)
+	minor := t.Amount
+	out := transactionJSON{
+		transactionAlias: &alias,
+		Amount:           json.Number(money.Format(t.Amount, t.Currency)),
+		AmountMinor:      &minor,
+	}
+	if t.BaseCurrency != "" {
+		out.BaseAmount = json.Number(money.Format(t.BaseAmount, t.BaseCurrency))
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON accepts the amount either as "amountMinor" or as a decimal "amount"
+// (a JSON number or string) in the transaction's currency. Decimals are never rounded.
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	aux := transactionJSON{transactionAlias: (*transactionAlias)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	switch {
+	case aux.AmountMinor != nil:
+		t.Amount = *aux.AmountMinor
+	case aux.Amount != "":
+		amount, err := money.Parse(aux.Amount.String(), t.Currency)
+		if err != nil {
+			return fmt.Errorf("invalid amount: %w", err)
+		}
+		t.Amount = amount
+	default:
+		t.Amount = 0
+	}
+	return nil
+}
@@ -3,8 +3,14 @@ package transaction
 // PaginatedTransactionsResponse defines the structure for paginated transaction results.
 type PaginatedTransactionsResponse struct {
 	Transactions []Transaction `json:"transactions"`
-	CurrentPage  int           `json:"currentPage"`
+	CurrentPage  *int          `json:"currentPage"` // Null when a cursor was followed
 	PageSize     int           `json:"pageSize"`
-	TotalItems   int           `json:"totalItems"`
-	TotalPages   int           `json:"totalPages"`
+	Sort         string        `json:"sort"`
+	// NextCursor and PrevCursor fetch the following and preceding pages, absent at the ends of the listing.
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	// TotalItems and TotalPages are always counted without a cursor, and with one only on request
+	// (include_total=true); null otherwise.
+	TotalItems *int `json:"totalItems"`
+	TotalPages *int `json:"totalPages"`
 }
@@ -0,0 +1,76 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/pkg/money"
+)
+
+// Patch is a partial update of a transaction; nil fields are left unchanged.
+type Patch struct {
+	Name          *string      `json:"name"`
+	Type          *Type        `json:"type"`
+	Amount        *json.Number `json:"amount"`      // Decimal in the (possibly patched) currency
+	AmountMinor   *int64       `json:"amountMinor"` // Takes precedence over Amount
+	Currency      *string      `json:"currency"`
+	Date          *string      `json:"date"`
+	IsClaimable   *bool        `json:"isClaimable"`
+	PaidForFamily *bool        `json:"paidForFamily"`
+	Category      *string      `json:"category"`
+	Tags          *[]string    `json:"tags"`        // Replaces all tags, [] removes them
+	AccountID     *int64       `json:"accountId"`   // 0 unlinks the account
+	ToAccountID   *int64       `json:"toAccountId"` // 0 unlinks the destination account of a transfer
+}
+
+// ApplyTo copies every field set in the patch onto the transaction.
+// When only the currency changes, the amount keeps its decimal value, so 12 SGD becomes 12 JPY.
+func (p Patch) ApplyTo(t *Transaction) error {
+	if p.Name != nil {
+		t.Name = *p.Name
+	}
+	if p.Type != nil {
+		t.Type = *p.Type
+	}
+	if p.Currency != nil {
+		if p.Amount == nil && p.AmountMinor == nil {
+			amount, err := money.Parse(money.Format(t.Amount, t.Currency), *p.Currency)
+			if err != nil {
+				return fmt.Errorf("invalid amount for the new currency: %w", err)
+			}
+			t.Amount = amount
+		}
+		t.Currency = *p.Currency
+	}
+	switch {
+	case p.AmountMinor != nil:
+		t.Amount = *p.AmountMinor
+	case p.Amount != nil:
+		amount, err := money.Parse(p.Amount.String(), t.Currency)
+		if err != nil {
+			return fmt.Errorf("invalid amount: %w", err)
+		}
+		t.Amount = amount
+	}
+	if p.Date != nil {
+		t.Date = *p.Date
+	}
+	if p.IsClaimable != nil {
+		t.IsClaimable = *p.IsClaimable
+	}
+	if p.PaidForFamily != nil {
+		t.PaidForFamily = *p.PaidForFamily
+	}
+	if p.Category != nil {
+		t.Category = *p.Category
+	}
+	if p.Tags != nil {
+		t.Tags = *p.Tags
+	}
+	if p.AccountID != nil {
+		t.AccountID = *p.AccountID
+	}
+	if p.ToAccountID != nil {
+		t.ToAccountID = *p.ToAccountID
+	}
+	return nil
+}
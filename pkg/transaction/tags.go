@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxTagLength matches the tags.name column.
+const maxTagLength = 50
+
+// ParseTags splits user input such as "#japan-trip-2025, wedding" into normalised tags.
+func ParseTags(s string) ([]string, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	return NormalizeTags(fields)
+}
+
+// NormalizeTags lowercases the tags, strips a leading "#", removes duplicates and sorts them.
+// Tags may contain letters, digits, "-" and "_".
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+		for _, r := range tag {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+				return nil, fmt.Errorf("tag %q may only contain letters, digits, '-' and '_'", tag)
+			}
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
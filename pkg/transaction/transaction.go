@@ -1,33 +1,108 @@
 package transaction
 
 import (
+	"errors"
 	"fmt"
+	"main/pkg/money"
 	"strconv"
+	"strings"
 	"time"
 )
 
-const inputDateFormat = "02.01.06"
+const (
+	inputDateFormat  = "02.01.06"
+	storedDateFormat = "2006-01-02"
+)
 
 // Transaction represents a user's transaction data.
 type Transaction struct {
 	ID            int64     `db:"id" json:"id"`
+	UserID        int64     `db:"user_id" json:"userId"` // Telegram chat ID of the owner
+	Type          Type      `db:"type" json:"type"`      // TypeExpense when empty
 	Name          string    `db:"name" json:"name"`
-	Amount        float32   `db:"amount" json:"amount"`
+	Amount        int64     `db:"amount_minor" json:"amount"` // In minor units of Currency, see package money
 	Currency      string    `db:"currency" json:"currency"`
 	Date          string    `db:"date" json:"date"`
 	IsClaimable   bool      `db:"is_claimable" json:"isClaimable"`
 	PaidForFamily bool      `db:"paid_for_family" json:"paidForFamily"`
 	Category      string    `db:"category" json:"category"`
 	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+	Tags          []string  `json:"tags"` // Normalised by Validate, see NormalizeTags
+
+	// AccountID is the account paid from, or paid into for income, 0 when none was picked.
+	// Transfers move the money from AccountID into ToAccountID, which is 0 for other types.
+	AccountID   int64 `db:"account_id" json:"accountId,omitempty"`
+	ToAccountID int64 `db:"to_account_id" json:"toAccountId,omitempty"`
+
+	// The reimbursement of a claimable transaction, all empty when it is not claimable.
+	// The status only changes along the claim lifecycle, see MoveClaim.
+	ClaimStatus      ClaimStatus `db:"claim_status" json:"claimStatus,omitempty"`
+	ClaimReference   string      `db:"claim_reference" json:"claimReference,omitempty"` // E.g. the expense report number
+	ClaimSubmittedAt *time.Time  `db:"claim_submitted_at" json:"claimSubmittedAt,omitempty"`
+	ClaimResolvedAt  *time.Time  `db:"claim_resolved_at" json:"claimResolvedAt,omitempty"` // When it was reimbursed or rejected
+
+	// RecurringRuleID is the recurring rule that recorded the transaction, 0 for transactions entered by hand.
+	RecurringRuleID int64 `db:"recurring_rule_id" json:"recurringRuleId,omitempty"`
+
+	// The conversion into the reporting currency, recorded with the rate in effect on Date.
+	// All empty when no base currency or rate was available when the transaction was saved.
+	BaseCurrency string `db:"base_currency" json:"baseCurrency,omitempty"`
+	ExchangeRate string `db:"exchange_rate" json:"exchangeRate,omitempty"` // One unit of Currency in BaseCurrency
+	BaseAmount   int64  `db:"base_amount_minor" json:"baseAmountMinor,omitempty"`
 }
 
-// ValidateAmount checks if the amount is a valid number.
-func ValidateAmount(amountStr string) (float32, error) {
-	f, err := strconv.ParseFloat(amountStr, 32)
+// ValidateAmount checks if the amount is a valid number in the currency
+// and returns it in the currency's minor units.
+func ValidateAmount(amountStr string, currency string) (int64, error) {
+	amount, err := money.Parse(amountStr, currency)
 	if err != nil {
 		return 0, fmt.Errorf("invalid amount: %w", err)
 	}
-	return float32(f), nil
+	return amount, nil
+}
+
+// FormattedAmount renders the amount as a decimal in the transaction's currency, e.g. "12.50".
+func (t Transaction) FormattedAmount() string {
+	return money.Format(t.Amount, t.Currency)
+}
+
+// Validate checks that the transaction can be stored, normalises its date to YYYY-MM-DD, its tags, its type and its claim.
+// Dates are accepted either as YYYY-MM-DD or as the RFC 3339 timestamps the API returns.
+func (t *Transaction) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("name is required")
+	}
+	if t.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+	if strings.TrimSpace(t.Currency) == "" {
+		return errors.New("currency is required")
+	}
+
+	date, err := ParseDate(t.Date)
+	if err != nil {
+		return err
+	}
+	t.Date = date.Format(storedDateFormat)
+
+	if t.Tags, err = NormalizeTags(t.Tags); err != nil {
+		return err
+	}
+	if err = t.normalizeType(); err != nil {
+		return err
+	}
+	return t.normalizeClaim()
+}
+
+// ParseDate parses a transaction date given as YYYY-MM-DD or as an RFC 3339 timestamp.
+func ParseDate(s string) (time.Time, error) {
+	if d, err := time.Parse(storedDateFormat, s); err == nil {
+		return d, nil
+	}
+	if d, err := time.Parse(time.RFC3339, s); err == nil {
+		return d, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
 }
 
 // ValidateBool checks if the string is a valid boolean.
@@ -41,13 +116,13 @@ func ValidateBool(s string) (bool, error) {
 
 func ProcessDate(answer string) string {
 	if answer == "t" {
-		return time.Now().Format("2006-01-02")
+		return time.Now().Format(storedDateFormat)
 	}
 
 	t, err := time.Parse(inputDateFormat, answer)
 	if err != nil {
-		return time.Now().Format("2006-01-02")
+		return time.Now().Format(storedDateFormat)
 	}
 
-	return t.Format("2006-01-02")
+	return t.Format(storedDateFormat)
 }
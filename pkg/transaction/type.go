@@ -0,0 +1,64 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Type tells whether money was spent, earned or moved between the user's own accounts.
+type Type string
+
+const (
+	TypeExpense  Type = "expense"
+	TypeIncome   Type = "income"
+	TypeTransfer Type = "transfer" // Neither spent nor earned, left out of income and expense totals
+)
+
+// Types lists the transaction types.
+var Types = []Type{TypeExpense, TypeIncome, TypeTransfer}
+
+// ParseType parses a transaction type, ignoring case.
+func ParseType(s string) (Type, error) {
+	t := Type(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Types {
+		if t == known {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown transaction type %q, use expense, income or transfer", s)
+}
+
+// TransactionType returns the type, TypeExpense for transactions recorded without one.
+func (t Transaction) TransactionType() Type {
+	if t.Type == "" {
+		return TypeExpense
+	}
+	return t.Type
+}
+
+// normalizeType defaults the type to TypeExpense and checks that only expenses are claimable
+// and only transfers move money into a second account.
+func (t *Transaction) normalizeType() error {
+	if t.Type != "" {
+		parsed, err := ParseType(string(t.Type))
+		if err != nil {
+			return err
+		}
+		t.Type = parsed
+	}
+	t.Type = t.TransactionType()
+	if t.Type != TypeExpense && t.IsClaimable {
+		return fmt.Errorf("only expenses can be claimable, not %s", t.Type)
+	}
+	if t.AccountID < 0 || t.ToAccountID < 0 {
+		return errors.New("account IDs must be positive")
+	}
+	if t.ToAccountID != 0 && t.Type != TypeTransfer {
+		return fmt.Errorf("only transfers can have a destination account, not %s", t.Type)
+	}
+	if t.ToAccountID != 0 && t.ToAccountID == t.AccountID {
+		return errors.New("a transfer must move money between two different accounts")
+	}
+	return nil
+}